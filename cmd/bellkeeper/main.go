@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
+	"gorm.io/gorm/logger"
 )
 
 var (
@@ -56,7 +58,28 @@ func main() {
 		Run:   runMigrate,
 	}
 
-	rootCmd.AddCommand(serveCmd, versionCmd, migrateCmd)
+	rssCmd := &cobra.Command{
+		Use:   "rss",
+		Short: "Manage RSS subscriptions",
+	}
+
+	rssImportCmd := &cobra.Command{
+		Use:   "import <file.opml>",
+		Short: "Import RSS feeds from an OPML file",
+		Args:  cobra.ExactArgs(1),
+		Run:   runRSSImport,
+	}
+
+	rssExportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export RSS feeds as OPML",
+		Run:   runRSSExport,
+	}
+	rssExportCmd.Flags().StringP("output", "o", "", "output file (default is stdout)")
+
+	rssCmd.AddCommand(rssImportCmd, rssExportCmd)
+
+	rootCmd.AddCommand(serveCmd, versionCmd, migrateCmd, rssCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -121,10 +144,15 @@ func runServer(cmd *cobra.Command, args []string) {
 
 	// Create http.Server for graceful shutdown
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+	// Requests derive from baseCtx, which is cancelled on shutdown so that
+	// long-lived streams such as job events end instead of holding it up
+	baseCtx, cancelBase := context.WithCancel(context.Background())
 	srv := &http.Server{
-		Addr:    addr,
-		Handler: r,
+		Addr:        addr,
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
+	srv.RegisterOnShutdown(cancelBase)
 
 	// Start server in goroutine
 	go func() {
@@ -134,6 +162,9 @@ func runServer(cmd *cobra.Command, args []string) {
 		}
 	}()
 
+	// Start background workers (job queue, scheduler, RSS fetcher, crawler, ...)
+	services.Start()
+
 	// Wait for shutdown signal (OS signal or restart request)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -153,6 +184,9 @@ func runServer(cmd *cobra.Command, args []string) {
 		log.Printf("Server forced shutdown: %v", err)
 	}
 
+	// Stop background workers before closing the database they write to
+	services.Stop()
+
 	// Close database connection
 	if sqlDB, err := db.DB(); err == nil {
 		sqlDB.Close()
@@ -178,3 +212,78 @@ func runMigrate(cmd *cobra.Command, args []string) {
 
 	log.Println("Database migrations completed successfully")
 }
+
+// initServices loads configuration, migrates the database and builds the service layer for CLI commands.
+func initServices() (*service.Services, func()) {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := model.InitDB(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	// SQL tracing goes to stdout and would corrupt command output such as an OPML export
+	db.Logger = db.Logger.LogMode(logger.Silent)
+
+	if err := model.AutoMigrate(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	closeDB := func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	return service.NewServices(repository.NewRepositories(db), cfg, version), closeDB
+}
+
+func runRSSImport(cmd *cobra.Command, args []string) {
+	file, err := os.Open(args[0])
+	if err != nil {
+		log.Fatalf("Failed to open OPML file: %v", err)
+	}
+	defer file.Close()
+
+	services, closeDB := initServices()
+	defer closeDB()
+
+	summary, err := services.RSS.ImportOPML(file)
+	if err != nil {
+		log.Fatalf("Failed to import OPML: %v", err)
+	}
+
+	for _, r := range summary.Results {
+		line := fmt.Sprintf("%-8s %s", r.Status, r.URL)
+		if r.Message != "" {
+			line += " (" + r.Message + ")"
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("Imported %d feeds: %d created, %d skipped, %d failed\n",
+		summary.Total, summary.Created, summary.Skipped, summary.Failed)
+}
+
+func runRSSExport(cmd *cobra.Command, args []string) {
+	output, _ := cmd.Flags().GetString("output")
+
+	services, closeDB := initServices()
+	defer closeDB()
+
+	data, err := services.RSS.ExportOPML()
+	if err != nil {
+		log.Fatalf("Failed to export OPML: %v", err)
+	}
+
+	if output == "" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			log.Fatalf("Failed to write OPML: %v", err)
+		}
+		return
+	}
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		log.Fatalf("Failed to write OPML: %v", err)
+	}
+	log.Printf("Exported RSS feeds to %s", output)
+}
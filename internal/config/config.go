@@ -8,12 +8,20 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	RagFlow  RagFlowConfig  `mapstructure:"ragflow"`
-	N8N      N8NConfig      `mapstructure:"n8n"`
-	Logging  LoggingConfig  `mapstructure:"logging"`
-	Features FeatureConfig  `mapstructure:"features"`
+	Server       ServerConfig       `mapstructure:"server"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	RagFlow      RagFlowConfig      `mapstructure:"ragflow"`
+	N8N          N8NConfig          `mapstructure:"n8n"`
+	RSS          RSSConfig          `mapstructure:"rss"`
+	Crawler      CrawlerConfig      `mapstructure:"crawler"`
+	Watch        WatchConfig        `mapstructure:"watch"`
+	GitHub       GitHubConfig       `mapstructure:"github"`
+	Reachability ReachabilityConfig `mapstructure:"reachability"`
+	Jobs         JobsConfig         `mapstructure:"jobs"`
+	Scheduler    SchedulerConfig    `mapstructure:"scheduler"`
+	Webhooks     WebhooksConfig     `mapstructure:"webhooks"`
+	Logging      LoggingConfig      `mapstructure:"logging"`
+	Features     FeatureConfig      `mapstructure:"features"`
 }
 
 type ServerConfig struct {
@@ -50,6 +58,90 @@ type N8NConfig struct {
 	APIKey         string `mapstructure:"api_key"`
 }
 
+type RSSConfig struct {
+	FetcherEnabled bool         `mapstructure:"fetcher_enabled"`
+	PollInterval   int          `mapstructure:"poll_interval"` // seconds between scans for due feeds
+	Timeout        int          `mapstructure:"timeout"`       // per-request timeout in seconds
+	Concurrency    int          `mapstructure:"concurrency"`   // max feeds fetched in parallel
+	UserAgent      string       `mapstructure:"user_agent"`
+	WebSub         WebSubConfig `mapstructure:"websub"`
+}
+
+// WebSubConfig controls push subscriptions to feeds that advertise a WebSub hub.
+type WebSubConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	PublicBaseURL string `mapstructure:"public_base_url"` // externally reachable base URL for hub callbacks
+	LeaseSeconds  int    `mapstructure:"lease_seconds"`   // requested subscription lease
+}
+
+// CrawlerConfig controls the website crawler. MaxDepth and MaxPages are the
+// defaults for data sources that do not set their own in metadata.
+type CrawlerConfig struct {
+	Enabled      bool `mapstructure:"enabled"`
+	PollInterval int  `mapstructure:"poll_interval"` // seconds between scans for due data sources
+	Interval     int  `mapstructure:"interval"`      // minutes between crawls of the same data source
+	MaxDepth     int  `mapstructure:"max_depth"`     // link hops followed from the start URL
+	MaxPages     int  `mapstructure:"max_pages"`     // pages fetched per crawl
+	Delay        int  `mapstructure:"delay"`         // per-host politeness delay in milliseconds
+}
+
+// WatchConfig controls change detection for data sources marked as watched.
+// MinChange is the share of the extracted text (0-1) that must differ before a
+// page is re-ingested, so whitespace and trivial edits are ignored.
+type WatchConfig struct {
+	Enabled      bool    `mapstructure:"enabled"`
+	PollInterval int     `mapstructure:"poll_interval"` // seconds between scans for due data sources
+	Interval     int     `mapstructure:"interval"`      // minutes between checks of the same data source
+	MinChange    float64 `mapstructure:"min_change"`    // minimum changed share of the text
+}
+
+// GitHubConfig controls the sync of github data sources. APIBaseURL can point
+// at GitHub Enterprise or a local fake; Token is optional but raises rate limits.
+type GitHubConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	APIBaseURL   string `mapstructure:"api_base_url"`
+	Token        string `mapstructure:"token"`
+	Timeout      int    `mapstructure:"timeout"`       // per-request timeout in seconds
+	PollInterval int    `mapstructure:"poll_interval"` // seconds between scans for due data sources
+	Interval     int    `mapstructure:"interval"`      // minutes between syncs of the same data source
+}
+
+// ReachabilityConfig controls the periodic reachability check of data source URLs.
+type ReachabilityConfig struct {
+	Enabled      bool `mapstructure:"enabled"`
+	PollInterval int  `mapstructure:"poll_interval"` // seconds between scans for due data sources
+	Interval     int  `mapstructure:"interval"`      // minutes between checks of the same data source
+	Timeout      int  `mapstructure:"timeout"`       // per-request timeout in seconds
+	Concurrency  int  `mapstructure:"concurrency"`   // max data sources checked in parallel
+}
+
+// JobsConfig controls the worker pool of the persistent job queue. A failed job
+// is retried after RetryDelay, doubled for every further attempt.
+type JobsConfig struct {
+	Enabled       bool `mapstructure:"enabled"`
+	Workers       int  `mapstructure:"workers"`        // jobs run in parallel
+	PollInterval  int  `mapstructure:"poll_interval"`  // seconds between queue scans when idle
+	Timeout       int  `mapstructure:"timeout"`        // minutes a single attempt may run
+	MaxAttempts   int  `mapstructure:"max_attempts"`   // attempts before a job is marked failed
+	RetryDelay    int  `mapstructure:"retry_delay"`    // seconds before the first retry
+	RetentionDays int  `mapstructure:"retention_days"` // days finished jobs are kept
+}
+
+// SchedulerConfig controls the runner of cron schedules. Timezone is the IANA
+// zone of schedules that do not set their own; empty means the server zone.
+type SchedulerConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	PollInterval int    `mapstructure:"poll_interval"` // seconds between scans for due schedules
+	Timeout      int    `mapstructure:"timeout"`       // minutes a single run may take
+	Timezone     string `mapstructure:"timezone"`
+}
+
+// WebhooksConfig controls the runner of webhook delivery retries. The retry
+// policy itself is set per webhook.
+type WebhooksConfig struct {
+	PollInterval int `mapstructure:"poll_interval"` // seconds between scans for due retries
+}
+
 type LoggingConfig struct {
 	Level  string `mapstructure:"level"`
 	Format string `mapstructure:"format"`
@@ -125,6 +217,63 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("n8n.api_base_url", "http://n8n:5678/api/v1")
 	v.SetDefault("n8n.api_key", "")
 
+	// RSS
+	v.SetDefault("rss.fetcher_enabled", true)
+	v.SetDefault("rss.poll_interval", 60)
+	v.SetDefault("rss.timeout", 30)
+	v.SetDefault("rss.concurrency", 4)
+	v.SetDefault("rss.user_agent", "Bellkeeper/1.0 (+https://github.com/singll/Bellkeeper)")
+	v.SetDefault("rss.websub.enabled", false)
+	v.SetDefault("rss.websub.public_base_url", "")
+	v.SetDefault("rss.websub.lease_seconds", 864000)
+
+	// Crawler
+	v.SetDefault("crawler.enabled", false)
+	v.SetDefault("crawler.poll_interval", 300)
+	v.SetDefault("crawler.interval", 1440)
+	v.SetDefault("crawler.max_depth", 2)
+	v.SetDefault("crawler.max_pages", 50)
+	v.SetDefault("crawler.delay", 1000)
+
+	// Watch
+	v.SetDefault("watch.enabled", false)
+	v.SetDefault("watch.poll_interval", 300)
+	v.SetDefault("watch.interval", 360)
+	v.SetDefault("watch.min_change", 0.01)
+
+	// GitHub
+	v.SetDefault("github.enabled", false)
+	v.SetDefault("github.api_base_url", "https://api.github.com")
+	v.SetDefault("github.token", "")
+	v.SetDefault("github.timeout", 30)
+	v.SetDefault("github.poll_interval", 600)
+	v.SetDefault("github.interval", 360)
+
+	// Reachability
+	v.SetDefault("reachability.enabled", false)
+	v.SetDefault("reachability.poll_interval", 300)
+	v.SetDefault("reachability.interval", 60)
+	v.SetDefault("reachability.timeout", 10)
+	v.SetDefault("reachability.concurrency", 4)
+
+	// Jobs
+	v.SetDefault("jobs.enabled", true)
+	v.SetDefault("jobs.workers", 4)
+	v.SetDefault("jobs.poll_interval", 5)
+	v.SetDefault("jobs.timeout", 30)
+	v.SetDefault("jobs.max_attempts", 3)
+	v.SetDefault("jobs.retry_delay", 30)
+	v.SetDefault("jobs.retention_days", 7)
+
+	// Scheduler
+	v.SetDefault("scheduler.enabled", true)
+	v.SetDefault("scheduler.poll_interval", 30)
+	v.SetDefault("scheduler.timeout", 30)
+	v.SetDefault("scheduler.timezone", "")
+
+	// Webhooks
+	v.SetDefault("webhooks.poll_interval", 10)
+
 	// Logging
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
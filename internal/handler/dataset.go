@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -139,6 +140,10 @@ func (h *DatasetHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.svc.Delete(id); err != nil {
+		if errors.Is(err, service.ErrDatasetMappingNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -1,18 +1,37 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
 	"github.com/singll/bellkeeper/internal/pkg/response"
 	"github.com/singll/bellkeeper/internal/service"
 )
 
 type DataSourceHandler struct {
-	svc *service.DataSourceService
+	svc     *service.DataSourceService
+	crawler *service.CrawlerService
+	watcher *service.WatcherService
+	github  *service.GitHubService
+	reach   *service.ReachabilityService
 }
 
-func NewDataSourceHandler(svc *service.DataSourceService) *DataSourceHandler {
-	return &DataSourceHandler{svc: svc}
+func NewDataSourceHandler(
+	svc *service.DataSourceService,
+	crawler *service.CrawlerService,
+	watcher *service.WatcherService,
+	github *service.GitHubService,
+	reach *service.ReachabilityService,
+) *DataSourceHandler {
+	return &DataSourceHandler{svc: svc, crawler: crawler, watcher: watcher, github: github, reach: reach}
 }
 
 type DataSourceRequest struct {
@@ -23,6 +42,14 @@ type DataSourceRequest struct {
 	Description string `json:"description"`
 	IsActive    *bool  `json:"is_active"`
 	TagIDs      []uint `json:"tag_ids"`
+	// ExtractFullText ingests only the readability-extracted article instead of the whole page
+	ExtractFullText *bool `json:"extract_full_text"`
+	// Watch re-checks the page periodically and re-ingests it when it changes
+	Watch *bool `json:"watch"`
+	// Crawl replaces the crawl depth/page budget when present; send {} to use the defaults
+	Crawl *service.CrawlSettings `json:"crawl"`
+	// GitHub replaces the repository settings of github data sources when present; send {} to clear them
+	GitHub *service.GitHubSettings `json:"github"`
 }
 
 func (h *DataSourceHandler) List(c *gin.Context) {
@@ -74,8 +101,31 @@ func (h *DataSourceHandler) Create(c *gin.Context) {
 		Description: req.Description,
 		IsActive:    isActive,
 	}
+	if req.ExtractFullText != nil {
+		source.ExtractFullText = *req.ExtractFullText
+	}
+	if req.Watch != nil {
+		source.Watch = *req.Watch
+	}
+	if req.Crawl != nil {
+		if err := h.svc.SetCrawlSettings(source, *req.Crawl); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+	}
+	if req.GitHub != nil {
+		if err := h.svc.SetGitHubSettings(source, *req.GitHub); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+	}
 
 	if err := h.svc.Create(source, req.TagIDs); err != nil {
+		var dupErr *service.DuplicateURLError
+		if errors.As(err, &dupErr) {
+			response.Conflict(c, dupErr.Error(), dupErr)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -104,13 +154,39 @@ func (h *DataSourceHandler) Update(c *gin.Context) {
 	source.Name = req.Name
 	source.URL = req.URL
 	source.Type = req.Type
+	if source.Type == "" {
+		source.Type = model.DataSourceTypeWebsite
+	}
 	source.Category = req.Category
 	source.Description = req.Description
 	if req.IsActive != nil {
 		source.IsActive = *req.IsActive
 	}
+	if req.ExtractFullText != nil {
+		source.ExtractFullText = *req.ExtractFullText
+	}
+	if req.Watch != nil {
+		source.Watch = *req.Watch
+	}
+	if req.Crawl != nil {
+		if err := h.svc.SetCrawlSettings(source, *req.Crawl); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+	}
+	if req.GitHub != nil {
+		if err := h.svc.SetGitHubSettings(source, *req.GitHub); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+	}
 
 	if err := h.svc.Update(source, req.TagIDs); err != nil {
+		var dupErr *service.DuplicateURLError
+		if errors.As(err, &dupErr) {
+			response.Conflict(c, dupErr.Error(), dupErr)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -131,3 +207,328 @@ func (h *DataSourceHandler) Delete(c *gin.Context) {
 
 	response.Deleted(c)
 }
+
+// Import creates or updates data sources from a CSV or JSON file sent as the
+// multipart "file" field or as the raw request body. The format is taken from
+// ?format=, the file extension or the content type; ?dry_run=true only reports
+// which rows would be created, updated or rejected. Files over
+// MaxImportBodySize are refused with 413.
+func (h *DataSourceHandler) Import(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	filename, contentType := "", c.ContentType()
+	if strings.HasPrefix(contentType, "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			response.BadRequest(c, "file is required")
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		defer file.Close()
+		body = file
+		filename, contentType = fileHeader.Filename, fileHeader.Header.Get("Content-Type")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, defaults.MaxImportBodySize+1))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if len(data) > defaults.MaxImportBodySize {
+		response.Error(c, http.StatusRequestEntityTooLarge, "import file too large")
+		return
+	}
+
+	format := importFormat(c.Query("format"), filename, contentType)
+	dryRun := c.Query("dry_run") == "true"
+	summary, err := h.svc.ImportDataSources(bytes.NewReader(data), format, dryRun)
+	switch {
+	case errors.Is(err, service.ErrUnsupportedFormat), errors.Is(err, service.ErrInvalidImportFile):
+		response.BadRequest(c, err.Error())
+		return
+	case err != nil:
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, summary)
+}
+
+// Export downloads all data sources as CSV (default) or, with ?format=json, as JSON
+func (h *DataSourceHandler) Export(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", service.DataSourceFormatCSV))
+	data, err := h.svc.ExportDataSources(format)
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedFormat) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == service.DataSourceFormatJSON {
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Header("Content-Disposition", `attachment; filename="bellkeeper-datasources.`+format+`"`)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// importFormat picks the import format from an explicit value, the uploaded
+// file name or the content type, in that order. It returns "" when unknown.
+func importFormat(format, filename, contentType string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return service.DataSourceFormatCSV
+	case ".json":
+		return service.DataSourceFormatJSON
+	}
+	switch {
+	case strings.Contains(contentType, "csv"):
+		return service.DataSourceFormatCSV
+	case strings.Contains(contentType, "json"):
+		return service.DataSourceFormatJSON
+	}
+	return ""
+}
+
+// Ingest fetches the data source page and uploads it to RagFlow
+func (h *DataSourceHandler) Ingest(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	source, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "data source not found")
+		return
+	}
+
+	// Body is optional: {"refresh": true} bypasses the extraction cache
+	var req struct {
+		Refresh bool `json:"refresh"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	result, err := h.svc.Ingest(c.Request.Context(), source, req.Refresh)
+	if err != nil {
+		response.Error(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Crawl crawls the data source right away and queues discovered pages as candidates
+func (h *DataSourceHandler) Crawl(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	source, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "data source not found")
+		return
+	}
+
+	result, err := h.crawler.Crawl(c.Request.Context(), source)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotCrawlable):
+			response.BadRequest(c, err.Error())
+		case errors.Is(err, service.ErrCrawlInProgress):
+			response.Error(c, http.StatusConflict, err.Error())
+		default:
+			response.Error(c, http.StatusBadGateway, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Check re-fetches a watched data source right away and re-ingests it if it changed
+func (h *DataSourceHandler) Check(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	source, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "data source not found")
+		return
+	}
+
+	result, err := h.watcher.Check(c.Request.Context(), source)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotWatched):
+			response.BadRequest(c, err.Error())
+		case errors.Is(err, service.ErrCheckInProgress):
+			response.Error(c, http.StatusConflict, err.Error())
+		default:
+			response.Error(c, http.StatusBadGateway, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Snapshot returns the last ingested version recorded for a watched data source
+func (h *DataSourceHandler) Snapshot(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	snapshot, err := h.watcher.GetSnapshot(id)
+	if err != nil {
+		response.NotFound(c, "snapshot not found")
+		return
+	}
+
+	response.Success(c, snapshot)
+}
+
+// Status returns the latest reachability check of a data source; ?refresh=true checks it right away
+func (h *DataSourceHandler) Status(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	source, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "data source not found")
+		return
+	}
+
+	if c.Query("refresh") == "true" {
+		status, err := h.reach.Check(c.Request.Context(), source)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+		response.Success(c, status)
+		return
+	}
+
+	status, err := h.reach.GetStatus(id)
+	if err != nil {
+		response.NotFound(c, "data source has not been checked yet")
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// GitHubSync syncs the releases and docs of a github data source right away
+func (h *DataSourceHandler) GitHubSync(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	source, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "data source not found")
+		return
+	}
+
+	result, err := h.github.Sync(c.Request.Context(), source)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotGitHub):
+			response.BadRequest(c, err.Error())
+		case errors.Is(err, service.ErrSyncInProgress):
+			response.Error(c, http.StatusConflict, err.Error())
+		default:
+			response.Error(c, http.StatusBadGateway, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GitHubItems lists the releases and files ingested for a github data source, optionally filtered by kind
+func (h *DataSourceHandler) GitHubItems(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.svc.GetByID(id); err != nil {
+		response.NotFound(c, "data source not found")
+		return
+	}
+
+	items, err := h.github.ListItems(id, c.Query("kind"))
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, items)
+}
+
+// Candidates lists the candidate articles discovered for a data source
+func (h *DataSourceHandler) Candidates(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.svc.GetByID(id); err != nil {
+		response.NotFound(c, "data source not found")
+		return
+	}
+
+	page, perPage := response.ParsePagination(c)
+	candidates, total, err := h.crawler.ListCandidates(page, perPage, id, c.Query("status"), c.Query("keyword"))
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Page(c, candidates, total, page, perPage)
+}
+
+// ListCandidates lists candidate articles across all data sources, optionally filtered by data_source_id
+func (h *DataSourceHandler) ListCandidates(c *gin.Context) {
+	page, perPage := response.ParsePagination(c)
+
+	var dataSourceID uint
+	if raw := c.Query("data_source_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			response.BadRequest(c, "invalid data_source_id")
+			return
+		}
+		dataSourceID = uint(id)
+	}
+
+	candidates, total, err := h.crawler.ListCandidates(page, perPage, dataSourceID, c.Query("status"), c.Query("keyword"))
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Page(c, candidates, total, page, perPage)
+}
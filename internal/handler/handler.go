@@ -9,6 +9,7 @@ type Handlers struct {
 	Tag        *TagHandler
 	DataSource *DataSourceHandler
 	RSS        *RSSHandler
+	WebSub     *WebSubHandler
 	Webhook    *WebhookHandler
 	Dataset    *DatasetHandler
 	Setting    *SettingHandler
@@ -16,20 +17,27 @@ type Handlers struct {
 	Health     *HealthHandler
 	Workflow   *WorkflowHandler
 	System     *SystemHandler
+	Job        *JobHandler
+	Schedule   *ScheduleHandler
+	Inbound    *InboundHookHandler
 }
 
 // NewHandlers creates all handler instances
 func NewHandlers(services *service.Services, shutdownChan chan struct{}) *Handlers {
 	return &Handlers{
 		Tag:        NewTagHandler(services.Tag),
-		DataSource: NewDataSourceHandler(services.DataSource),
-		RSS:        NewRSSHandler(services.RSS),
+		DataSource: NewDataSourceHandler(services.DataSource, services.Crawler, services.Watcher, services.GitHub, services.Reachability),
+		RSS:        NewRSSHandler(services.RSS, services.RSSFetcher, services.Jobs),
+		WebSub:     NewWebSubHandler(services.RSSFetcher),
 		Webhook:    NewWebhookHandler(services.Webhook),
 		Dataset:    NewDatasetHandler(services.Dataset),
 		Setting:    NewSettingHandler(services.Setting),
-		RagFlow:    NewRagFlowHandler(services.RagFlow),
+		RagFlow:    NewRagFlowHandler(services.RagFlow, services.Jobs),
 		Health:     NewHealthHandler(services.Health),
 		Workflow:   NewWorkflowHandler(services.Workflow),
 		System:     NewSystemHandler(shutdownChan),
+		Job:        NewJobHandler(services.Jobs),
+		Schedule:   NewScheduleHandler(services.Scheduler),
+		Inbound:    NewInboundHookHandler(services.Inbound),
 	}
 }
@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/response"
+	"github.com/singll/bellkeeper/internal/service"
+	"gorm.io/datatypes"
+)
+
+// InboundTokenHeader carries the token of requests to token inbound hooks,
+// as an alternative to a bearer token.
+const InboundTokenHeader = "X-Bellkeeper-Token"
+
+// InboundHookHandler manages inbound hooks and serves their public endpoint.
+// Receive is outside the authenticated API; requests are tied to a hook by its
+// slug and authenticated by its token or HMAC signature.
+type InboundHookHandler struct {
+	svc *service.InboundHookService
+}
+
+// InboundHookRequest creates or updates an inbound hook. Mapping holds the
+// title, url, content, category and tags of a document as paths into the
+// payload or literal values.
+type InboundHookRequest struct {
+	Name            string          `json:"name" binding:"required"`
+	Slug            string          `json:"slug" binding:"required"`
+	Description     string          `json:"description"`
+	AuthType        string          `json:"auth_type"`
+	SignatureHeader string          `json:"signature_header"`
+	TimestampHeader string          `json:"timestamp_header"`
+	Mapping         json.RawMessage `json:"mapping"`
+	IsActive        *bool           `json:"is_active"`
+	// Secret replaces the token or HMAC secret when present
+	Secret *string `json:"secret"`
+}
+
+func NewInboundHookHandler(svc *service.InboundHookService) *InboundHookHandler {
+	return &InboundHookHandler{svc: svc}
+}
+
+func (h *InboundHookHandler) List(c *gin.Context) {
+	page, perPage := response.ParsePagination(c)
+
+	hooks, total, err := h.svc.List(page, perPage)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Page(c, hooks, total, page, perPage)
+}
+
+func (h *InboundHookHandler) Get(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	hook, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "inbound hook not found")
+		return
+	}
+
+	response.Success(c, hook)
+}
+
+func (h *InboundHookHandler) Create(c *gin.Context) {
+	var req InboundHookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	hook := &model.InboundHook{
+		Name:            req.Name,
+		Slug:            req.Slug,
+		Description:     req.Description,
+		AuthType:        req.AuthType,
+		SignatureHeader: req.SignatureHeader,
+		TimestampHeader: req.TimestampHeader,
+		Mapping:         datatypes.JSON(req.Mapping),
+		IsActive:        isActive,
+	}
+	if req.Secret != nil {
+		hook.Secret = strings.TrimSpace(*req.Secret)
+	}
+
+	if err := h.svc.Create(hook); err != nil {
+		inboundHookError(c, err)
+		return
+	}
+
+	response.Created(c, hook)
+}
+
+func (h *InboundHookHandler) Update(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	hook, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "inbound hook not found")
+		return
+	}
+
+	var req InboundHookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	hook.Name = req.Name
+	hook.Slug = req.Slug
+	hook.Description = req.Description
+	hook.AuthType = req.AuthType
+	hook.SignatureHeader = req.SignatureHeader
+	hook.TimestampHeader = req.TimestampHeader
+	hook.Mapping = datatypes.JSON(req.Mapping)
+	if req.IsActive != nil {
+		hook.IsActive = *req.IsActive
+	}
+	if req.Secret != nil {
+		hook.Secret = strings.TrimSpace(*req.Secret)
+	}
+
+	if err := h.svc.Update(hook); err != nil {
+		inboundHookError(c, err)
+		return
+	}
+
+	response.Success(c, hook)
+}
+
+func (h *InboundHookHandler) Delete(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(id); err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Deleted(c)
+}
+
+// Preview returns the document a hook makes of the JSON payload in the body,
+// without uploading it
+func (h *InboundHookHandler) Preview(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	body, ok := readInboundBody(c)
+	if !ok {
+		return
+	}
+
+	doc, err := h.svc.Preview(id, body)
+	switch {
+	case errors.Is(err, service.ErrInboundHookNotFound):
+		response.NotFound(c, err.Error())
+		return
+	case errors.Is(err, service.ErrInboundPayload):
+		response.BadRequest(c, err.Error())
+		return
+	case err != nil:
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, doc)
+}
+
+// Receive ingests the JSON payload posted to a hook. Duplicates of documents
+// already ingested are acknowledged with 200, new documents with 201.
+func (h *InboundHookHandler) Receive(c *gin.Context) {
+	body, ok := readInboundBody(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.svc.Receive(c.Param("slug"), inboundToken(c), c.Request.Header, body)
+	switch {
+	case errors.Is(err, service.ErrInboundHookNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, service.ErrInboundUnauthorized):
+		response.Error(c, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrInboundPayload):
+		response.Error(c, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, service.ErrInboundUpload):
+		response.BadGateway(c, err.Error(), result)
+	case err != nil:
+		response.InternalError(c, err.Error())
+	case result.Status == model.InboundStatusDuplicate:
+		response.Success(c, result)
+	default:
+		response.Created(c, result)
+	}
+}
+
+// readInboundBody reads a request body up to MaxInboundBodySize, answering
+// 413 for larger ones
+func readInboundBody(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, defaults.MaxInboundBodySize+1))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return nil, false
+	}
+	if len(body) > defaults.MaxInboundBodySize {
+		response.Error(c, http.StatusRequestEntityTooLarge, "request body too large")
+		return nil, false
+	}
+	return body, true
+}
+
+// inboundToken returns the token of a request: a bearer token or the
+// X-Bellkeeper-Token header. Tokens are not taken from the query string,
+// which ends up in access logs.
+func inboundToken(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return c.GetHeader(InboundTokenHeader)
+}
+
+// inboundHookError answers a failed create or update
+func inboundHookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInboundHook):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrInboundSlugTaken):
+		response.Error(c, http.StatusConflict, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
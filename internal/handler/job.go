@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/response"
+	"github.com/singll/bellkeeper/internal/service"
+)
+
+type JobHandler struct {
+	svc *service.JobService
+}
+
+func NewJobHandler(svc *service.JobService) *JobHandler {
+	return &JobHandler{svc: svc}
+}
+
+// List returns jobs newest first, optionally filtered by type and status
+func (h *JobHandler) List(c *gin.Context) {
+	page, perPage := response.ParsePagination(c)
+
+	jobs, total, err := h.svc.List(page, perPage, c.Query("type"), c.Query("status"))
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Page(c, jobs, total, page, perPage)
+}
+
+// Get returns a job with its payload and result
+func (h *JobHandler) Get(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	job, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "job not found")
+		return
+	}
+
+	response.Success(c, job)
+}
+
+// Events streams the progress of a job as Server-Sent Events. The first event
+// is the current state of the job; the stream ends after the event that
+// reports it succeeded or failed.
+func (h *JobHandler) Events(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	events, err := h.svc.Watch(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, "job not found")
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(defaults.JobEventHeartbeatSeconds * time.Second)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			// A comment line, ignored by EventSource
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
+
+// enqueue queues a job for an ?async=true request and answers 202 with the job
+func enqueue(c *gin.Context, jobs *service.JobService, jobType string, payload interface{}) {
+	job, err := jobs.Enqueue(jobType, payload)
+	if err != nil {
+		if errors.Is(err, service.ErrJobsDisabled) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	// The payload only echoes the request
+	job.Payload = nil
+	response.Accepted(c, job)
+}
@@ -10,11 +10,12 @@ import (
 )
 
 type RagFlowHandler struct {
-	svc *service.RagFlowService
+	svc  *service.RagFlowService
+	jobs *service.JobService
 }
 
-func NewRagFlowHandler(svc *service.RagFlowService) *RagFlowHandler {
-	return &RagFlowHandler{svc: svc}
+func NewRagFlowHandler(svc *service.RagFlowService, jobs *service.JobService) *RagFlowHandler {
+	return &RagFlowHandler{svc: svc, jobs: jobs}
 }
 
 func (h *RagFlowHandler) Upload(c *gin.Context) {
@@ -98,7 +99,7 @@ func (h *RagFlowHandler) DeleteDocument(c *gin.Context) {
 		return
 	}
 
-	if err := h.svc.DeleteDocument(datasetID, documentID); err != nil {
+	if err := h.svc.DeleteDocument(c.Request.Context(), datasetID, documentID); err != nil {
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -237,7 +238,7 @@ func (h *RagFlowHandler) GetParsingStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// BatchUpload uploads multiple documents
+// BatchUpload uploads multiple documents; ?async=true queues a job and returns it
 func (h *RagFlowHandler) BatchUpload(c *gin.Context) {
 	var req struct {
 		DatasetID string                  `json:"dataset_id" binding:"required"`
@@ -248,14 +249,22 @@ func (h *RagFlowHandler) BatchUpload(c *gin.Context) {
 		return
 	}
 
-	results, errors := h.svc.BatchUpload(req.DatasetID, req.Documents)
+	if c.Query("async") == "true" {
+		enqueue(c, h.jobs, service.JobTypeBatchUpload, service.BatchUploadJob{
+			DatasetID: req.DatasetID,
+			Documents: req.Documents,
+		})
+		return
+	}
+
+	results, errors := h.svc.BatchUpload(c.Request.Context(), req.DatasetID, req.Documents, nil)
 	c.JSON(http.StatusOK, gin.H{
 		"results": results,
 		"errors":  errors,
 	})
 }
 
-// BatchDeleteDocuments deletes multiple documents
+// BatchDeleteDocuments deletes multiple documents; ?async=true queues a job and returns it
 func (h *RagFlowHandler) BatchDeleteDocuments(c *gin.Context) {
 	var req struct {
 		DatasetID   string   `json:"dataset_id" binding:"required"`
@@ -266,7 +275,15 @@ func (h *RagFlowHandler) BatchDeleteDocuments(c *gin.Context) {
 		return
 	}
 
-	deleted, errors := h.svc.BatchDeleteDocuments(req.DatasetID, req.DocumentIDs)
+	if c.Query("async") == "true" {
+		enqueue(c, h.jobs, service.JobTypeBatchDelete, service.BatchDeleteJob{
+			DatasetID:   req.DatasetID,
+			DocumentIDs: req.DocumentIDs,
+		})
+		return
+	}
+
+	deleted, errors := h.svc.BatchDeleteDocuments(c.Request.Context(), req.DatasetID, req.DocumentIDs, nil)
 	c.JSON(http.StatusOK, gin.H{
 		"deleted": deleted,
 		"errors":  errors,
@@ -285,7 +302,7 @@ func (h *RagFlowHandler) TransferDocument(c *gin.Context) {
 		return
 	}
 
-	result, err := h.svc.TransferDocument(req.SourceDatasetID, req.TargetDatasetID, req.DocumentID)
+	result, err := h.svc.TransferDocument(c.Request.Context(), req.SourceDatasetID, req.TargetDatasetID, req.DocumentID)
 	if err != nil {
 		response.InternalError(c, err.Error())
 		return
@@ -353,7 +370,7 @@ func (h *RagFlowHandler) DeleteChunks(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// BatchTransferDocuments transfers multiple documents between datasets
+// BatchTransferDocuments transfers multiple documents between datasets; ?async=true queues a job and returns it
 func (h *RagFlowHandler) BatchTransferDocuments(c *gin.Context) {
 	var req struct {
 		SourceDatasetID string   `json:"source_dataset_id" binding:"required"`
@@ -365,7 +382,16 @@ func (h *RagFlowHandler) BatchTransferDocuments(c *gin.Context) {
 		return
 	}
 
-	result, err := h.svc.BatchTransferDocuments(req.SourceDatasetID, req.TargetDatasetID, req.DocumentIDs)
+	if c.Query("async") == "true" {
+		enqueue(c, h.jobs, service.JobTypeBatchTransfer, service.BatchTransferJob{
+			SourceDatasetID: req.SourceDatasetID,
+			TargetDatasetID: req.TargetDatasetID,
+			DocumentIDs:     req.DocumentIDs,
+		})
+		return
+	}
+
+	result, err := h.svc.BatchTransferDocuments(c.Request.Context(), req.SourceDatasetID, req.TargetDatasetID, req.DocumentIDs, nil)
 	if err != nil {
 		response.InternalError(c, err.Error())
 		return
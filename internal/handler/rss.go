@@ -1,6 +1,12 @@
 package handler
 
 import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/singll/bellkeeper/internal/model"
 	"github.com/singll/bellkeeper/internal/pkg/defaults"
@@ -9,11 +15,13 @@ import (
 )
 
 type RSSHandler struct {
-	svc *service.RSSService
+	svc     *service.RSSService
+	fetcher *service.RSSFetcher
+	jobs    *service.JobService
 }
 
-func NewRSSHandler(svc *service.RSSService) *RSSHandler {
-	return &RSSHandler{svc: svc}
+func NewRSSHandler(svc *service.RSSService, fetcher *service.RSSFetcher, jobs *service.JobService) *RSSHandler {
+	return &RSSHandler{svc: svc, fetcher: fetcher, jobs: jobs}
 }
 
 type RSSRequest struct {
@@ -23,7 +31,11 @@ type RSSRequest struct {
 	Description          string `json:"description"`
 	IsActive             *bool  `json:"is_active"`
 	FetchIntervalMinutes int    `json:"fetch_interval_minutes"`
+	AutoIngest           *bool  `json:"auto_ingest"`
+	ExtractFullText      *bool  `json:"extract_full_text"`
 	TagIDs               []uint `json:"tag_ids"`
+	// Filters replaces the feed's filter rules when present; send {} to remove them
+	Filters *service.FeedFilters `json:"filters"`
 }
 
 func (h *RSSHandler) List(c *gin.Context) {
@@ -31,7 +43,13 @@ func (h *RSSHandler) List(c *gin.Context) {
 	category := c.Query("category")
 	keyword := c.Query("keyword")
 
-	feeds, total, err := h.svc.List(page, perPage, category, keyword)
+	health := c.Query("health")
+	if health != "" && health != "healthy" && health != "unhealthy" {
+		response.BadRequest(c, "health must be healthy or unhealthy")
+		return
+	}
+
+	feeds, total, err := h.svc.List(page, perPage, category, keyword, health)
 	if err != nil {
 		response.InternalError(c, err.Error())
 		return
@@ -75,12 +93,29 @@ func (h *RSSHandler) Create(c *gin.Context) {
 		IsActive:             isActive,
 		FetchIntervalMinutes: req.FetchIntervalMinutes,
 	}
+	if req.AutoIngest != nil {
+		feed.AutoIngest = *req.AutoIngest
+	}
+	if req.ExtractFullText != nil {
+		feed.ExtractFullText = *req.ExtractFullText
+	}
 
 	if feed.FetchIntervalMinutes == 0 {
 		feed.FetchIntervalMinutes = defaults.DefaultFetchInterval
 	}
+	if req.Filters != nil {
+		if err := h.svc.SetFilters(feed, *req.Filters); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+	}
 
 	if err := h.svc.Create(feed, req.TagIDs); err != nil {
+		var dupErr *service.DuplicateURLError
+		if errors.As(err, &dupErr) {
+			response.Conflict(c, dupErr.Error(), dupErr)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
@@ -106,6 +141,7 @@ func (h *RSSHandler) Update(c *gin.Context) {
 		return
 	}
 
+	urlChanged := req.URL != feed.URL
 	feed.Name = req.Name
 	feed.URL = req.URL
 	feed.Category = req.Category
@@ -114,11 +150,35 @@ func (h *RSSHandler) Update(c *gin.Context) {
 		feed.IsActive = *req.IsActive
 	}
 	feed.FetchIntervalMinutes = req.FetchIntervalMinutes
+	if req.AutoIngest != nil {
+		feed.AutoIngest = *req.AutoIngest
+	}
+	if req.ExtractFullText != nil {
+		feed.ExtractFullText = *req.ExtractFullText
+	}
+	if req.Filters != nil {
+		if err := h.svc.SetFilters(feed, *req.Filters); err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+	}
 
 	if err := h.svc.Update(feed, req.TagIDs); err != nil {
+		var dupErr *service.DuplicateURLError
+		if errors.As(err, &dupErr) {
+			response.Conflict(c, dupErr.Error(), dupErr)
+			return
+		}
 		response.InternalError(c, err.Error())
 		return
 	}
+	if urlChanged {
+		// Validators and failure history belong to the old URL
+		if err := h.svc.ResetFetchState(feed); err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+	}
 
 	response.Success(c, feed)
 }
@@ -136,3 +196,154 @@ func (h *RSSHandler) Delete(c *gin.Context) {
 
 	response.Deleted(c)
 }
+
+// Entries lists fetched entries for a single feed
+func (h *RSSHandler) Entries(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.svc.GetByID(id); err != nil {
+		response.NotFound(c, "RSS feed not found")
+		return
+	}
+
+	page, perPage := response.ParsePagination(c)
+	entries, total, err := h.svc.ListEntries(page, perPage, id, c.Query("keyword"))
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Page(c, entries, total, page, perPage)
+}
+
+// ListEntries lists fetched entries across all feeds, optionally filtered by feed_id
+func (h *RSSHandler) ListEntries(c *gin.Context) {
+	page, perPage := response.ParsePagination(c)
+
+	var feedID uint
+	if raw := c.Query("feed_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			response.BadRequest(c, "invalid feed_id")
+			return
+		}
+		feedID = uint(id)
+	}
+
+	entries, total, err := h.svc.ListEntries(page, perPage, feedID, c.Query("keyword"))
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Page(c, entries, total, page, perPage)
+}
+
+// ImportOPML creates feeds from an OPML document sent as the multipart "file"
+// field or as the raw request body
+func (h *RSSHandler) ImportOPML(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			response.BadRequest(c, "file is required")
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	summary, err := h.svc.ImportOPML(io.LimitReader(body, defaults.MaxFeedBodySize))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, summary)
+}
+
+// ExportOPML downloads all feeds as an OPML file
+func (h *RSSHandler) ExportOPML(c *gin.Context) {
+	data, err := h.svc.ExportOPML()
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="bellkeeper-feeds.opml"`)
+	c.Data(http.StatusOK, "text/x-opml; charset=utf-8", data)
+}
+
+// Fetch polls a single feed immediately, regardless of its fetch interval; ?async=true queues a job and returns it
+func (h *RSSHandler) Fetch(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	feed, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "RSS feed not found")
+		return
+	}
+
+	if c.Query("async") == "true" {
+		enqueue(c, h.jobs, service.JobTypeFeedFetch, service.FeedFetchJob{FeedID: feed.ID})
+		return
+	}
+
+	result, err := h.fetcher.Fetch(c.Request.Context(), feed, nil)
+	if err != nil {
+		if errors.Is(err, service.ErrFetchInProgress) {
+			response.Error(c, http.StatusConflict, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Preview fetches and parses a feed URL without saving it
+func (h *RSSHandler) Preview(c *gin.Context) {
+	var req struct {
+		URL string `json:"url" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	preview, err := h.fetcher.Preview(c.Request.Context(), req.URL)
+	if err != nil {
+		response.Error(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	response.Success(c, preview)
+}
+
+// WebSub returns the feed's WebSub push subscription
+func (h *RSSHandler) WebSub(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	sub, err := h.fetcher.GetWebSubscription(id)
+	if err != nil {
+		response.NotFound(c, "WebSub subscription not found")
+		return
+	}
+
+	response.Success(c, sub)
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/response"
+	"github.com/singll/bellkeeper/internal/service"
+	"gorm.io/datatypes"
+)
+
+type ScheduleHandler struct {
+	svc *service.SchedulerService
+}
+
+type ScheduleRequest struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description"`
+	Cron        string          `json:"cron" binding:"required"`
+	Timezone    string          `json:"timezone"`
+	Task        string          `json:"task" binding:"required"`
+	Params      json.RawMessage `json:"params"`
+	IsActive    *bool           `json:"is_active"`
+}
+
+func NewScheduleHandler(svc *service.SchedulerService) *ScheduleHandler {
+	return &ScheduleHandler{svc: svc}
+}
+
+func (h *ScheduleHandler) List(c *gin.Context) {
+	page, perPage := response.ParsePagination(c)
+
+	schedules, total, err := h.svc.List(page, perPage)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Page(c, schedules, total, page, perPage)
+}
+
+func (h *ScheduleHandler) Get(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	schedule, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "schedule not found")
+		return
+	}
+
+	response.Success(c, schedule)
+}
+
+func (h *ScheduleHandler) Create(c *gin.Context) {
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	schedule := &model.Schedule{
+		Name:        req.Name,
+		Description: req.Description,
+		Cron:        req.Cron,
+		Timezone:    req.Timezone,
+		Task:        req.Task,
+		Params:      datatypes.JSON(req.Params),
+		IsActive:    isActive,
+	}
+
+	if err := h.svc.Create(schedule); err != nil {
+		scheduleError(c, err)
+		return
+	}
+
+	response.Created(c, schedule)
+}
+
+func (h *ScheduleHandler) Update(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	schedule, err := h.svc.GetByID(id)
+	if err != nil {
+		response.NotFound(c, "schedule not found")
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	schedule.Name = req.Name
+	schedule.Description = req.Description
+	schedule.Cron = req.Cron
+	schedule.Timezone = req.Timezone
+	schedule.Task = req.Task
+	schedule.Params = datatypes.JSON(req.Params)
+	if req.IsActive != nil {
+		schedule.IsActive = *req.IsActive
+	}
+
+	if err := h.svc.Update(schedule); err != nil {
+		scheduleError(c, err)
+		return
+	}
+
+	response.Success(c, schedule)
+}
+
+func (h *ScheduleHandler) Delete(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(id); err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Deleted(c)
+}
+
+// Run runs a schedule immediately and returns it with the outcome of the run
+func (h *ScheduleHandler) Run(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	schedule, err := h.svc.RunNow(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrScheduleRunning) {
+			response.Error(c, http.StatusConflict, err.Error())
+			return
+		}
+		response.NotFound(c, "schedule not found")
+		return
+	}
+
+	response.Success(c, schedule)
+}
+
+// Tasks lists the task names a schedule can run
+func (h *ScheduleHandler) Tasks(c *gin.Context) {
+	response.Success(c, h.svc.Tasks())
+}
+
+// Preview returns the next activations of ?cron= in ?timezone=, 5 by default
+func (h *ScheduleHandler) Preview(c *gin.Context) {
+	count, _ := strconv.Atoi(c.DefaultQuery("count", "5"))
+	if count < 1 || count > 50 {
+		count = 5
+	}
+
+	runs, err := h.svc.Preview(c.Query("cron"), c.Query("timezone"), count)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, runs)
+}
+
+// scheduleError answers a failed create or update
+func scheduleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSchedule):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrScheduleNameTaken):
+		response.Error(c, http.StatusConflict, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
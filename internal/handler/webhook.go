@@ -2,8 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/singll/bellkeeper/internal/model"
@@ -27,6 +31,23 @@ type WebhookRequest struct {
 	TimeoutSeconds int                    `json:"timeout_seconds"`
 	Description    string                 `json:"description"`
 	IsActive       *bool                  `json:"is_active"`
+
+	// MaxAttempts and RetryDelaySeconds replace the retry policy when present
+	MaxAttempts       *int `json:"max_attempts" binding:"omitempty,min=1"`
+	RetryDelaySeconds *int `json:"retry_delay_seconds" binding:"omitempty,min=1"`
+	// RetryStatusCodes replaces the retried response codes when present; send [] to retry network errors only
+	RetryStatusCodes []int `json:"retry_status_codes"`
+	// SigningSecret replaces the signing secret when present; send "" to stop signing
+	SigningSecret *string `json:"signing_secret"`
+}
+
+// SubscriptionRequest subscribes a webhook to events. EventType is a type,
+// a prefix such as document.* or *; Filters holds lists of tags, categories
+// and dataset_ids.
+type SubscriptionRequest struct {
+	EventType string          `json:"event_type" binding:"required"`
+	Filters   json.RawMessage `json:"filters"`
+	IsActive  *bool           `json:"is_active"`
 }
 
 func NewWebhookHandler(svc *service.WebhookService) *WebhookHandler {
@@ -82,16 +103,33 @@ func (h *WebhookHandler) Create(c *gin.Context) {
 		headersJSON = datatypes.JSON(data)
 	}
 
+	retryCodes, ok := retryStatusCodes(c, req.RetryStatusCodes)
+	if !ok {
+		return
+	}
+
 	webhook := &model.WebhookConfig{
-		Name:           req.Name,
-		URL:            req.URL,
-		Method:         req.Method,
-		ContentType:    req.ContentType,
-		Headers:        headersJSON,
-		BodyTemplate:   req.BodyTemplate,
-		TimeoutSeconds: req.TimeoutSeconds,
-		Description:    req.Description,
-		IsActive:       isActive,
+		Name:              req.Name,
+		URL:               req.URL,
+		Method:            req.Method,
+		ContentType:       req.ContentType,
+		Headers:           headersJSON,
+		BodyTemplate:      req.BodyTemplate,
+		TimeoutSeconds:    req.TimeoutSeconds,
+		Description:       req.Description,
+		IsActive:          isActive,
+		MaxAttempts:       defaults.DefaultWebhookMaxAttempts,
+		RetryDelaySeconds: defaults.DefaultWebhookRetryDelay,
+		RetryStatusCodes:  retryCodes,
+	}
+	if req.MaxAttempts != nil {
+		webhook.MaxAttempts = *req.MaxAttempts
+	}
+	if req.RetryDelaySeconds != nil {
+		webhook.RetryDelaySeconds = *req.RetryDelaySeconds
+	}
+	if req.SigningSecret != nil {
+		webhook.SigningSecret = strings.TrimSpace(*req.SigningSecret)
 	}
 
 	if webhook.Method == "" {
@@ -154,6 +192,22 @@ func (h *WebhookHandler) Update(c *gin.Context) {
 	if req.IsActive != nil {
 		webhook.IsActive = *req.IsActive
 	}
+	if req.MaxAttempts != nil {
+		webhook.MaxAttempts = *req.MaxAttempts
+	}
+	if req.RetryDelaySeconds != nil {
+		webhook.RetryDelaySeconds = *req.RetryDelaySeconds
+	}
+	if req.RetryStatusCodes != nil {
+		retryCodes, ok := retryStatusCodes(c, req.RetryStatusCodes)
+		if !ok {
+			return
+		}
+		webhook.RetryStatusCodes = retryCodes
+	}
+	if req.SigningSecret != nil {
+		webhook.SigningSecret = strings.TrimSpace(*req.SigningSecret)
+	}
 
 	if err := h.svc.Update(webhook); err != nil {
 		response.InternalError(c, err.Error())
@@ -221,3 +275,224 @@ func (h *WebhookHandler) History(c *gin.Context) {
 
 	response.Success(c, history)
 }
+
+// Attempts lists the attempt records of a delivery
+func (h *WebhookHandler) Attempts(c *gin.Context) {
+	id, ok := response.ParseID(c, "history_id")
+	if !ok {
+		return
+	}
+
+	attempts, err := h.svc.GetAttempts(id)
+	if err != nil {
+		response.NotFound(c, "webhook history not found")
+		return
+	}
+
+	response.Success(c, attempts)
+}
+
+// Dead lists the deliveries that ran out of attempts, optionally of one ?webhook_id=
+func (h *WebhookHandler) Dead(c *gin.Context) {
+	page, perPage := response.ParsePagination(c)
+	webhookID, _ := strconv.ParseUint(c.Query("webhook_id"), 10, 32)
+
+	deliveries, total, err := h.svc.ListDead(uint(webhookID), page, perPage)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Page(c, deliveries, total, page, perPage)
+}
+
+// Requeue schedules a dead delivery for another round of attempts
+func (h *WebhookHandler) Requeue(c *gin.Context) {
+	id, ok := response.ParseID(c, "history_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.svc.Requeue(id)
+	if err != nil {
+		if errors.Is(err, service.ErrDeliveryNotDead) {
+			response.Error(c, http.StatusConflict, err.Error())
+			return
+		}
+		response.NotFound(c, "webhook history not found")
+		return
+	}
+
+	response.Success(c, delivery)
+}
+
+// Replay re-sends the stored request of a history record as a new delivery.
+// With {"use_current_url": true} it goes to the webhook's current URL; the body
+// may be empty. A failed first attempt answers 502 with the new delivery.
+func (h *WebhookHandler) Replay(c *gin.Context) {
+	id, ok := response.ParseID(c, "history_id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		UseCurrentURL bool `json:"use_current_url"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	history, err := h.svc.Replay(id, req.UseCurrentURL)
+	if err != nil {
+		switch {
+		case history != nil:
+			response.BadGateway(c, err.Error(), history)
+		case errors.Is(err, service.ErrWebhookHistoryNotFound), errors.Is(err, service.ErrWebhookNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, service.ErrWebhookDisabled):
+			response.Error(c, http.StatusConflict, err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, history)
+}
+
+// EventTypes lists the event types webhooks can subscribe to
+func (h *WebhookHandler) EventTypes(c *gin.Context) {
+	response.Success(c, h.svc.EventTypes())
+}
+
+// Subscriptions lists the event subscriptions of a webhook
+func (h *WebhookHandler) Subscriptions(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	subs, err := h.svc.ListSubscriptions(id)
+	if err != nil {
+		response.NotFound(c, "webhook not found")
+		return
+	}
+
+	response.Success(c, subs)
+}
+
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.svc.GetByID(id); err != nil {
+		response.NotFound(c, "webhook not found")
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	sub := &model.WebhookSubscription{
+		WebhookID: id,
+		EventType: req.EventType,
+		Filters:   datatypes.JSON(req.Filters),
+		IsActive:  isActive,
+	}
+
+	if err := h.svc.CreateSubscription(sub); err != nil {
+		subscriptionError(c, err)
+		return
+	}
+
+	response.Created(c, sub)
+}
+
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+	subID, ok := response.ParseID(c, "subscription_id")
+	if !ok {
+		return
+	}
+
+	sub, err := h.svc.GetSubscription(id, subID)
+	if err != nil {
+		response.NotFound(c, "subscription not found")
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	sub.EventType = req.EventType
+	sub.Filters = datatypes.JSON(req.Filters)
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+
+	if err := h.svc.UpdateSubscription(sub); err != nil {
+		subscriptionError(c, err)
+		return
+	}
+
+	response.Success(c, sub)
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+	subID, ok := response.ParseID(c, "subscription_id")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteSubscription(id, subID); err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Deleted(c)
+}
+
+// subscriptionError answers a failed create or update of a subscription
+func subscriptionError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidSubscription) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.InternalError(c, err.Error())
+}
+
+// retryStatusCodes validates the retried response codes of a webhook request
+func retryStatusCodes(c *gin.Context, codes []int) (datatypes.JSON, bool) {
+	if codes == nil {
+		return nil, true
+	}
+	for _, code := range codes {
+		if code < 100 || code > 599 {
+			response.BadRequest(c, fmt.Sprintf("invalid retry status code %d", code))
+			return nil, false
+		}
+	}
+	data, _ := json.Marshal(codes)
+	return datatypes.JSON(data), true
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/response"
+	"github.com/singll/bellkeeper/internal/service"
+)
+
+// WebSubHandler serves the public callback that WebSub hubs call. These routes
+// are outside the authenticated API; requests are tied to a subscription by its
+// ID and pushes are authenticated by their HMAC signature.
+type WebSubHandler struct {
+	fetcher *service.RSSFetcher
+}
+
+func NewWebSubHandler(fetcher *service.RSSFetcher) *WebSubHandler {
+	return &WebSubHandler{fetcher: fetcher}
+}
+
+// Verify answers the hub's verification of intent by echoing hub.challenge
+func (h *WebSubHandler) Verify(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	mode := c.Query("hub.mode")
+	leaseSeconds, _ := strconv.Atoi(c.Query("hub.lease_seconds"))
+	err := h.fetcher.VerifyWebSub(id, mode, c.Query("hub.topic"), leaseSeconds, c.Query("hub.reason"))
+	if err != nil {
+		log.Printf("warn: WebSub verification for subscription %d rejected: %v", id, err)
+		c.String(http.StatusNotFound, "unknown subscription")
+		return
+	}
+
+	if mode == service.WebSubModeDenied {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.String(http.StatusOK, c.Query("hub.challenge"))
+}
+
+// Push receives content distributed by the hub. Per the WebSub spec, pushes with
+// a bad signature are acknowledged but ignored, and 410 Gone tells the hub to drop
+// subscriptions Bellkeeper no longer wants.
+func (h *WebSubHandler) Push(c *gin.Context) {
+	id, ok := response.ParseID(c, "id")
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, defaults.MaxFeedBodySize))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.fetcher.HandlePush(c.Request.Context(), id, c.GetHeader("X-Hub-Signature"), c.ContentType(), body)
+	switch {
+	case errors.Is(err, service.ErrWebSubNotFound):
+		c.Status(http.StatusGone)
+	case errors.Is(err, service.ErrWebSubSignature):
+		log.Printf("warn: ignoring WebSub push for subscription %d: %v", id, err)
+		c.Status(http.StatusAccepted)
+	case errors.Is(err, service.ErrFetchInProgress):
+		// Ask the hub to redeliver later
+		c.Status(http.StatusServiceUnavailable)
+	case err != nil:
+		log.Printf("warn: WebSub push for subscription %d failed: %v", id, err)
+		c.Status(http.StatusInternalServerError)
+	default:
+		log.Printf("RSS feed %d pushed: %d items, %d new, %d updated, %d skipped, %d ingested",
+			result.FeedID, result.Items, result.New, result.Updated, result.Skipped, result.Ingested)
+		c.Status(http.StatusNoContent)
+	}
+}
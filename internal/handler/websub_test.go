@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/repository"
+	"github.com/singll/bellkeeper/internal/service"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testTopic = "https://example.com/feed.xml"
+
+// newTestWebSubServer serves the WebSub callback routes backed by an in-memory
+// SQLite database holding one pending subscription, whose ID it returns.
+func newTestWebSubServer(t *testing.T) (*httptest.Server, uint) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection would get its own in-memory database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&model.Tag{}, &model.RSSFeed{}, &model.FeedEntry{}, &model.WebSubSubscription{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	rssFeed := &model.RSSFeed{Name: "Example", URL: testTopic, IsActive: true}
+	if err := db.Create(rssFeed).Error; err != nil {
+		t.Fatal(err)
+	}
+	sub := &model.WebSubSubscription{FeedID: rssFeed.ID, HubURL: "https://hub.example.com/", TopicURL: testTopic, Secret: "s3cret", State: model.WebSubStatePending}
+	if err := db.Create(sub).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.RSSConfig{WebSub: config.WebSubConfig{Enabled: true, PublicBaseURL: "https://bellkeeper.example.com", LeaseSeconds: 86400}}
+	fetcher := service.NewRSSFetcher(cfg, false,
+		repository.NewRSSRepository(db),
+		repository.NewFeedEntryRepository(db),
+		repository.NewWebSubRepository(db),
+		nil, nil, nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewWebSubHandler(fetcher)
+	r.GET("/websub/callback/:id", h.Verify)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, sub.ID
+}
+
+func TestWebSubVerify(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		mode       string
+		topic      string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "challenge echoed", mode: "subscribe", topic: testTopic, wantStatus: http.StatusOK, wantBody: "c-12345"},
+		{name: "topic mismatch", mode: "subscribe", topic: "https://evil.example.com/feed.xml", wantStatus: http.StatusNotFound, wantBody: "unknown subscription"},
+		{name: "unsubscribe rejected", mode: "unsubscribe", topic: testTopic, wantStatus: http.StatusNotFound, wantBody: "unknown subscription"},
+		{name: "unknown subscription", id: "999", mode: "subscribe", topic: testTopic, wantStatus: http.StatusNotFound, wantBody: "unknown subscription"},
+		{name: "denial acknowledged without echo", mode: "denied", topic: testTopic, wantStatus: http.StatusOK, wantBody: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, id := newTestWebSubServer(t)
+			if tt.id == "" {
+				tt.id = fmt.Sprint(id)
+			}
+
+			query := url.Values{
+				"hub.mode":          {tt.mode},
+				"hub.topic":         {tt.topic},
+				"hub.challenge":     {"c-12345"},
+				"hub.lease_seconds": {"3600"},
+			}
+			resp, err := srv.Client().Get(srv.URL + "/websub/callback/" + tt.id + "?" + query.Encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus || string(body) != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+)
+
+// CandidateArticle statuses
+const (
+	CandidateStatusNew = "new"
+)
+
+// CandidateArticle is a page discovered by crawling a data source that has not
+// been ingested yet. URLs are stored normalized and are unique per data source.
+type CandidateArticle struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	DataSourceID uint       `gorm:"not null;uniqueIndex:idx_candidate_articles_source_url" json:"data_source_id"`
+	URL          string     `gorm:"size:2000;not null;uniqueIndex:idx_candidate_articles_source_url" json:"url"`
+	Title        string     `gorm:"size:1000" json:"title"`
+	FoundOn      string     `gorm:"size:2000" json:"found_on"` // page or sitemap the link was found on
+	Depth        int        `json:"depth"`                     // link hops from the data source URL, 0 for sitemap entries
+	LastModified *time.Time `json:"last_modified,omitempty"`   // sitemap <lastmod>
+	Status       string     `gorm:"size:20;default:'new';index" json:"status"`
+	CreatedAt    time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relations
+	DataSource *DataSource `gorm:"foreignKey:DataSourceID" json:"data_source,omitempty"`
+}
+
+// TableName specifies table name
+func (CandidateArticle) TableName() string {
+	return "candidate_articles"
+}
@@ -7,22 +7,42 @@ import (
 	"gorm.io/gorm"
 )
 
-// DataSource represents a data source for knowledge collection
+// Data source types visited by the crawler and the GitHub sync
+const (
+	DataSourceTypeWebsite = "website" // default: follow same-host links from the URL
+	DataSourceTypeSitemap = "sitemap" // URL points to a sitemap or sitemap index
+	DataSourceTypeGitHub  = "github"  // releases and docs of a GitHub repository, synced through the REST API
+)
+
+// DataSource represents a data source for knowledge collection.
+// LastCrawledAt and LastCrawlError record the latest crawler run;
+// SitemapSyncedAt is the start of the latest sitemap run that read every
+// sitemap file, the baseline of incremental sitemap runs. Watched
+// sources are re-checked periodically and re-ingested when their page changes.
+// NormalizedURL is unique among live sources; it is left empty on legacy
+// duplicates until they are resolved (see BackfillNormalizedURLs).
 type DataSource struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	Name        string         `gorm:"size:200;not null" json:"name"`
-	URL         string         `gorm:"size:1000;not null" json:"url"`
-	Type        string         `gorm:"size:50;default:'website'" json:"type"`
-	Category    string         `gorm:"size:100;index" json:"category"`
-	Description string         `gorm:"type:text" json:"description"`
-	IsActive    bool           `gorm:"default:true" json:"is_active"`
-	Metadata    datatypes.JSON `gorm:"type:jsonb" json:"metadata,omitempty"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Name            string         `gorm:"size:200;not null" json:"name"`
+	URL             string         `gorm:"size:1000;not null" json:"url"`
+	NormalizedURL   string         `gorm:"size:1000;index:idx_data_sources_normalized_url,unique,where:deleted_at IS NULL AND normalized_url <> ''" json:"-"`
+	Type            string         `gorm:"size:50;default:'website'" json:"type"`
+	Category        string         `gorm:"size:100;index" json:"category"`
+	Description     string         `gorm:"type:text" json:"description"`
+	IsActive        bool           `gorm:"default:true" json:"is_active"`
+	ExtractFullText bool           `gorm:"default:false" json:"extract_full_text"`
+	Watch           bool           `gorm:"default:false;index" json:"watch"`
+	LastCrawledAt   *time.Time     `json:"last_crawled_at,omitempty"`
+	LastCrawlError  string         `gorm:"type:text" json:"last_crawl_error,omitempty"`
+	SitemapSyncedAt *time.Time     `json:"sitemap_synced_at,omitempty"`
+	Metadata        datatypes.JSON `gorm:"type:jsonb" json:"metadata,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
-	Tags []Tag `gorm:"many2many:datasource_tags;" json:"tags,omitempty"`
+	Tags         []Tag             `gorm:"many2many:datasource_tags;" json:"tags,omitempty"`
+	Reachability *DataSourceStatus `gorm:"foreignKey:DataSourceID" json:"reachability,omitempty"`
 }
 
 // TableName specifies table name
@@ -1,9 +1,11 @@
 package model
 
 import (
+	"log"
 	"time"
 
 	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/pkg/urlutil"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -36,19 +38,102 @@ func AutoMigrate(db *gorm.DB) error {
 	if err := db.AutoMigrate(
 		&Tag{},
 		&DataSource{},
+		&CandidateArticle{},
+		&PageSnapshot{},
+		&GitHubItem{},
+		&DataSourceStatus{},
 		&RSSFeed{},
+		&FeedEntry{},
+		&ExtractedPage{},
+		&WebSubSubscription{},
 		&WebhookConfig{},
 		&WebhookHistory{},
+		&WebhookSubscription{},
 		&DatasetMapping{},
 		&ArticleTag{},
 		&Setting{},
+		&Job{},
+		&Schedule{},
+		&InboundHook{},
 	); err != nil {
 		return err
 	}
 
+	if err := dropRSSFeedURLUnique(db); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"data_sources", "rss_feeds"} {
+		if err := BackfillNormalizedURLs(db, table); err != nil {
+			return err
+		}
+	}
+
 	return SeedSettings(db)
 }
 
+// dropRSSFeedURLUnique removes the unique index on rss_feeds.url created by
+// earlier versions, as index or as constraint of the SQL migration. It also
+// covered soft-deleted feeds, so re-adding the URL of a deleted feed failed;
+// uniqueness now comes from idx_rss_feeds_normalized_url alone.
+func dropRSSFeedURLUnique(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if migrator.HasIndex(&RSSFeed{}, "idx_rss_feeds_url") {
+		if err := migrator.DropIndex(&RSSFeed{}, "idx_rss_feeds_url"); err != nil {
+			return err
+		}
+	}
+	if migrator.HasConstraint(&RSSFeed{}, "rss_feeds_url_key") {
+		if err := migrator.DropConstraint(&RSSFeed{}, "rss_feeds_url_key"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackfillNormalizedURLs fills the normalized_url column of live rows that
+// lack it, oldest first. A row whose URL normalizes to the URL of another row
+// is reported and left empty, outside the unique index, until it is edited
+// or deleted; later runs report it again.
+func BackfillNormalizedURLs(db *gorm.DB, table string) error {
+	var rows []struct {
+		ID  uint
+		URL string
+	}
+	err := db.Table(table).Select("id, url").
+		Where("deleted_at IS NULL AND (normalized_url IS NULL OR normalized_url = '')").
+		Order("id").
+		Find(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		normalized := urlutil.Normalize(row.URL)
+		if normalized == "" {
+			continue
+		}
+
+		var existingID uint
+		err := db.Table(table).Select("id").
+			Where("deleted_at IS NULL AND normalized_url = ?", normalized).
+			Limit(1).
+			Scan(&existingID).Error
+		if err != nil {
+			return err
+		}
+		if existingID != 0 {
+			log.Printf("warn: %s %d (%s) duplicates %d by normalized URL; edit or delete it to enforce uniqueness", table, row.ID, row.URL, existingID)
+			normalized = ""
+		}
+
+		if err := db.Table(table).Where("id = ?", row.ID).Update("normalized_url", normalized).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SeedSettings creates default settings if they don't exist
 func SeedSettings(db *gorm.DB) error {
 	defaults := []Setting{
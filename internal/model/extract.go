@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// ExtractedPage caches the article text extracted from a web page so that
+// re-ingesting the same URL does not fetch it again
+type ExtractedPage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	URL       string    `gorm:"size:2000;uniqueIndex;not null" json:"url"`
+	Title     string    `gorm:"size:1000" json:"title"`
+	Content   string    `gorm:"type:text" json:"content"` // Markdown
+	Length    int       `json:"length"`                   // characters of article text
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies table name
+func (ExtractedPage) TableName() string {
+	return "extracted_pages"
+}
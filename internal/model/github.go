@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+)
+
+// GitHubItem kinds
+const (
+	GitHubItemRelease = "release" // Ref is the release tag
+	GitHubItemFile    = "file"    // Ref is the path of a README or docs Markdown file
+)
+
+// GitHubItem records a release or Markdown file of a github data source that
+// was ingested into RagFlow. Releases are ingested once per tag; files are
+// re-ingested when their blob SHA changes.
+type GitHubItem struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	DataSourceID uint       `gorm:"not null;uniqueIndex:idx_github_items_source_ref" json:"data_source_id"`
+	Kind         string     `gorm:"size:20;not null;uniqueIndex:idx_github_items_source_ref" json:"kind"`
+	Ref          string     `gorm:"size:500;not null;uniqueIndex:idx_github_items_source_ref" json:"ref"`
+	SHA          string     `gorm:"size:64" json:"sha,omitempty"`
+	Title        string     `gorm:"size:1000" json:"title"`
+	URL          string     `gorm:"size:2000" json:"url"`
+	DocumentID   string     `gorm:"size:100" json:"document_id,omitempty"`
+	DatasetID    string     `gorm:"size:100" json:"dataset_id,omitempty"`
+	PublishedAt  *time.Time `json:"published_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName specifies table name
+func (GitHubItem) TableName() string {
+	return "github_items"
+}
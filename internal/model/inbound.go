@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Inbound hook authentication types
+const (
+	InboundAuthToken = "token"
+	InboundAuthHMAC  = "hmac"
+)
+
+// Inbound request outcomes
+const (
+	InboundStatusIngested  = "ingested"
+	InboundStatusDuplicate = "duplicate"
+	InboundStatusFailed    = "failed"
+)
+
+// InboundHook is a public endpoint, /hooks/in/<slug>, that turns the JSON
+// payloads posted to it into documents. Requests carry the Secret as a token
+// or sign their body with it, depending on AuthType; the secret is never
+// serialized, HasSecret tells whether one is set. Mapping describes where the
+// document fields are found in a payload. HMAC hooks with a TimestampHeader
+// also sign the request time and reject requests outside a tolerance window,
+// so that captured requests cannot be replayed. The Last* fields describe the
+// latest authenticated request.
+type InboundHook struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Name            string         `gorm:"size:200;not null" json:"name"`
+	Slug            string         `gorm:"size:100;not null;index:idx_inbound_hooks_slug,unique,where:deleted_at IS NULL" json:"slug"`
+	Description     string         `gorm:"type:text" json:"description"`
+	AuthType        string         `gorm:"size:20;default:'token'" json:"auth_type"`
+	Secret          string         `gorm:"size:200" json:"-"`
+	HasSecret       bool           `gorm:"-" json:"has_secret"`
+	SignatureHeader string         `gorm:"size:100" json:"signature_header,omitempty"`
+	TimestampHeader string         `gorm:"size:100" json:"timestamp_header,omitempty"`
+	Mapping         datatypes.JSON `gorm:"type:jsonb" json:"mapping"`
+	IsActive        bool           `gorm:"default:true" json:"is_active"`
+	LastReceivedAt  *time.Time     `json:"last_received_at,omitempty"`
+	LastStatus      string         `gorm:"size:20" json:"last_status,omitempty"`
+	LastError       string         `gorm:"type:text" json:"last_error,omitempty"`
+	LastDocumentID  string         `gorm:"size:100" json:"last_document_id,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies table name
+func (InboundHook) TableName() string {
+	return "inbound_hooks"
+}
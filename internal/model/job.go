@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Job statuses
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// Job is a unit of work run by the background worker pool. Payload is the
+// input of the job type and Result its output. A failed attempt is queued
+// again with RunAt pushed back until MaxAttempts is reached; Error keeps the
+// error of the latest attempt. Jobs that work through a list of items count
+// them in Total, Done and Failed while they run.
+type Job struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Type        string         `gorm:"size:100;not null;index" json:"type"`
+	Status      string         `gorm:"size:20;not null;default:'queued';index:idx_jobs_status_run_at,priority:1" json:"status"`
+	Payload     datatypes.JSON `gorm:"type:jsonb" json:"payload,omitempty"`
+	Result      datatypes.JSON `gorm:"type:jsonb" json:"result,omitempty"`
+	Error       string         `gorm:"type:text" json:"error,omitempty"`
+	Attempts    int            `gorm:"default:0" json:"attempts"`
+	MaxAttempts int            `gorm:"default:3" json:"max_attempts"`
+	Total       int            `gorm:"default:0" json:"total"`
+	Done        int            `gorm:"default:0" json:"done"`
+	Failed      int            `gorm:"default:0" json:"failed"`
+	RunAt       time.Time      `gorm:"not null;index:idx_jobs_status_run_at,priority:2" json:"run_at"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	FinishedAt  *time.Time     `gorm:"index" json:"finished_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// TableName specifies table name
+func (Job) TableName() string {
+	return "jobs"
+}
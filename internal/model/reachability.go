@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+)
+
+// DataSource reachability states
+const (
+	ReachabilityUp      = "up"
+	ReachabilityMoved   = "moved"   // permanently redirected to another URL, the catalog entry should be updated
+	ReachabilityFailing = "failing" // the last check failed
+	ReachabilityDead    = "dead"    // failed repeatedly or the page is gone
+)
+
+// DataSourceStatus is the latest reachability check of a data source URL.
+// FinalURL is where redirects ended; TLSExpiresAt is the expiry of the
+// certificate served by the final host.
+type DataSourceStatus struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	DataSourceID        uint       `gorm:"not null;uniqueIndex" json:"data_source_id"`
+	State               string     `gorm:"size:20;index" json:"state"` // up, moved, failing, dead
+	StatusCode          int        `json:"status_code"`
+	LatencyMs           int64      `json:"latency_ms"`
+	FinalURL            string     `gorm:"size:2000" json:"final_url,omitempty"`
+	TLSExpiresAt        *time.Time `gorm:"index" json:"tls_expires_at,omitempty"`
+	Error               string     `gorm:"type:text" json:"error,omitempty"`
+	ConsecutiveFailures int        `gorm:"default:0" json:"consecutive_failures"`
+	CheckedAt           time.Time  `gorm:"index" json:"checked_at"`
+	LastUpAt            *time.Time `json:"last_up_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// TableName specifies table name
+func (DataSourceStatus) TableName() string {
+	return "data_source_statuses"
+}
@@ -7,16 +7,30 @@ import (
 	"gorm.io/gorm"
 )
 
-// RSSFeed represents an RSS feed subscription
+// RSSFeed represents an RSS feed subscription.
+// LastFetchedAt is the last successful fetch and LastCheckedAt the last attempt;
+// ETag/LastModified are the validators for the next conditional GET.
+// Unhealthy is not persisted, the service derives it from ConsecutiveFailures.
+// NormalizedURL is unique among live feeds, like DataSource.NormalizedURL.
 type RSSFeed struct {
 	ID                   uint           `gorm:"primaryKey" json:"id"`
 	Name                 string         `gorm:"size:200;not null" json:"name"`
-	URL                  string         `gorm:"size:1000;uniqueIndex;not null" json:"url"`
+	URL                  string         `gorm:"size:1000;not null" json:"url"`
+	NormalizedURL        string         `gorm:"size:1000;index:idx_rss_feeds_normalized_url,unique,where:deleted_at IS NULL AND normalized_url <> ''" json:"-"`
 	Category             string         `gorm:"size:100;index" json:"category"`
 	Description          string         `gorm:"type:text" json:"description"`
 	IsActive             bool           `gorm:"default:true" json:"is_active"`
 	LastFetchedAt        *time.Time     `json:"last_fetched_at,omitempty"`
 	FetchIntervalMinutes int            `gorm:"default:60" json:"fetch_interval_minutes"`
+	AutoIngest           bool           `gorm:"default:false" json:"auto_ingest"`
+	ExtractFullText      bool           `gorm:"default:false" json:"extract_full_text"`
+	ETag                 string         `gorm:"column:etag;size:500" json:"etag,omitempty"`
+	LastModified         string         `gorm:"size:100" json:"last_modified,omitempty"`
+	LastCheckedAt        *time.Time     `json:"last_checked_at,omitempty"`
+	LastStatusCode       int            `json:"last_status_code"`
+	LastError            string         `gorm:"type:text" json:"last_error,omitempty"`
+	ConsecutiveFailures  int            `gorm:"default:0;index" json:"consecutive_failures"`
+	Unhealthy            bool           `gorm:"-" json:"unhealthy"`
 	Metadata             datatypes.JSON `gorm:"type:jsonb" json:"metadata,omitempty"`
 	CreatedAt            time.Time      `json:"created_at"`
 	UpdatedAt            time.Time      `json:"updated_at"`
@@ -30,3 +44,42 @@ type RSSFeed struct {
 func (RSSFeed) TableName() string {
 	return "rss_feeds"
 }
+
+// FeedEntry ingest statuses
+const (
+	EntryStatusNew       = "new"
+	EntryStatusIngested  = "ingested"
+	EntryStatusDuplicate = "duplicate"
+	EntryStatusFailed    = "failed"
+	EntryStatusSkipped   = "skipped" // dropped by the feed's filter rules
+)
+
+// FeedEntry represents a single item fetched from an RSS feed
+type FeedEntry struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	FeedID      uint       `gorm:"not null;uniqueIndex:idx_feed_entries_feed_guid" json:"feed_id"`
+	GUID        string     `gorm:"size:1000;not null;uniqueIndex:idx_feed_entries_feed_guid" json:"guid"`
+	Title       string     `gorm:"size:1000" json:"title"`
+	Link        string     `gorm:"size:2000" json:"link"`
+	Author      string     `gorm:"size:200" json:"author"`
+	PublishedAt *time.Time `gorm:"index" json:"published_at,omitempty"`
+	Summary     string     `gorm:"type:text" json:"summary"`
+	Content     string     `gorm:"type:text" json:"content,omitempty"`
+	ContentHash string     `gorm:"size:64" json:"content_hash"`
+
+	// Ingest state (only set for feeds with auto-ingest enabled)
+	Status        string    `gorm:"size:20;default:'new';index" json:"status"` // new, ingested, duplicate, failed, skipped
+	StatusMessage string    `gorm:"type:text" json:"status_message,omitempty"`
+	DocumentID    string    `gorm:"size:100;index" json:"document_id,omitempty"`
+	DatasetID     string    `gorm:"size:100" json:"dataset_id,omitempty"`
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Relations
+	Feed *RSSFeed `gorm:"foreignKey:FeedID" json:"feed,omitempty"`
+}
+
+// TableName specifies table name
+func (FeedEntry) TableName() string {
+	return "feed_entries"
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Schedule run outcomes
+const (
+	ScheduleStatusSucceeded = "succeeded"
+	ScheduleStatusFailed    = "failed"
+)
+
+// Schedule runs a named task whenever its cron expression activates. Params
+// is the task input; the Last* fields describe the latest run. NextRunAt is
+// empty while the schedule is inactive.
+type Schedule struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	Name           string         `gorm:"size:200;not null;uniqueIndex" json:"name"`
+	Description    string         `gorm:"type:text" json:"description"`
+	Cron           string         `gorm:"size:100;not null" json:"cron"`
+	Timezone       string         `gorm:"size:64" json:"timezone"`
+	Task           string         `gorm:"size:100;not null" json:"task"`
+	Params         datatypes.JSON `gorm:"type:jsonb" json:"params,omitempty"`
+	IsActive       bool           `gorm:"not null" json:"is_active"`
+	NextRunAt      *time.Time     `gorm:"index" json:"next_run_at,omitempty"`
+	LastRunAt      *time.Time     `json:"last_run_at,omitempty"`
+	LastStatus     string         `gorm:"size:20" json:"last_status,omitempty"`
+	LastError      string         `gorm:"type:text" json:"last_error,omitempty"`
+	LastResult     datatypes.JSON `gorm:"type:jsonb" json:"last_result,omitempty"`
+	LastDurationMs int            `json:"last_duration_ms,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// TableName specifies table name
+func (Schedule) TableName() string {
+	return "schedules"
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// PageSnapshot is the last ingested version of a watched data source page.
+// ContentHash and Content are taken from the normalized extracted text; DocumentID
+// and DatasetID point to the RagFlow document holding that version. Version counts
+// the re-ingests caused by page changes.
+type PageSnapshot struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	DataSourceID uint       `gorm:"not null;uniqueIndex" json:"data_source_id"`
+	URL          string     `gorm:"size:2000;not null" json:"url"`
+	ContentHash  string     `gorm:"size:64" json:"content_hash"`
+	Content      string     `gorm:"type:text" json:"-"`
+	Length       int        `json:"length"`
+	DocumentID   string     `gorm:"size:100;index" json:"document_id,omitempty"`
+	DatasetID    string     `gorm:"size:100" json:"dataset_id,omitempty"`
+	Version      int        `gorm:"default:0" json:"version"`
+	LastChange   float64    `json:"last_change"` // changed share of the text at the last check
+	CheckedAt    *time.Time `gorm:"index" json:"checked_at,omitempty"`
+	ChangedAt    *time.Time `json:"changed_at,omitempty"`
+	LastError    string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName specifies table name
+func (PageSnapshot) TableName() string {
+	return "page_snapshots"
+}
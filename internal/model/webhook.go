@@ -7,7 +7,24 @@ import (
 	"gorm.io/gorm"
 )
 
-// WebhookConfig represents a webhook configuration
+// Webhook delivery statuses. A delivery of an event is "pending" until its
+// first attempt, made in the background. A delivery that failed with a retryable error is
+// "retrying" until its next attempt; once it runs out of attempts it is "dead"
+// and stays so until it is requeued. Non-retryable failures are "failed".
+const (
+	WebhookStatusPending  = "pending"
+	WebhookStatusSuccess  = "success"
+	WebhookStatusFailed   = "failed"
+	WebhookStatusRetrying = "retrying"
+	WebhookStatusDead     = "dead"
+)
+
+// WebhookConfig represents a webhook configuration. A delivery is attempted up
+// to MaxAttempts times when it fails with a network error or one of the
+// RetryStatusCodes (a default set of transient codes when NULL), waiting
+// RetryDelaySeconds before the first retry and twice as long before each
+// further one. Deliveries of a webhook with a SigningSecret are signed; the
+// secret is never serialized, Signed tells whether one is set.
 type WebhookConfig struct {
 	ID             uint           `gorm:"primaryKey" json:"id"`
 	Name           string         `gorm:"size:200;not null" json:"name"`
@@ -23,6 +40,14 @@ type WebhookConfig struct {
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Retry policy
+	MaxAttempts       int            `gorm:"default:3" json:"max_attempts"`
+	RetryDelaySeconds int            `gorm:"default:30" json:"retry_delay_seconds"`
+	RetryStatusCodes  datatypes.JSON `gorm:"type:jsonb" json:"retry_status_codes,omitempty"`
+
+	SigningSecret string `gorm:"size:200" json:"-"`
+	Signed        bool   `gorm:"-" json:"signed"`
+
 	// Relations
 	History []WebhookHistory `gorm:"foreignKey:WebhookID" json:"history,omitempty"`
 }
@@ -32,7 +57,12 @@ func (WebhookConfig) TableName() string {
 	return "webhook_configs"
 }
 
-// WebhookHistory represents a webhook invocation record
+// WebhookHistory represents a webhook invocation record. A delivery is a
+// record without ParentID: its status is that of the whole delivery and its
+// response fields mirror the latest attempt. Every attempt is also stored as
+// a record of its own, linked to the delivery by ParentID and numbered by
+// Attempt. A delivery that replays an earlier record links to it by ReplayOf;
+// a delivery of an event records its type in EventType.
 type WebhookHistory struct {
 	ID              uint           `gorm:"primaryKey" json:"id"`
 	WebhookID       uint           `gorm:"index" json:"webhook_id"`
@@ -48,6 +78,17 @@ type WebhookHistory struct {
 	ErrorMessage    string         `gorm:"type:text" json:"error_message,omitempty"`
 	CreatedAt       time.Time      `gorm:"index" json:"created_at"`
 
+	// Attempt records
+	ParentID *uint `gorm:"index" json:"parent_id,omitempty"`
+	Attempt  int   `gorm:"default:0" json:"attempt,omitempty"`
+
+	// Delivery state
+	Attempts    int        `gorm:"default:0" json:"attempts,omitempty"`
+	MaxAttempts int        `gorm:"default:0" json:"max_attempts,omitempty"`
+	NextRetryAt *time.Time `gorm:"index" json:"next_retry_at,omitempty"`
+	ReplayOf    *uint      `gorm:"index" json:"replay_of,omitempty"`
+	EventType   string     `gorm:"size:100;index" json:"event_type,omitempty"`
+
 	// Relations
 	Webhook WebhookConfig `gorm:"foreignKey:WebhookID" json:"webhook,omitempty"`
 }
@@ -56,3 +97,25 @@ type WebhookHistory struct {
 func (WebhookHistory) TableName() string {
 	return "webhook_history"
 }
+
+// WebhookSubscription subscribes a webhook to the events of a type. EventType
+// is a type such as document.uploaded, a prefix such as document.* or * for
+// every event. Filters narrow the events down by their tags, category or
+// dataset; an empty list does not filter.
+type WebhookSubscription struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	WebhookID uint           `gorm:"index;not null" json:"webhook_id"`
+	EventType string         `gorm:"size:100;index;not null" json:"event_type"`
+	Filters   datatypes.JSON `gorm:"type:jsonb" json:"filters,omitempty"`
+	IsActive  bool           `gorm:"default:true" json:"is_active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+
+	// Relations
+	Webhook *WebhookConfig `gorm:"foreignKey:WebhookID" json:"-"`
+}
+
+// TableName specifies table name
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+)
+
+// WebSub subscription states
+const (
+	WebSubStatePending = "pending" // subscribe request sent, waiting for the hub's verification
+	WebSubStateActive  = "active"
+	WebSubStateDenied  = "denied" // hub refused the subscription
+	WebSubStateFailed  = "failed" // subscribe request could not be delivered
+)
+
+// WebSubSubscription tracks the push subscription of a feed at its WebSub hub.
+// RequestedAt is when the last subscribe request was sent; ExpiresAt comes from
+// the lease the hub granted during verification.
+type WebSubSubscription struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	FeedID       uint       `gorm:"not null;uniqueIndex" json:"feed_id"`
+	HubURL       string     `gorm:"size:1000;not null" json:"hub_url"`
+	TopicURL     string     `gorm:"size:1000;not null" json:"topic_url"`
+	Secret       string     `gorm:"size:100" json:"-"`
+	State        string     `gorm:"size:20;default:'pending';index" json:"state"` // pending, active, denied, failed
+	LeaseSeconds int        `json:"lease_seconds"`
+	RequestedAt  *time.Time `json:"requested_at,omitempty"`
+	ExpiresAt    *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	LastPushAt   *time.Time `json:"last_push_at,omitempty"`
+	LastError    string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName specifies table name
+func (WebSubSubscription) TableName() string {
+	return "websub_subscriptions"
+}
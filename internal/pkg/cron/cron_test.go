@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// A Monday
+	from := time.Date(2024, 1, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{"step over all minutes", "*/15 * * * *", time.Date(2024, 1, 15, 10, 15, 0, 0, time.UTC)},
+		{"step from a value", "3/10 * * * *", time.Date(2024, 1, 15, 10, 13, 0, 0, time.UTC)},
+		{"step over a range", "0 8-18/2 * * *", time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)},
+		{"range of weekday names", "0 9-17 * * MON-FRI", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"list", "30 1,13 * * *", time.Date(2024, 1, 15, 13, 30, 0, 0, time.UTC)},
+		{"same minute is not next", "7 10 * * *", time.Date(2024, 1, 16, 10, 7, 0, 0, time.UTC)},
+		{"first of month", "0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"month name", "0 0 1 MAR *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"day of month only", "0 0 31 * *", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"day of week only", "0 0 * * 0", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"either day field, weekday first", "0 0 13 * FRI", time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"either day field, day of month first", "0 0 16 * FRI", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 30 2 *", time.Time{}},
+		{"macro", "@hourly", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"macro case", "@Weekly", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"0 0 * JANX *",
+		"@reboot",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := Parse(expr); err == nil {
+				t.Errorf("Parse(%q) succeeded, want an error", expr)
+			}
+		})
+	}
+}
@@ -10,6 +10,64 @@ const (
 	// DefaultFetchInterval is the default RSS fetch interval in minutes.
 	DefaultFetchInterval = 60
 
+	// MaxFeedBodySize caps how many bytes are read from a single feed response.
+	MaxFeedBodySize = 10 << 20
+
+	// FeedUnhealthyFailures is the number of consecutive failed fetches after which a feed is flagged unhealthy.
+	FeedUnhealthyFailures = 3
+
+	// MaxFeedBackoffMinutes caps the exponential retry delay of a failing feed.
+	MaxFeedBackoffMinutes = 24 * 60
+
+	// FeedPreviewItems is the number of latest entries returned by a feed preview.
+	FeedPreviewItems = 10
+
+	// MaxPageBodySize caps how many bytes are read from a web page for content extraction.
+	MaxPageBodySize = 5 << 20
+
+	// MaxRobotsBodySize caps how many bytes of a robots.txt file are parsed.
+	MaxRobotsBodySize = 512 << 10
+
+	// WebSubFallbackPollMinutes is the polling interval of feeds that receive WebSub pushes,
+	// kept as a safety net in case the hub misses an update.
+	WebSubFallbackPollMinutes = 24 * 60
+
+	// WebSubRenewMarginHours is how long before its lease expires a subscription is renewed.
+	WebSubRenewMarginHours = 48
+
+	// WebSubRetryMinutes is the delay before an unverified or refused subscription is requested again.
+	WebSubRetryMinutes = 6 * 60
+
+	// GitHubMaxReleases is how many of the latest releases a github data source syncs by default.
+	GitHubMaxReleases = 20
+
+	// GitHubMaxFiles caps how many Markdown files are synced from the docs paths of a github data source.
+	GitHubMaxFiles = 100
+
+	// MaxGitHubBodySize caps how many bytes are read from a single GitHub API response.
+	MaxGitHubBodySize = 10 << 20
+
+	// DataSourceDeadFailures is the number of consecutive failed reachability checks after which a data source is flagged dead.
+	DataSourceDeadFailures = 3
+
+	// TLSExpiryWarningDays is how close to expiry a data source certificate is reported in health metrics.
+	TLSExpiryWarningDays = 14
+
+	// MaxImportBodySize caps how many bytes of a data source import file are read.
+	MaxImportBodySize = 10 << 20
+
+	// MaxJobBackoffMinutes caps the retry delay of a failing job.
+	MaxJobBackoffMinutes = 60
+
+	// JobEventBuffer is how many events of a job are buffered for a slow subscriber before they are dropped.
+	JobEventBuffer = 256
+
+	// JobEventPollSeconds is how often a watched job is re-read while its worker publishes no events.
+	JobEventPollSeconds = 2
+
+	// JobEventHeartbeatSeconds is the interval of keep-alive comments on an idle job event stream.
+	JobEventHeartbeatSeconds = 15
+
 	// DefaultWebhookMethod is the default HTTP method for webhooks.
 	DefaultWebhookMethod = "POST"
 
@@ -19,6 +77,30 @@ const (
 	// DefaultWebhookTimeout is the default webhook timeout in seconds.
 	DefaultWebhookTimeout = 30
 
+	// DefaultWebhookMaxAttempts is the default number of attempts of a webhook delivery.
+	DefaultWebhookMaxAttempts = 3
+
+	// DefaultWebhookRetryDelay is the default delay in seconds before the first retry of a webhook delivery.
+	DefaultWebhookRetryDelay = 30
+
+	// MaxWebhookBackoffMinutes caps the retry delay of a failing webhook delivery.
+	MaxWebhookBackoffMinutes = 60
+
+	// WebhookRetryBatch is how many due webhook retries are attempted per scan.
+	WebhookRetryBatch = 50
+
+	// EventBuffer is how many published events wait for their handlers before publishers handle further ones themselves.
+	EventBuffer = 1024
+
+	// MaxInboundBodySize caps how many bytes of an inbound webhook request are read.
+	MaxInboundBodySize = 5 << 20
+
+	// DefaultInboundSignatureHeader is the header carrying the HMAC signature of inbound webhook requests.
+	DefaultInboundSignatureHeader = "X-Hub-Signature-256"
+
+	// InboundTimestampTolerance is how many seconds the signed timestamp of an inbound webhook request may be off.
+	InboundTimestampTolerance = 300
+
 	// HealthCheckTimeout is the timeout for external service health checks in seconds.
 	HealthCheckTimeout = 5
 )
@@ -0,0 +1,312 @@
+// Package extract pulls the main article body out of an HTML page,
+// readability-style, and renders it as Markdown.
+package extract
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNoContent is returned when a page has no recognizable article text.
+var ErrNoContent = errors.New("no article content found")
+
+// Article is the extracted content of a page.
+type Article struct {
+	Title    string
+	Markdown string
+	// Length is the number of text characters in the extracted body.
+	Length int
+}
+
+var (
+	// unlikelyPattern marks page chrome that is dropped before scoring.
+	unlikelyPattern = regexp.MustCompile(`(?i)comment|sidebar|footer|footnote|navbar|menu|share|social|related|advert|sponsor|promo|cookie|banner|popup|modal|subscribe|newsletter|breadcrumb|pagination|widget`)
+	// maybePattern rescues unlikely-looking nodes that are probably the article.
+	maybePattern    = regexp.MustCompile(`(?i)article|content|main|body|post|entry|story|column`)
+	positivePattern = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|blog|story`)
+	negativePattern = regexp.MustCompile(`(?i)hidden|comment|contact|footer|footnote|masthead|meta|related|share|sidebar|sponsor|shopping|tags|widget|nav|menu|advert|promo`)
+)
+
+// removedTags never contain article text.
+var removedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true,
+	atom.Form: true, atom.Svg: true, atom.Nav: true, atom.Aside: true, atom.Footer: true,
+	atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+	atom.Template: true, atom.Object: true, atom.Embed: true, atom.Canvas: true,
+}
+
+// Extract finds the main article of the page and converts it to Markdown.
+// Relative links and images are resolved against pageURL.
+func Extract(r io.Reader, pageURL string) (*Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	base := documentBase(doc, pageURL)
+	title := documentTitle(doc)
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		return nil, ErrNoContent
+	}
+	clean(body)
+
+	top := topCandidate(body)
+	if top == nil {
+		if top = findFirst(body, atom.Article); top == nil {
+			if top = findFirst(body, atom.Main); top == nil {
+				top = body
+			}
+		}
+	}
+
+	return newArticle(title, top, base)
+}
+
+// Convert renders the whole page body as Markdown without picking out the article.
+func Convert(r io.Reader, pageURL string) (*Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	base := documentBase(doc, pageURL)
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		return nil, ErrNoContent
+	}
+	removeTags(body)
+
+	return newArticle(documentTitle(doc), body, base)
+}
+
+// HTMLToMarkdown converts an HTML fragment, such as a feed item's content, to Markdown.
+func HTMLToMarkdown(fragment, pageURL string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return fragment
+	}
+	base, _ := url.Parse(pageURL)
+	c := &converter{base: base}
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(c.render(n))
+	}
+	return tidy(b.String())
+}
+
+func newArticle(title string, n *html.Node, base *url.URL) (*Article, error) {
+	length := utf8.RuneCountInString(strings.Join(strings.Fields(textContent(n)), " "))
+	if length == 0 {
+		return nil, ErrNoContent
+	}
+	c := &converter{base: base}
+	return &Article{
+		Title:    title,
+		Markdown: tidy(c.render(n)),
+		Length:   length,
+	}, nil
+}
+
+// clean strips tags and class/id-flagged boilerplate that cannot be part of the article.
+func clean(n *html.Node) {
+	removeTags(n)
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.ElementNode && isUnlikely(child) {
+			n.RemoveChild(child)
+		} else {
+			clean(child)
+		}
+		child = next
+	}
+}
+
+func removeTags(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.CommentNode || (child.Type == html.ElementNode && removedTags[child.DataAtom]) {
+			n.RemoveChild(child)
+		} else {
+			removeTags(child)
+		}
+		child = next
+	}
+}
+
+func isUnlikely(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Article, atom.Main, atom.Body, atom.A:
+		return false
+	}
+	hint := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyPattern.MatchString(hint) && !maybePattern.MatchString(hint)
+}
+
+// topCandidate scores block containers by the paragraphs they hold and returns
+// the best one, penalizing link-heavy blocks such as navigation lists.
+func topCandidate(body *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+		}
+		scores[n] += score
+	}
+
+	walk(body, func(n *html.Node) {
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		default:
+			return
+		}
+		text := strings.TrimSpace(textContent(n))
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")) + math.Min(float64(length)/100, 3)
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+	})
+
+	var (
+		best      *html.Node
+		bestScore float64
+	)
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best
+}
+
+func initialScore(n *html.Node) float64 {
+	var score float64
+	switch n.DataAtom {
+	case atom.Article:
+		score = 10
+	case atom.Div, atom.Main, atom.Section:
+		score = 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score = 3
+	case atom.Ol, atom.Ul, atom.Dl, atom.Form:
+		score = -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score = -5
+	}
+
+	hint := attr(n, "class") + " " + attr(n, "id")
+	if negativePattern.MatchString(hint) {
+		score -= 25
+	}
+	if positivePattern.MatchString(hint) {
+		score += 25
+	}
+	return score
+}
+
+// linkDensity is the share of the node's text that sits inside links.
+func linkDensity(n *html.Node) float64 {
+	total := utf8.RuneCountInString(textContent(n))
+	if total == 0 {
+		return 0
+	}
+	var linked int
+	walk(n, func(c *html.Node) {
+		if c.DataAtom == atom.A {
+			linked += utf8.RuneCountInString(textContent(c))
+		}
+	})
+	return float64(linked) / float64(total)
+}
+
+// documentBase resolves the page URL against a <base href> element if present.
+func documentBase(doc *html.Node, pageURL string) *url.URL {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		base = nil
+	}
+	if b := findFirst(doc, atom.Base); b != nil {
+		if href, err := url.Parse(attr(b, "href")); err == nil && href.String() != "" {
+			if base != nil {
+				return base.ResolveReference(href)
+			}
+			return href
+		}
+	}
+	return base
+}
+
+// documentTitle prefers the Open Graph title, which rarely carries the site name suffix.
+func documentTitle(doc *html.Node) string {
+	var og string
+	walk(doc, func(n *html.Node) {
+		if og == "" && n.DataAtom == atom.Meta && attr(n, "property") == "og:title" {
+			og = strings.TrimSpace(attr(n, "content"))
+		}
+	})
+	if og != "" {
+		return og
+	}
+	if t := findFirst(doc, atom.Title); t != nil {
+		return strings.Join(strings.Fields(textContent(t)), " ")
+	}
+	return ""
+}
+
+func walk(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findFirst(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package extract
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Link is an anchor found on a page.
+type Link struct {
+	URL  string
+	Text string
+}
+
+// Links returns the page title and the absolute http(s) links on the page in
+// document order, without fragments or duplicates. rel="nofollow" links are
+// skipped, and a page with a nofollow robots meta tag yields no links.
+func Links(r io.Reader, pageURL string) (string, []Link, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", nil, err
+	}
+	base := documentBase(doc, pageURL)
+	title := documentTitle(doc)
+
+	nofollow := false
+	walk(doc, func(n *html.Node) {
+		if n.DataAtom == atom.Meta && strings.EqualFold(attr(n, "name"), "robots") &&
+			strings.Contains(strings.ToLower(attr(n, "content")), "nofollow") {
+			nofollow = true
+		}
+	})
+	if nofollow {
+		return title, nil, nil
+	}
+
+	var links []Link
+	seen := map[string]bool{}
+	walk(doc, func(n *html.Node) {
+		if n.DataAtom != atom.A {
+			return
+		}
+		href := strings.TrimSpace(attr(n, "href"))
+		if href == "" || strings.Contains(strings.ToLower(attr(n, "rel")), "nofollow") {
+			return
+		}
+		u, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return
+		}
+		u.Fragment = ""
+		abs := u.String()
+		if seen[abs] {
+			return
+		}
+		seen[abs] = true
+		links = append(links, Link{URL: abs, Text: strings.Join(strings.Fields(textContent(n)), " ")})
+	})
+	return title, links, nil
+}
@@ -0,0 +1,239 @@
+package extract
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	whitespacePattern = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// converter renders an HTML tree as Markdown.
+type converter struct {
+	base *url.URL
+}
+
+func (c *converter) render(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return whitespacePattern.ReplaceAllString(n.Data, " ")
+	case html.ElementNode, html.DocumentNode:
+	default:
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.TrimSpace(c.children(n))
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + text + "\n\n"
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header,
+		atom.Figure, atom.Figcaption, atom.Dl, atom.Dd, atom.Dt, atom.Details, atom.Summary:
+		return "\n\n" + strings.TrimSpace(c.children(n)) + "\n\n"
+	case atom.Br:
+		return "\n"
+	case atom.Hr:
+		return "\n\n---\n\n"
+	case atom.A:
+		text := strings.TrimSpace(c.children(n))
+		href := c.resolve(attr(n, "href"))
+		if text == "" || href == "" || strings.HasPrefix(href, "javascript:") || strings.HasPrefix(href, "#") {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	case atom.Img:
+		src := c.resolve(firstAttr(n, "src", "data-src", "data-original"))
+		if src == "" || strings.HasPrefix(src, "data:") {
+			return ""
+		}
+		return "![" + strings.TrimSpace(attr(n, "alt")) + "](" + src + ")"
+	case atom.Strong, atom.B:
+		return wrapInline(c.children(n), "**")
+	case atom.Em, atom.I:
+		return wrapInline(c.children(n), "_")
+	case atom.Del, atom.S:
+		return wrapInline(c.children(n), "~~")
+	case atom.Code:
+		return wrapInline(textContent(n), "`")
+	case atom.Pre:
+		code := strings.Trim(textContent(n), "\n")
+		lang := ""
+		if codeEl := findFirst(n, atom.Code); codeEl != nil {
+			lang = codeLanguage(attr(codeEl, "class"))
+		}
+		return "\n\n```" + lang + "\n" + code + "\n```\n\n"
+	case atom.Blockquote:
+		inner := tidy(c.children(n))
+		if inner == "" {
+			return ""
+		}
+		lines := strings.Split(inner, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return "\n\n" + strings.Join(lines, "\n") + "\n\n"
+	case atom.Ul, atom.Ol:
+		return c.list(n)
+	case atom.Table:
+		return c.table(n)
+	case atom.Script, atom.Style, atom.Noscript, atom.Head, atom.Title:
+		return ""
+	}
+	return c.children(n)
+}
+
+func (c *converter) children(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.render(child))
+	}
+	return b.String()
+}
+
+func (c *converter) list(n *html.Node) string {
+	var b strings.Builder
+	index := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", index)
+		}
+		index++
+
+		item := tidy(c.children(li))
+		if item == "" {
+			continue
+		}
+		indent := strings.Repeat(" ", len(marker))
+		lines := strings.Split(item, "\n")
+		for i, line := range lines {
+			if i == 0 {
+				b.WriteString(marker + line + "\n")
+			} else if line == "" {
+				b.WriteString("\n")
+			} else {
+				b.WriteString(indent + line + "\n")
+			}
+		}
+	}
+	return "\n\n" + b.String() + "\n"
+}
+
+func (c *converter) table(n *html.Node) string {
+	var rows [][]string
+	walk(n, func(tr *html.Node) {
+		if tr.DataAtom != atom.Tr {
+			return
+		}
+		var cells []string
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+				text := strings.Join(strings.Fields(c.children(cell)), " ")
+				cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+	})
+	if len(rows) == 0 {
+		return ""
+	}
+
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	return "\n\n" + b.String() + "\n"
+}
+
+func (c *converter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || c.base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return c.base.ResolveReference(u).String()
+}
+
+// wrapInline surrounds text with a Markdown marker, keeping surrounding spaces outside it.
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	lead := text[:strings.Index(text, trimmed)]
+	trail := text[len(lead)+len(trimmed):]
+	return lead + marker + trimmed + marker + trail
+}
+
+// codeLanguage reads the language from class names such as "language-go" or "lang-go".
+func codeLanguage(class string) string {
+	for _, c := range strings.Fields(class) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(c, prefix) {
+				return strings.TrimPrefix(c, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+func firstAttr(n *html.Node, keys ...string) string {
+	for _, k := range keys {
+		if v := attr(n, k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// tidy trims stray spaces and collapses runs of blank lines, leaving fenced code untouched.
+// A single leading space is left over from collapsed HTML whitespace and is dropped;
+// deeper indentation belongs to nested list items and is kept.
+func tidy(s string) string {
+	lines := strings.Split(s, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if inFence {
+			continue
+		}
+		line = strings.TrimRight(line, " \t")
+		if strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "  ") {
+			line = line[1:]
+		}
+		lines[i] = line
+	}
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
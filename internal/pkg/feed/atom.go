@@ -0,0 +1,113 @@
+package feed
+
+import (
+	"fmt"
+	"strings"
+)
+
+type atomDocument struct {
+	Title    atomText    `xml:"title"`
+	Subtitle atomText    `xml:"subtitle"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// String returns the text content, keeping the raw markup for xhtml payloads.
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return firstNonEmpty(t.Inner)
+	}
+	return firstNonEmpty(t.Text)
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email"`
+}
+
+type atomEntry struct {
+	ID        string       `xml:"id"`
+	Title     atomText     `xml:"title"`
+	Links     []atomLink   `xml:"link"`
+	Authors   []atomPerson `xml:"author"`
+	Published string       `xml:"published"`
+	Updated   string       `xml:"updated"`
+	Summary   atomText     `xml:"summary"`
+	Content   atomText     `xml:"content"`
+}
+
+func parseAtom(data []byte) (*Feed, error) {
+	var doc atomDocument
+	if err := newDecoder(data).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse Atom: %w", err)
+	}
+
+	feed := &Feed{
+		Format:      FormatAtom,
+		Title:       doc.Title.String(),
+		Description: doc.Subtitle.String(),
+		Link:        atomAlternateLink(doc.Links),
+		Hub:         atomRelLink(doc.Links, "hub"),
+		Self:        atomRelLink(doc.Links, "self"),
+	}
+
+	for _, e := range doc.Entries {
+		link := atomAlternateLink(e.Links)
+		var author string
+		if len(e.Authors) > 0 {
+			author = firstNonEmpty(e.Authors[0].Name, e.Authors[0].Email)
+		}
+		published := parseDate(e.Published)
+		updated := parseDate(e.Updated)
+		if published == nil {
+			published = updated
+		}
+		feed.Items = append(feed.Items, Item{
+			GUID:      firstNonEmpty(e.ID, link),
+			Title:     e.Title.String(),
+			Link:      link,
+			Author:    author,
+			Published: published,
+			Updated:   updated,
+			Summary:   e.Summary.String(),
+			Content:   e.Content.String(),
+		})
+	}
+
+	return feed, nil
+}
+
+// atomAlternateLink picks the rel="alternate" link, which is the default when rel is omitted.
+func atomAlternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
+
+// atomRelLink returns the href of the first link with the given rel.
+func atomRelLink(links []atomLink, rel string) string {
+	for _, l := range links {
+		if strings.EqualFold(l.Rel, rel) {
+			return firstNonEmpty(l.Href)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,195 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Supported feed formats
+const (
+	FormatRSS  = "rss"  // RSS 0.9x / 2.0
+	FormatRDF  = "rdf"  // RSS 1.0
+	FormatAtom = "atom" // Atom 1.0
+	FormatJSON = "json" // JSON Feed 1.0 / 1.1
+)
+
+// ErrUnknownFormat is returned when the document is not a supported feed format.
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// Feed is the normalized representation of a parsed feed document.
+type Feed struct {
+	Format      string `json:"format"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Link        string `json:"link"`
+	Hub         string `json:"hub,omitempty"`  // WebSub hub advertised by the feed
+	Self        string `json:"self,omitempty"` // canonical feed URL (WebSub topic)
+	Items       []Item `json:"items"`
+}
+
+// Item is a single normalized feed entry.
+type Item struct {
+	GUID      string     `json:"guid"`
+	Title     string     `json:"title"`
+	Link      string     `json:"link"`
+	Author    string     `json:"author"`
+	Published *time.Time `json:"published,omitempty"`
+	Updated   *time.Time `json:"updated,omitempty"`
+	Summary   string     `json:"summary"`
+	Content   string     `json:"content"`
+}
+
+// Parse detects the feed format from the content type and body and parses the document.
+// Relative links are resolved against baseURL, which is normally the URL the feed was fetched from.
+func Parse(data []byte, contentType, baseURL string) (*Feed, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var (
+		f   *Feed
+		err error
+	)
+	if isJSON(data, contentType) {
+		f, err = parseJSON(data)
+	} else {
+		f, err = parseXML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if base, err := url.Parse(baseURL); err == nil && baseURL != "" {
+		resolveLinks(f, base)
+	}
+	return f, nil
+}
+
+func parseXML(data []byte) (*Feed, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(root) {
+	case "rss":
+		return parseRSS(data)
+	case "rdf":
+		return parseRDF(data)
+	case "feed":
+		return parseAtom(data)
+	default:
+		return nil, fmt.Errorf("%w: root element <%s>", ErrUnknownFormat, root)
+	}
+}
+
+// isJSON reports whether the payload should be treated as a JSON Feed.
+// Servers often send JSON feeds as text/plain, so the body is sniffed too.
+func isJSON(data []byte, contentType string) bool {
+	if strings.Contains(strings.ToLower(contentType), "json") {
+		return true
+	}
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// resolveLinks makes the feed and item links absolute. Item GUIDs that were
+// derived from a relative link are resolved along with it.
+func resolveLinks(f *Feed, base *url.URL) {
+	f.Link = resolveURL(base, f.Link)
+	f.Hub = resolveURL(base, f.Hub)
+	f.Self = resolveURL(base, f.Self)
+	for i := range f.Items {
+		item := &f.Items[i]
+		resolved := resolveURL(base, item.Link)
+		if item.GUID == item.Link {
+			item.GUID = resolved
+		}
+		item.Link = resolved
+	}
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.IsAbs() {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// rootElement returns the local name of the first XML element in data.
+func rootElement(data []byte) (string, error) {
+	dec := newDecoder(data)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return "", ErrUnknownFormat
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid XML: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func newDecoder(data []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	// Decode non-UTF-8 documents according to the encoding declared in the XML prolog
+	dec.CharsetReader = charset.NewReaderLabel
+	return dec
+}
+
+// dateLayouts lists the timestamp formats seen in the wild, most common first.
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseDate parses a feed timestamp, returning nil when no layout matches.
+func parseDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
+
+// firstNonEmpty returns the first non-blank value.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if s := strings.TrimSpace(v); s != "" {
+			return s
+		}
+	}
+	return ""
+}
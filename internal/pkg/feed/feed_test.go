@@ -0,0 +1,235 @@
+package feed
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const rssDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+  <title> Example Blog </title>
+  <link>https://example.com/</link>
+  <atom:link rel="hub" href="https://hub.example.com/"/>
+  <atom:link rel="self" href="https://example.com/feed.xml"/>
+  <description>Posts &amp; notes</description>
+  <item>
+    <title>First &mdash; post</title>
+    <link>/posts/1</link>
+    <dc:creator>Alice</dc:creator>
+    <author>alice@example.com</author>
+    <pubDate>Mon, 15 Jan 2024 10:00:00 +0100</pubDate>
+    <description>Summary</description>
+    <content:encoded><![CDATA[<p>Body</p>]]></content:encoded>
+  </item>
+  <item>
+    <guid>urn:post:2</guid>
+    <title>Second</title>
+    <link>https://example.com/posts/2</link>
+    <dc:date>2024-01-16T08:30:00Z</dc:date>
+  </item>
+</channel>
+</rss>`
+
+const atomDoc = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example Atom</title>
+  <subtitle type="xhtml"><div>Sub</div></subtitle>
+  <link rel="self" href="https://example.com/atom.xml"/>
+  <link href="https://example.com/"/>
+  <link rel="hub" href="https://hub.example.com/"/>
+  <entry>
+    <id>tag:example.com,2024:1</id>
+    <title>Atom entry</title>
+    <link rel="alternate" href="https://example.com/a/1"/>
+    <author><email>bob@example.com</email></author>
+    <updated>2024-01-15T09:00:00Z</updated>
+    <summary>Short</summary>
+    <content type="html">&lt;p&gt;Long&lt;/p&gt;</content>
+  </entry>
+</feed>`
+
+const rdfDoc = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel rdf:about="https://example.com/">
+    <title>Example RDF</title>
+    <link>https://example.com/</link>
+    <description>RSS 1.0</description>
+  </channel>
+  <item rdf:about="https://example.com/r/1">
+    <title>RDF item</title>
+    <dc:creator>Carol</dc:creator>
+    <dc:date>2024-01-15</dc:date>
+  </item>
+</rdf:RDF>`
+
+const jsonDoc = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Example JSON",
+  "home_page_url": "https://example.com/",
+  "feed_url": "https://example.com/feed.json",
+  "hubs": [{"type": "rssCloud", "url": "https://cloud.example.com/"}, {"type": "WebSub", "url": "https://hub.example.com/"}],
+  "items": [
+    {"id": 42, "url": "https://example.com/j/42", "title": "Numeric id", "content_text": "Text", "date_modified": "2024-01-15T12:00:00+00:00", "author": {"name": "Dave"}},
+    {"external_url": "https://elsewhere.example.com/x", "content_html": "<p>HTML</p>", "content_text": "Text", "authors": [{"url": "https://example.com/erin"}]}
+  ]
+}`
+
+func at(year int, month time.Month, day, hour, min int) *time.Time {
+	t := time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		contentType string
+		baseURL     string
+		want        *Feed
+	}{
+		{
+			name:    "RSS 2.0",
+			data:    rssDoc,
+			baseURL: "https://example.com/feed.xml",
+			want: &Feed{
+				Format:      FormatRSS,
+				Title:       "Example Blog",
+				Description: "Posts & notes",
+				Link:        "https://example.com/",
+				Hub:         "https://hub.example.com/",
+				Self:        "https://example.com/feed.xml",
+				Items: []Item{
+					{
+						GUID:      "https://example.com/posts/1",
+						Title:     "First — post",
+						Link:      "https://example.com/posts/1",
+						Author:    "Alice",
+						Published: at(2024, 1, 15, 9, 0),
+						Summary:   "Summary",
+						Content:   "<p>Body</p>",
+					},
+					{
+						GUID:      "urn:post:2",
+						Title:     "Second",
+						Link:      "https://example.com/posts/2",
+						Published: at(2024, 1, 16, 8, 30),
+					},
+				},
+			},
+		},
+		{
+			name: "Atom",
+			data: atomDoc,
+			want: &Feed{
+				Format:      FormatAtom,
+				Title:       "Example Atom",
+				Description: "<div>Sub</div>",
+				Link:        "https://example.com/",
+				Hub:         "https://hub.example.com/",
+				Self:        "https://example.com/atom.xml",
+				Items: []Item{{
+					GUID:      "tag:example.com,2024:1",
+					Title:     "Atom entry",
+					Link:      "https://example.com/a/1",
+					Author:    "bob@example.com",
+					Published: at(2024, 1, 15, 9, 0),
+					Updated:   at(2024, 1, 15, 9, 0),
+					Summary:   "Short",
+					Content:   "<p>Long</p>",
+				}},
+			},
+		},
+		{
+			name: "RSS 1.0",
+			data: rdfDoc,
+			want: &Feed{
+				Format:      FormatRDF,
+				Title:       "Example RDF",
+				Description: "RSS 1.0",
+				Link:        "https://example.com/",
+				Items: []Item{{
+					GUID:      "https://example.com/r/1",
+					Title:     "RDF item",
+					Link:      "https://example.com/r/1",
+					Author:    "Carol",
+					Published: at(2024, 1, 15, 0, 0),
+				}},
+			},
+		},
+		{
+			name:        "JSON Feed sent as text/plain",
+			data:        jsonDoc,
+			contentType: "text/plain",
+			want: &Feed{
+				Format: FormatJSON,
+				Title:  "Example JSON",
+				Link:   "https://example.com/",
+				Hub:    "https://hub.example.com/",
+				Self:   "https://example.com/feed.json",
+				Items: []Item{
+					{
+						GUID:      "42",
+						Title:     "Numeric id",
+						Link:      "https://example.com/j/42",
+						Author:    "Dave",
+						Published: at(2024, 1, 15, 12, 0),
+						Updated:   at(2024, 1, 15, 12, 0),
+						Content:   "Text",
+					},
+					{
+						GUID:    "https://elsewhere.example.com/x",
+						Link:    "https://elsewhere.example.com/x",
+						Author:  "https://example.com/erin",
+						Content: "<p>HTML</p>",
+					},
+				},
+			},
+		},
+		{
+			name: "byte order mark and declared encoding",
+			data: "\xef\xbb\xbf<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><rss><channel><title>Caf\xe9</title></channel></rss>",
+			want: &Feed{Format: FormatRSS, Title: "Café"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.data), tt.contentType, tt.baseURL)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		contentType string
+		unknown     bool
+	}{
+		{"HTML page", "<html><body>Not a feed</body></html>", "text/html", true},
+		{"JSON without version", `{"title": "x"}`, "application/json", true},
+		{"empty document", "", "", true},
+		{"invalid JSON", `{"version": `, "application/json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data), tt.contentType, "")
+			if err == nil {
+				t.Fatal("Parse succeeded, want an error")
+			}
+			if errors.Is(err, ErrUnknownFormat) != tt.unknown {
+				t.Errorf("err = %v, ErrUnknownFormat expected: %v", err, tt.unknown)
+			}
+		})
+	}
+}
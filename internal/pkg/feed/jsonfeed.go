@@ -0,0 +1,116 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonFeedVersionPrefix identifies JSON Feed documents (https://www.jsonfeed.org/version/1.1/).
+const jsonFeedVersionPrefix = "https://jsonfeed.org/version/"
+
+type jsonFeedDocument struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Hubs        []jsonFeedHub  `json:"hubs"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type jsonFeedItem struct {
+	ID            json.RawMessage  `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Author        *jsonFeedAuthor  `json:"author"` // JSON Feed 1.0
+}
+
+func parseJSON(data []byte) (*Feed, error) {
+	var doc jsonFeedDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Feed: %w", err)
+	}
+	if !strings.HasPrefix(doc.Version, jsonFeedVersionPrefix) {
+		return nil, fmt.Errorf("%w: JSON document without a JSON Feed version", ErrUnknownFormat)
+	}
+
+	feed := &Feed{
+		Format:      FormatJSON,
+		Title:       firstNonEmpty(doc.Title),
+		Description: firstNonEmpty(doc.Description),
+		Link:        firstNonEmpty(doc.HomePageURL),
+		Self:        firstNonEmpty(doc.FeedURL),
+	}
+	for _, hub := range doc.Hubs {
+		if strings.EqualFold(hub.Type, "websub") || strings.EqualFold(hub.Type, "pubsubhubbub") {
+			feed.Hub = firstNonEmpty(hub.URL)
+			break
+		}
+	}
+
+	for _, it := range doc.Items {
+		link := firstNonEmpty(it.URL, it.ExternalURL)
+		published := parseDate(it.DatePublished)
+		updated := parseDate(it.DateModified)
+		if published == nil {
+			published = updated
+		}
+		feed.Items = append(feed.Items, Item{
+			GUID:      firstNonEmpty(jsonFeedID(it.ID), link),
+			Title:     firstNonEmpty(it.Title),
+			Link:      link,
+			Author:    jsonFeedAuthorName(it),
+			Published: published,
+			Updated:   updated,
+			Summary:   firstNonEmpty(it.Summary),
+			Content:   firstNonEmpty(it.ContentHTML, it.ContentText),
+		})
+	}
+
+	return feed, nil
+}
+
+// jsonFeedID returns the item id, which the spec requires to be a string but
+// some publishers emit as a number.
+func jsonFeedID(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String()
+	}
+	return ""
+}
+
+func jsonFeedAuthorName(it jsonFeedItem) string {
+	if len(it.Authors) > 0 {
+		return firstNonEmpty(it.Authors[0].Name, it.Authors[0].URL)
+	}
+	if it.Author != nil {
+		return firstNonEmpty(it.Author.Name, it.Author.URL)
+	}
+	return ""
+}
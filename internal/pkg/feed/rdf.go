@@ -0,0 +1,57 @@
+package feed
+
+import (
+	"fmt"
+)
+
+// rdfDocument is an RSS 1.0 document. Unlike RSS 2.0, items are siblings of
+// the channel rather than its children.
+type rdfDocument struct {
+	Channel rdfChannel `xml:"channel"`
+	Items   []rdfItem  `xml:"item"`
+}
+
+type rdfChannel struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+}
+
+type rdfItem struct {
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+func parseRDF(data []byte) (*Feed, error) {
+	var doc rdfDocument
+	if err := newDecoder(data).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse RSS 1.0: %w", err)
+	}
+
+	feed := &Feed{
+		Format:      FormatRDF,
+		Title:       firstNonEmpty(doc.Channel.Title),
+		Description: firstNonEmpty(doc.Channel.Description),
+		Link:        firstNonEmpty(doc.Channel.Link),
+	}
+
+	for _, it := range doc.Items {
+		link := firstNonEmpty(it.Link, it.About)
+		feed.Items = append(feed.Items, Item{
+			GUID:      firstNonEmpty(it.About, link),
+			Title:     firstNonEmpty(it.Title),
+			Link:      link,
+			Author:    firstNonEmpty(it.Creator),
+			Published: parseDate(it.Date),
+			Summary:   firstNonEmpty(it.Description),
+			Content:   firstNonEmpty(it.Content),
+		})
+	}
+
+	return feed, nil
+}
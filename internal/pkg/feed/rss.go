@@ -0,0 +1,94 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type rssDocument struct {
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Links       []rssLink `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+// rssLink matches both the plain <link> element and namespaced variants such as
+// <atom:link>, which carry their target in the href attribute instead.
+type rssLink struct {
+	XMLName xml.Name
+	Href    string `xml:"href,attr"`
+	Rel     string `xml:"rel,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string    `xml:"title"`
+	Links       []rssLink `xml:"link"`
+	GUID        string    `xml:"guid"`
+	Author      string    `xml:"author"`
+	Creator     string    `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	PubDate     string    `xml:"pubDate"`
+	Date        string    `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Description string    `xml:"description"`
+	Content     string    `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+func parseRSS(data []byte) (*Feed, error) {
+	var doc rssDocument
+	if err := newDecoder(data).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse RSS: %w", err)
+	}
+
+	feed := &Feed{
+		Format:      FormatRSS,
+		Title:       firstNonEmpty(doc.Channel.Title),
+		Description: firstNonEmpty(doc.Channel.Description),
+		Link:        rssPlainLink(doc.Channel.Links),
+		Hub:         rssRelLink(doc.Channel.Links, "hub"),
+		Self:        rssRelLink(doc.Channel.Links, "self"),
+	}
+
+	for _, it := range doc.Channel.Items {
+		link := rssPlainLink(it.Links)
+		feed.Items = append(feed.Items, Item{
+			GUID:      firstNonEmpty(it.GUID, link),
+			Title:     firstNonEmpty(it.Title),
+			Link:      link,
+			Author:    firstNonEmpty(it.Creator, it.Author),
+			Published: parseDate(firstNonEmpty(it.PubDate, it.Date)),
+			Summary:   firstNonEmpty(it.Description),
+			Content:   firstNonEmpty(it.Content),
+		})
+	}
+
+	return feed, nil
+}
+
+// rssPlainLink returns the text of the first non-namespaced <link> element.
+func rssPlainLink(links []rssLink) string {
+	for _, l := range links {
+		if l.XMLName.Space == "" {
+			if v := firstNonEmpty(l.Value); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// rssRelLink returns the href of the first namespaced link (e.g. <atom:link>) with the given rel.
+func rssRelLink(links []rssLink, rel string) string {
+	for _, l := range links {
+		if l.XMLName.Space != "" && strings.EqualFold(l.Rel, rel) {
+			if v := firstNonEmpty(l.Href); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
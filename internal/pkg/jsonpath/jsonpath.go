@@ -0,0 +1,163 @@
+// Package jsonpath selects values from decoded JSON documents with a subset of
+// JSONPath.
+//
+// A path starts with $, the document itself, followed by any number of steps:
+// .name or ['name'] selects a member of an object, [n] an element of an array
+// (negative indexes count from the end) and .* or [*] every member or element.
+// For example $.issue.labels[*].name selects the names of all labels of an
+// issue. Steps that do not apply to a value, such as a missing member, select
+// nothing.
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a parsed path.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// step selects a member by name, an element by index or, with wildcard, every
+// member or element.
+type step struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// Parse parses a path.
+func Parse(expr string) (*Path, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("path %q must start with $", expr)
+	}
+
+	p := &Path{expr: expr}
+	rest := expr[1:]
+	for rest != "" {
+		var s step
+		var err error
+		switch rest[0] {
+		case '.':
+			s, rest, err = parseDot(rest[1:])
+		case '[':
+			s, rest, err = parseBracket(rest[1:])
+		default:
+			err = fmt.Errorf("unexpected %q", rest[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %v", expr, err)
+		}
+		p.steps = append(p.steps, s)
+	}
+	return p, nil
+}
+
+// parseDot parses the member name or * following a dot.
+func parseDot(rest string) (step, string, error) {
+	end := strings.IndexAny(rest, ".[")
+	if end < 0 {
+		end = len(rest)
+	}
+	name := rest[:end]
+	if name == "" {
+		return step{}, "", fmt.Errorf("empty member name")
+	}
+	if name == "*" {
+		return step{wildcard: true}, rest[end:], nil
+	}
+	return step{name: name}, rest[end:], nil
+}
+
+// parseBracket parses a quoted member name, an index or * up to the closing bracket.
+func parseBracket(rest string) (step, string, error) {
+	if rest != "" && (rest[0] == '\'' || rest[0] == '"') {
+		quote := rest[0]
+		end := strings.IndexByte(rest[1:], quote)
+		if end < 0 {
+			return step{}, "", fmt.Errorf("unterminated member name")
+		}
+		name := rest[1 : end+1]
+		rest = rest[end+2:]
+		if !strings.HasPrefix(rest, "]") {
+			return step{}, "", fmt.Errorf("missing ] after member name %q", name)
+		}
+		return step{name: name}, rest[1:], nil
+	}
+
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return step{}, "", fmt.Errorf("missing ]")
+	}
+	inner := strings.TrimSpace(rest[:end])
+	if inner == "*" {
+		return step{wildcard: true}, rest[end+1:], nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return step{}, "", fmt.Errorf("invalid index %q", inner)
+	}
+	return step{index: index, isIndex: true}, rest[end+1:], nil
+}
+
+// String returns the path as it was parsed.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Get returns the values the path selects in a document decoded by
+// encoding/json into interface{} values. Members selected by a wildcard come
+// in the order of their names.
+func (p *Path) Get(doc interface{}) []interface{} {
+	values := []interface{}{doc}
+	for _, s := range p.steps {
+		var next []interface{}
+		for _, v := range values {
+			next = s.apply(v, next)
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		values = next
+	}
+	return values
+}
+
+func (s step) apply(v interface{}, out []interface{}) []interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			names := make([]string, 0, len(val))
+			for name := range val {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				out = append(out, val[name])
+			}
+		} else if !s.isIndex {
+			if member, ok := val[s.name]; ok {
+				out = append(out, member)
+			}
+		}
+	case []interface{}:
+		if s.wildcard {
+			out = append(out, val...)
+		} else if s.isIndex {
+			i := s.index
+			if i < 0 {
+				i += len(val)
+			}
+			if i >= 0 && i < len(val) {
+				out = append(out, val[i])
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const testDoc = `{
+	"issue": {
+		"title": "Crash on start",
+		"labels": [{"name": "bug"}, {"name": "p1"}, {"color": "red"}],
+		"user.name": "octo",
+		"it's": "quoted"
+	},
+	"counts": {"b": 2, "a": 1},
+	"list": [10, 20, 30],
+	"nested": [[1, 2], [3, 4]]
+}`
+
+func TestGet(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(testDoc), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want []interface{}
+	}{
+		{"$", []interface{}{doc}},
+		{"$.issue.title", []interface{}{"Crash on start"}},
+		{"$['issue']['title']", []interface{}{"Crash on start"}},
+		{`$["issue"]["title"]`, []interface{}{"Crash on start"}},
+		{"$.issue['user.name']", []interface{}{"octo"}},
+		{`$.issue["it's"]`, []interface{}{"quoted"}},
+		{"$.issue.labels[0].name", []interface{}{"bug"}},
+		{"$.issue.labels[*].name", []interface{}{"bug", "p1"}},
+		{"$.issue.labels.*.name", []interface{}{"bug", "p1"}},
+		{"$.list[-1]", []interface{}{30.0}},
+		{"$.list[-3]", []interface{}{10.0}},
+		{"$.list[ 1 ]", []interface{}{20.0}},
+		{"$.counts.*", []interface{}{1.0, 2.0}},
+		{"$.counts[*]", []interface{}{1.0, 2.0}},
+		{"$.nested[*][-1]", []interface{}{2.0, 4.0}},
+		{"$.list[3]", nil},
+		{"$.list[-4]", nil},
+		{"$.missing", nil},
+		{"$.issue.title.length", nil},
+		{"$.list.name", nil},
+		{"$.counts[0]", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p, err := Parse(tt.path)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.path, err)
+			}
+			if got := p.Get(doc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"issue.title",
+		"$.",
+		"$..title",
+		"$.issue[",
+		"$.issue['title'",
+		"$.issue['title'x]",
+		"$.list[one]",
+		"$issue",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := Parse(expr); err == nil {
+				t.Errorf("Parse(%q) succeeded, want an error", expr)
+			}
+		})
+	}
+}
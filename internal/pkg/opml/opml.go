@@ -0,0 +1,130 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Document is an OPML 2.0 document.
+type Document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is either a folder (no xmlUrl, has children) or a feed subscription.
+type Outline struct {
+	Text        string    `xml:"text,attr"`
+	Title       string    `xml:"title,attr,omitempty"`
+	Type        string    `xml:"type,attr,omitempty"`
+	XMLURL      string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL     string    `xml:"htmlUrl,attr,omitempty"`
+	Description string    `xml:"description,attr,omitempty"`
+	Category    string    `xml:"category,attr,omitempty"`
+	Outlines    []Outline `xml:"outline"`
+}
+
+// Subscription is a feed outline flattened together with the folders that contain it.
+type Subscription struct {
+	Title       string
+	XMLURL      string
+	HTMLURL     string
+	Description string
+	// Folders is the path of enclosing folder outlines, outermost first.
+	Folders []string
+	// Categories holds the entries of the outline's category attribute,
+	// e.g. "/Tech/Go,News" yields ["Tech", "Go", "News"].
+	Categories []string
+}
+
+// Parse decodes an OPML document.
+func Parse(r io.Reader) (*Document, error) {
+	var doc Document
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid OPML: %w", err)
+	}
+	return &doc, nil
+}
+
+// Write encodes doc as an indented OPML document with an XML prolog.
+func Write(w io.Writer, doc *Document) error {
+	if doc.Version == "" {
+		doc.Version = "2.0"
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Subscriptions walks the outline tree and returns every feed outline in document order.
+func (d *Document) Subscriptions() []Subscription {
+	var subs []Subscription
+	var walk func(outlines []Outline, folders []string)
+	walk = func(outlines []Outline, folders []string) {
+		for _, o := range outlines {
+			if strings.TrimSpace(o.XMLURL) != "" {
+				subs = append(subs, Subscription{
+					Title:       firstNonEmpty(o.Title, o.Text, o.XMLURL),
+					XMLURL:      strings.TrimSpace(o.XMLURL),
+					HTMLURL:     strings.TrimSpace(o.HTMLURL),
+					Description: strings.TrimSpace(o.Description),
+					Folders:     append([]string(nil), folders...),
+					Categories:  splitCategories(o.Category),
+				})
+			}
+			if len(o.Outlines) > 0 {
+				name := firstNonEmpty(o.Text, o.Title)
+				next := folders
+				if name != "" && o.XMLURL == "" {
+					next = append(append([]string(nil), folders...), name)
+				}
+				walk(o.Outlines, next)
+			}
+		}
+	}
+	walk(d.Body.Outlines, nil)
+	return subs
+}
+
+// splitCategories parses the comma-separated, slash-delimited category attribute.
+func splitCategories(attr string) []string {
+	var out []string
+	for _, group := range strings.Split(attr, ",") {
+		for _, part := range strings.Split(group, "/") {
+			if p := strings.TrimSpace(part); p != "" {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if s := strings.TrimSpace(v); s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -17,6 +17,11 @@ func Created(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusCreated, gin.H{"data": data})
 }
 
+// Accepted sends a 202 response for work that continues in the background.
+func Accepted(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, gin.H{"data": data})
+}
+
 // Page sends a paginated 200 response.
 func Page(c *gin.Context, data interface{}, total int64, page, perPage int) {
 	c.JSON(http.StatusOK, gin.H{
@@ -42,6 +47,11 @@ func Error(c *gin.Context, status int, msg string) {
 	c.JSON(status, gin.H{"error": msg})
 }
 
+// Conflict sends a 409 error response pointing at the existing record.
+func Conflict(c *gin.Context, msg string, existing interface{}) {
+	c.JSON(http.StatusConflict, gin.H{"error": msg, "existing": existing})
+}
+
 // BadRequest sends a 400 error response.
 func BadRequest(c *gin.Context, msg string) {
 	c.JSON(http.StatusBadRequest, gin.H{"error": msg})
@@ -52,6 +62,12 @@ func NotFound(c *gin.Context, msg string) {
 	c.JSON(http.StatusNotFound, gin.H{"error": msg})
 }
 
+// BadGateway sends a 502 error response along with the record of the failed
+// call to an upstream service.
+func BadGateway(c *gin.Context, msg string, data interface{}) {
+	c.JSON(http.StatusBadGateway, gin.H{"error": msg, "data": data})
+}
+
 // InternalError sends a 500 error response.
 func InternalError(c *gin.Context, msg string) {
 	c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
@@ -0,0 +1,170 @@
+// Package robots parses robots.txt files (RFC 9309) and answers whether a
+// crawler may fetch a path.
+package robots
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rules is a parsed robots.txt file.
+type Rules struct {
+	groups []group
+}
+
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+type rule struct {
+	allow   bool
+	length  int // pattern length, the most specific match wins
+	pattern *regexp.Regexp
+}
+
+// AllowAll is the rule set used when a site has no robots.txt.
+var AllowAll = &Rules{}
+
+// DisallowAll is the rule set used when robots.txt is unreachable, which the
+// RFC treats as a complete disallow.
+var DisallowAll = &Rules{groups: []group{{
+	agents: []string{"*"},
+	rules:  []rule{{allow: false, length: 1, pattern: regexp.MustCompile(`^/`)}},
+}}}
+
+// Parse reads a robots.txt file. Unknown and malformed lines are ignored.
+func Parse(r io.Reader) *Rules {
+	rules := &Rules{}
+	var current *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share one group
+			if current == nil || !inAgents {
+				rules.groups = append(rules.groups, group{})
+				current = &rules.groups[len(rules.groups)-1]
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, rule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: compilePattern(value),
+			})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+	return rules
+}
+
+// compilePattern turns a path pattern with * wildcards and an optional $ end
+// anchor into a regular expression.
+func compilePattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// groupsFor returns the groups that apply to the user agent: the groups naming
+// it, or the * groups if none does.
+func (r *Rules) groupsFor(userAgent string) []group {
+	agent := strings.ToLower(userAgent)
+	var matched, wildcard []group
+	for _, g := range r.groups {
+		for _, a := range g.agents {
+			if a == agent {
+				matched = append(matched, g)
+				break
+			}
+			if a == "*" {
+				wildcard = append(wildcard, g)
+				break
+			}
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	}
+	return wildcard
+}
+
+// Allowed reports whether the user agent may fetch path, which should include
+// the query string. The longest matching rule wins and allow wins ties.
+func (r *Rules) Allowed(userAgent, path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed, best := true, -1
+	for _, g := range r.groupsFor(userAgent) {
+		for _, rl := range g.rules {
+			if !rl.pattern.MatchString(path) {
+				continue
+			}
+			if rl.length > best || (rl.length == best && rl.allow) {
+				allowed, best = rl.allow, rl.length
+			}
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay returns the Crawl-delay requested for the user agent, or zero.
+func (r *Rules) CrawlDelay(userAgent string) time.Duration {
+	var delay time.Duration
+	for _, g := range r.groupsFor(userAgent) {
+		if g.crawlDelay > delay {
+			delay = g.crawlDelay
+		}
+	}
+	return delay
+}
+
+// ProductToken returns the robots.txt name of a User-Agent header, e.g.
+// "Bellkeeper" for "Bellkeeper/1.0 (+https://...)".
+func ProductToken(userAgent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(userAgent), "/")
+	if i := strings.IndexAny(token, " \t("); i >= 0 {
+		token = token[:i]
+	}
+	return token
+}
@@ -0,0 +1,122 @@
+package robots
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRobots = `
+# Example
+User-agent: *
+Disallow: /private/
+Allow: /private/public/
+Disallow: /*.pdf$
+Allow: /tie
+Disallow: /tie
+Disallow: /search?
+Crawl-delay: 2
+
+User-agent: Bellkeeper
+User-agent: OtherBot
+Disallow: /admin
+Allow: /admin/help
+Allow: /page
+Disallow: /page$
+Crawl-delay: 0.5
+`
+
+func TestAllowed(t *testing.T) {
+	rules := Parse(strings.NewReader(testRobots))
+
+	tests := []struct {
+		name  string
+		agent string
+		path  string
+		want  bool
+	}{
+		{"no matching rule", "SomeBot", "/about", true},
+		{"disallowed prefix", "SomeBot", "/private/notes", false},
+		{"longer allow wins", "SomeBot", "/private/public/index.html", true},
+		{"wildcard with end anchor", "SomeBot", "/files/report.pdf", false},
+		{"end anchor does not match longer path", "SomeBot", "/files/report.pdf.html", true},
+		{"allow wins a tie", "SomeBot", "/tie", true},
+		{"query string", "SomeBot", "/search?q=go", false},
+		{"robots.txt is always allowed", "SomeBot", "/robots.txt", true},
+		{"empty path is the root", "SomeBot", "", true},
+		{"named group replaces the wildcard group", "Bellkeeper", "/private/notes", true},
+		{"agent names are case-insensitive", "bellkeeper", "/admin/users", false},
+		{"longer allow wins in named group", "Bellkeeper", "/admin/help/faq", true},
+		{"shared group", "OtherBot", "/admin", false},
+		{"longer anchored disallow wins", "Bellkeeper", "/page", false},
+		{"shorter allow applies past the anchor", "Bellkeeper", "/pages", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Allowed(tt.agent, tt.path); got != tt.want {
+				t.Errorf("Allowed(%q, %q) = %v, want %v", tt.agent, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules *Rules
+		path  string
+		want  bool
+	}{
+		{"allow all", AllowAll, "/anything", true},
+		{"disallow all", DisallowAll, "/anything", false},
+		{"disallow all keeps robots.txt", DisallowAll, "/robots.txt", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.Allowed("Bellkeeper", tt.path); got != tt.want {
+				t.Errorf("Allowed(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrawlDelay(t *testing.T) {
+	rules := Parse(strings.NewReader(testRobots))
+
+	tests := []struct {
+		agent string
+		want  time.Duration
+	}{
+		{"SomeBot", 2 * time.Second},
+		{"Bellkeeper", 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.agent, func(t *testing.T) {
+			if got := rules.CrawlDelay(tt.agent); got != tt.want {
+				t.Errorf("CrawlDelay(%q) = %v, want %v", tt.agent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductToken(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Bellkeeper/1.0 (+https://example.com/bot)", "Bellkeeper"},
+		{"Bellkeeper (+https://example.com/bot)", "Bellkeeper"},
+		{"  Bellkeeper  ", "Bellkeeper"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userAgent, func(t *testing.T) {
+			if got := ProductToken(tt.userAgent); got != tt.want {
+				t.Errorf("ProductToken(%q) = %q, want %q", tt.userAgent, got, tt.want)
+			}
+		})
+	}
+}
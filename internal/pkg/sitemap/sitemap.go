@@ -0,0 +1,140 @@
+// Package sitemap parses sitemap and sitemap index files (https://www.sitemaps.org/protocol.html),
+// plain or gzip-compressed, in XML or plain text form.
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// MaxSize is the largest uncompressed sitemap the protocol allows.
+const MaxSize = 50 << 20
+
+// ErrUnknownFormat is returned when the document is neither a urlset nor a sitemap index.
+var ErrUnknownFormat = errors.New("unknown sitemap format")
+
+// Entry is a page or child sitemap listed in a sitemap.
+type Entry struct {
+	Loc     string
+	LastMod *time.Time
+}
+
+// Sitemap is a parsed sitemap file. A sitemap index lists child sitemaps
+// instead of pages.
+type Sitemap struct {
+	URLs     []Entry
+	Sitemaps []Entry
+}
+
+type document struct {
+	XMLName  xml.Name
+	URLs     []xmlEntry `xml:"url"`
+	Sitemaps []xmlEntry `xml:"sitemap"`
+}
+
+type xmlEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// Parse reads a sitemap, transparently decompressing gzip input.
+func Parse(r io.Reader) (*Sitemap, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip data: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize))
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] != '<' {
+		return parseText(trimmed), nil
+	}
+	return parseXML(data)
+}
+
+func parseXML(data []byte) (*Sitemap, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.CharsetReader = charset.NewReaderLabel
+
+	var doc document
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap: %w", err)
+	}
+	switch doc.XMLName.Local {
+	case "urlset", "sitemapindex":
+	default:
+		return nil, fmt.Errorf("%w: root element <%s>", ErrUnknownFormat, doc.XMLName.Local)
+	}
+
+	sm := &Sitemap{}
+	for _, e := range doc.URLs {
+		if loc := strings.TrimSpace(e.Loc); loc != "" {
+			sm.URLs = append(sm.URLs, Entry{Loc: loc, LastMod: parseLastMod(e.LastMod)})
+		}
+	}
+	for _, e := range doc.Sitemaps {
+		if loc := strings.TrimSpace(e.Loc); loc != "" {
+			sm.Sitemaps = append(sm.Sitemaps, Entry{Loc: loc, LastMod: parseLastMod(e.LastMod)})
+		}
+	}
+	return sm, nil
+}
+
+// parseText reads a text sitemap: one absolute URL per line.
+func parseText(data []byte) *Sitemap {
+	sm := &Sitemap{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			sm.URLs = append(sm.URLs, Entry{Loc: line})
+		}
+	}
+	return sm
+}
+
+// lastModLayouts are the W3C Datetime forms allowed in <lastmod>.
+var lastModLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func parseLastMod(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const urlsetXML = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://example.com/a </loc><lastmod>2024-03-01T10:00:00+02:00</lastmod></url>
+  <url><loc>https://example.com/b</loc><lastmod>2024-03-02</lastmod></url>
+  <url><loc>https://example.com/c</loc><lastmod>yesterday</lastmod></url>
+  <url><loc></loc></url>
+</urlset>`
+
+const indexXML = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-posts.xml.gz</loc><lastmod>2024-03</lastmod></sitemap>
+  <sitemap><loc>https://example.com/sitemap-pages.xml</loc></sitemap>
+</sitemapindex>`
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func date(year int, month time.Month, day, hour int) *time.Time {
+	t := time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	urls := []Entry{
+		{Loc: "https://example.com/a", LastMod: date(2024, 3, 1, 8)},
+		{Loc: "https://example.com/b", LastMod: date(2024, 3, 2, 0)},
+		{Loc: "https://example.com/c"},
+	}
+	sitemaps := []Entry{
+		{Loc: "https://example.com/sitemap-posts.xml.gz", LastMod: date(2024, 3, 1, 0)},
+		{Loc: "https://example.com/sitemap-pages.xml"},
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want *Sitemap
+	}{
+		{"urlset", []byte(urlsetXML), &Sitemap{URLs: urls}},
+		{"urlset with BOM", append([]byte("\xef\xbb\xbf"), urlsetXML...), &Sitemap{URLs: urls}},
+		{"gzip urlset", gzipped(t, urlsetXML), &Sitemap{URLs: urls}},
+		{"index", []byte(indexXML), &Sitemap{Sitemaps: sitemaps}},
+		{"gzip index", gzipped(t, indexXML), &Sitemap{Sitemaps: sitemaps}},
+		{
+			"text",
+			[]byte("https://example.com/a\r\n\nnot a url\nhttp://example.com/b\n"),
+			&Sitemap{URLs: []Entry{{Loc: "https://example.com/a"}, {Loc: "http://example.com/b"}}},
+		},
+		{
+			"gzip text",
+			gzipped(t, "https://example.com/a\n"),
+			&Sitemap{URLs: []Entry{{Loc: "https://example.com/a"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		unknown bool
+	}{
+		{"unknown root element", []byte(`<rss><channel></channel></rss>`), true},
+		{"broken gzip", []byte{0x1f, 0x8b, 0x00}, false},
+		{"empty XML", []byte(`<`), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(bytes.NewReader(tt.data))
+			if err == nil {
+				t.Fatal("Parse succeeded, want an error")
+			}
+			if errors.Is(err, ErrUnknownFormat) != tt.unknown {
+				t.Errorf("err = %v, ErrUnknownFormat expected: %v", err, tt.unknown)
+			}
+		})
+	}
+}
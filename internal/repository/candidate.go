@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"github.com/singll/bellkeeper/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CandidateArticleRepository struct {
+	db *gorm.DB
+}
+
+func NewCandidateArticleRepository(db *gorm.DB) *CandidateArticleRepository {
+	return &CandidateArticleRepository{db: db}
+}
+
+func (r *CandidateArticleRepository) List(page, perPage int, dataSourceID uint, status, keyword string) ([]model.CandidateArticle, int64, error) {
+	var candidates []model.CandidateArticle
+	var total int64
+
+	query := r.db.Model(&model.CandidateArticle{})
+	if dataSourceID != 0 {
+		query = query.Where("data_source_id = ?", dataSourceID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if keyword != "" {
+		query = query.Where("title ILIKE ? OR url ILIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Preload("DataSource").
+		Offset(offset).Limit(perPage).
+		Order("id DESC").
+		Find(&candidates).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return candidates, total, nil
+}
+
+// CreateIfNotExists inserts the candidate unless its URL is already queued for
+// the data source, and reports whether a row was inserted
+func (r *CandidateArticleRepository) CreateIfNotExists(candidate *model.CandidateArticle) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "data_source_id"}, {Name: "url"}},
+		DoNothing: true,
+	}).Create(candidate)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Requeue inserts the candidate or, if its URL is already known, resets it to new
+// with the updated last-modified time
+func (r *CandidateArticleRepository) Requeue(candidate *model.CandidateArticle) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "data_source_id"}, {Name: "url"}},
+		DoUpdates: clause.AssignmentColumns([]string{"found_on", "last_modified", "status", "updated_at"}),
+	}).Create(candidate).Error
+}
@@ -143,6 +143,15 @@ func (r *DatasetMappingRepository) DeleteArticleTagsByDocumentIDs(documentIDs []
 	return r.db.Where("document_id IN ?", documentIDs).Delete(&model.ArticleTag{}).Error
 }
 
+// RepointArticleTags moves the article-tag associations of a replaced document to its new version
+func (r *DatasetMappingRepository) RepointArticleTags(oldDocumentID, newDocumentID, datasetID, title string) error {
+	return r.db.Model(&model.ArticleTag{}).Where("document_id = ?", oldDocumentID).Updates(map[string]interface{}{
+		"document_id":   newDocumentID,
+		"dataset_id":    datasetID,
+		"article_title": title,
+	}).Error
+}
+
 // FindArticleTagsByURL finds article tags matching the exact URL
 func (r *DatasetMappingRepository) FindArticleTagsByURL(url string) ([]model.ArticleTag, error) {
 	var ats []model.ArticleTag
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/urlutil"
 	"gorm.io/gorm"
 )
 
@@ -17,7 +20,7 @@ func (r *DataSourceRepository) List(page, perPage int, category, keyword string)
 	var sources []model.DataSource
 	var total int64
 
-	query := r.db.Model(&model.DataSource{}).Preload("Tags")
+	query := r.db.Model(&model.DataSource{}).Preload("Tags").Preload("Reachability")
 	if category != "" {
 		query = query.Where("category = ?", category)
 	}
@@ -37,20 +40,45 @@ func (r *DataSourceRepository) List(page, perPage int, category, keyword string)
 	return sources, total, nil
 }
 
+// GetAll returns every data source with its tags, oldest first
+func (r *DataSourceRepository) GetAll() ([]model.DataSource, error) {
+	var sources []model.DataSource
+	if err := r.db.Preload("Tags").Order("id").Find(&sources).Error; err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
 func (r *DataSourceRepository) GetByID(id uint) (*model.DataSource, error) {
 	var source model.DataSource
-	if err := r.db.Preload("Tags").First(&source, id).Error; err != nil {
+	if err := r.db.Preload("Tags").Preload("Reachability").First(&source, id).Error; err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
+
+// GetByNormalizedURL returns the live source whose normalized URL matches, other than excludeID
+func (r *DataSourceRepository) GetByNormalizedURL(normalized string, excludeID uint) (*model.DataSource, error) {
+	var source model.DataSource
+	if err := r.db.Where("normalized_url = ? AND id <> ?", normalized, excludeID).First(&source).Error; err != nil {
 		return nil, err
 	}
 	return &source, nil
 }
 
 func (r *DataSourceRepository) Create(source *model.DataSource) error {
+	source.NormalizedURL = urlutil.Normalize(source.URL)
 	return r.db.Create(source).Error
 }
 
+// Update stores the editable fields only, so that it cannot overwrite the
+// outcome of a crawl, sitemap run or reachability check made meanwhile
 func (r *DataSourceRepository) Update(source *model.DataSource) error {
-	return r.db.Save(source).Error
+	source.NormalizedURL = urlutil.Normalize(source.URL)
+	return r.db.Model(source).
+		Select("name", "url", "normalized_url", "type", "category", "description", "is_active",
+			"extract_full_text", "watch", "metadata").
+		Updates(source).Error
 }
 
 func (r *DataSourceRepository) Delete(id uint) error {
@@ -60,3 +88,52 @@ func (r *DataSourceRepository) Delete(id uint) error {
 func (r *DataSourceRepository) UpdateTags(source *model.DataSource, tags []model.Tag) error {
 	return r.db.Model(source).Association("Tags").Replace(tags)
 }
+
+// GetDueForCrawl returns active sources of the given types not crawled since the given time
+func (r *DataSourceRepository) GetDueForCrawl(types []string, crawledBefore time.Time) ([]model.DataSource, error) {
+	var sources []model.DataSource
+	err := r.db.Preload("Tags").
+		Where("is_active = ? AND type IN ?", true, types).
+		Where("last_crawled_at IS NULL OR last_crawled_at < ?", crawledBefore).
+		Order("last_crawled_at ASC NULLS FIRST, id ASC").
+		Find(&sources).Error
+	return sources, err
+}
+
+// RecordCrawl stores the time and error (empty on success) of a crawl
+func (r *DataSourceRepository) RecordCrawl(id uint, at time.Time, errMsg string) error {
+	return r.db.Model(&model.DataSource{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_crawled_at":  at,
+		"last_crawl_error": errMsg,
+	}).Error
+}
+
+// RecordSitemapSync moves the baseline of incremental sitemap runs forward
+func (r *DataSourceRepository) RecordSitemapSync(id uint, at time.Time) error {
+	return r.db.Model(&model.DataSource{}).Where("id = ?", id).Update("sitemap_synced_at", at).Error
+}
+
+// GetDueForWatch returns active watched sources whose page was not checked since
+// the given time, least recently checked first
+func (r *DataSourceRepository) GetDueForWatch(checkedBefore time.Time) ([]model.DataSource, error) {
+	var sources []model.DataSource
+	err := r.db.Preload("Tags").
+		Joins("LEFT JOIN page_snapshots ON page_snapshots.data_source_id = data_sources.id").
+		Where("data_sources.is_active = ? AND data_sources.watch = ?", true, true).
+		Where("page_snapshots.checked_at IS NULL OR page_snapshots.checked_at < ?", checkedBefore).
+		Order("page_snapshots.checked_at ASC NULLS FIRST, data_sources.id ASC").
+		Find(&sources).Error
+	return sources, err
+}
+
+// GetDueForReachability returns active sources whose URL was not checked since the given time
+func (r *DataSourceRepository) GetDueForReachability(checkedBefore time.Time) ([]model.DataSource, error) {
+	var sources []model.DataSource
+	err := r.db.Preload("Tags").
+		Joins("LEFT JOIN data_source_statuses ON data_source_statuses.data_source_id = data_sources.id").
+		Where("data_sources.is_active = ?", true).
+		Where("data_source_statuses.checked_at IS NULL OR data_source_statuses.checked_at < ?", checkedBefore).
+		Order("data_source_statuses.checked_at ASC NULLS FIRST, data_sources.id ASC").
+		Find(&sources).Error
+	return sources, err
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"github.com/singll/bellkeeper/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExtractedPageRepository struct {
+	db *gorm.DB
+}
+
+func NewExtractedPageRepository(db *gorm.DB) *ExtractedPageRepository {
+	return &ExtractedPageRepository{db: db}
+}
+
+func (r *ExtractedPageRepository) GetByURL(url string) (*model.ExtractedPage, error) {
+	var page model.ExtractedPage
+	if err := r.db.Where("url = ?", url).First(&page).Error; err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Upsert stores the page, replacing any earlier extraction of the same URL
+func (r *ExtractedPageRepository) Upsert(page *model.ExtractedPage) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "url"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "content", "length", "updated_at"}),
+	}).Create(page).Error
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"github.com/singll/bellkeeper/internal/model"
+	"gorm.io/gorm"
+)
+
+type FeedEntryRepository struct {
+	db *gorm.DB
+}
+
+func NewFeedEntryRepository(db *gorm.DB) *FeedEntryRepository {
+	return &FeedEntryRepository{db: db}
+}
+
+// List returns entries newest first. A zero feedID lists entries across all feeds.
+func (r *FeedEntryRepository) List(page, perPage int, feedID uint, keyword string) ([]model.FeedEntry, int64, error) {
+	var entries []model.FeedEntry
+	var total int64
+
+	query := r.db.Model(&model.FeedEntry{})
+	if feedID != 0 {
+		query = query.Where("feed_id = ?", feedID)
+	}
+	if keyword != "" {
+		query = query.Where("title ILIKE ? OR link ILIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Preload("Feed").
+		Offset(offset).Limit(perPage).
+		Order("published_at DESC NULLS LAST, id DESC").
+		Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+func (r *FeedEntryRepository) GetByID(id uint) (*model.FeedEntry, error) {
+	var entry model.FeedEntry
+	if err := r.db.First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *FeedEntryRepository) GetByFeedAndGUID(feedID uint, guid string) (*model.FeedEntry, error) {
+	var entry model.FeedEntry
+	if err := r.db.Where("feed_id = ? AND guid = ?", feedID, guid).First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *FeedEntryRepository) Create(entry *model.FeedEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *FeedEntryRepository) Update(entry *model.FeedEntry) error {
+	return r.db.Omit("Feed").Save(entry).Error
+}
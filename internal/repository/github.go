@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"github.com/singll/bellkeeper/internal/model"
+	"gorm.io/gorm"
+)
+
+type GitHubItemRepository struct {
+	db *gorm.DB
+}
+
+func NewGitHubItemRepository(db *gorm.DB) *GitHubItemRepository {
+	return &GitHubItemRepository{db: db}
+}
+
+// ListByDataSource returns the ingested items of a data source, optionally of one kind, newest first
+func (r *GitHubItemRepository) ListByDataSource(dataSourceID uint, kind string) ([]model.GitHubItem, error) {
+	var items []model.GitHubItem
+	query := r.db.Where("data_source_id = ?", dataSourceID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	err := query.Order("published_at DESC NULLS LAST, id DESC").Find(&items).Error
+	return items, err
+}
+
+func (r *GitHubItemRepository) Save(item *model.GitHubItem) error {
+	return r.db.Save(item).Error
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"github.com/singll/bellkeeper/internal/model"
+	"gorm.io/gorm"
+)
+
+type InboundHookRepository struct {
+	db *gorm.DB
+}
+
+func NewInboundHookRepository(db *gorm.DB) *InboundHookRepository {
+	return &InboundHookRepository{db: db}
+}
+
+func (r *InboundHookRepository) List(page, perPage int) ([]model.InboundHook, int64, error) {
+	var hooks []model.InboundHook
+	var total int64
+
+	query := r.db.Model(&model.InboundHook{})
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Offset(offset).Limit(perPage).Order("name").Find(&hooks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return hooks, total, nil
+}
+
+func (r *InboundHookRepository) GetByID(id uint) (*model.InboundHook, error) {
+	var hook model.InboundHook
+	if err := r.db.First(&hook, id).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *InboundHookRepository) GetBySlug(slug string) (*model.InboundHook, error) {
+	var hook model.InboundHook
+	if err := r.db.Where("slug = ?", slug).First(&hook).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *InboundHookRepository) Create(hook *model.InboundHook) error {
+	return r.db.Create(hook).Error
+}
+
+// Update stores the editable fields only, so that it cannot overwrite the
+// outcome of a request received meanwhile
+func (r *InboundHookRepository) Update(hook *model.InboundHook) error {
+	return r.db.Model(hook).
+		Select("name", "slug", "description", "auth_type", "secret", "signature_header", "timestamp_header", "mapping", "is_active").
+		Updates(hook).Error
+}
+
+func (r *InboundHookRepository) Delete(id uint) error {
+	return r.db.Delete(&model.InboundHook{}, id).Error
+}
+
+// RecordReceipt stores the outcome of a request
+func (r *InboundHookRepository) RecordReceipt(hook *model.InboundHook) error {
+	return r.db.Model(hook).Updates(map[string]interface{}{
+		"last_received_at": hook.LastReceivedAt,
+		"last_status":      hook.LastStatus,
+		"last_error":       hook.LastError,
+		"last_document_id": hook.LastDocumentID,
+	}).Error
+}
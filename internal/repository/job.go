@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// List returns jobs newest first without their payloads, optionally filtered by type and status
+func (r *JobRepository) List(page, perPage int, jobType, status string) ([]model.Job, int64, error) {
+	var jobs []model.Job
+	var total int64
+
+	query := r.db.Model(&model.Job{})
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Omit("payload").Offset(offset).Limit(perPage).Order("id DESC").Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return jobs, total, nil
+}
+
+func (r *JobRepository) GetByID(id uint) (*model.Job, error) {
+	var job model.Job
+	if err := r.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *JobRepository) Create(job *model.Job) error {
+	return r.db.Create(job).Error
+}
+
+func (r *JobRepository) Save(job *model.Job) error {
+	return r.db.Save(job).Error
+}
+
+// Claim marks the next due job as running and returns it. Due jobs are queued
+// jobs whose run time has come and running jobs started before staleBefore,
+// whose worker is presumed dead. Rows locked by other workers are skipped, so
+// several processes can share the queue. It returns gorm.ErrRecordNotFound
+// when no job is due.
+func (r *JobRepository) Claim(now, staleBefore time.Time) (*model.Job, error) {
+	var job model.Job
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND started_at < ?)",
+				model.JobStatusQueued, now, model.JobStatusRunning, staleBefore).
+			Order("run_at, id").
+			Take(&job).Error
+		if err != nil {
+			return err
+		}
+
+		job.Status = model.JobStatusRunning
+		job.Attempts++
+		job.StartedAt = &now
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":     job.Status,
+			"attempts":   job.Attempts,
+			"started_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// UpdateProgress stores the item counters of a running job
+func (r *JobRepository) UpdateProgress(job *model.Job) error {
+	return r.db.Model(job).Updates(map[string]interface{}{
+		"total":  job.Total,
+		"done":   job.Done,
+		"failed": job.Failed,
+	}).Error
+}
+
+// DeleteFinishedBefore removes succeeded and failed jobs that finished before the given time
+func (r *JobRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status IN ? AND finished_at < ?",
+		[]string{model.JobStatusSucceeded, model.JobStatusFailed}, before).
+		Delete(&model.Job{})
+	return result.RowsAffected, result.Error
+}
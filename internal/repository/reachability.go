@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"gorm.io/gorm"
+)
+
+type DataSourceStatusRepository struct {
+	db *gorm.DB
+}
+
+func NewDataSourceStatusRepository(db *gorm.DB) *DataSourceStatusRepository {
+	return &DataSourceStatusRepository{db: db}
+}
+
+func (r *DataSourceStatusRepository) GetByDataSourceID(dataSourceID uint) (*model.DataSourceStatus, error) {
+	var status model.DataSourceStatus
+	if err := r.db.Where("data_source_id = ?", dataSourceID).First(&status).Error; err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (r *DataSourceStatusRepository) Save(status *model.DataSourceStatus) error {
+	return r.db.Save(status).Error
+}
+
+// activeSources limits a status query to active, non-deleted data sources
+func (r *DataSourceStatusRepository) activeSources() *gorm.DB {
+	return r.db.Model(&model.DataSourceStatus{}).
+		Joins("JOIN data_sources ON data_sources.id = data_source_statuses.data_source_id").
+		Where("data_sources.is_active = ? AND data_sources.deleted_at IS NULL", true)
+}
+
+// CountByState returns the number of active data sources in each reachability state
+func (r *DataSourceStatusRepository) CountByState() (map[string]int64, error) {
+	var rows []struct {
+		State string
+		Count int64
+	}
+	if err := r.activeSources().
+		Select("data_source_statuses.state AS state, COUNT(*) AS count").
+		Group("data_source_statuses.state").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.State] = row.Count
+	}
+	return counts, nil
+}
+
+// CountTLSExpiring returns the number of active data sources whose certificate expires before the given time
+func (r *DataSourceStatusRepository) CountTLSExpiring(before time.Time) (int64, error) {
+	var count int64
+	err := r.activeSources().
+		Where("data_source_statuses.tls_expires_at < ?", before).
+		Count(&count).Error
+	return count, err
+}
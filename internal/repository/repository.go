@@ -8,10 +8,20 @@ import (
 type Repositories struct {
 	Tag            *TagRepository
 	DataSource     *DataSourceRepository
+	Candidate      *CandidateArticleRepository
+	PageSnapshot   *PageSnapshotRepository
+	GitHubItem     *GitHubItemRepository
+	SourceStatus   *DataSourceStatusRepository
 	RSS            *RSSRepository
+	FeedEntry      *FeedEntryRepository
+	ExtractedPage  *ExtractedPageRepository
+	WebSub         *WebSubRepository
 	Webhook        *WebhookRepository
 	DatasetMapping *DatasetMappingRepository
 	Setting        *SettingRepository
+	Job            *JobRepository
+	Schedule       *ScheduleRepository
+	InboundHook    *InboundHookRepository
 }
 
 // NewRepositories creates all repository instances
@@ -19,9 +29,19 @@ func NewRepositories(db *gorm.DB) *Repositories {
 	return &Repositories{
 		Tag:            NewTagRepository(db),
 		DataSource:     NewDataSourceRepository(db),
+		Candidate:      NewCandidateArticleRepository(db),
+		PageSnapshot:   NewPageSnapshotRepository(db),
+		GitHubItem:     NewGitHubItemRepository(db),
+		SourceStatus:   NewDataSourceStatusRepository(db),
 		RSS:            NewRSSRepository(db),
+		FeedEntry:      NewFeedEntryRepository(db),
+		ExtractedPage:  NewExtractedPageRepository(db),
+		WebSub:         NewWebSubRepository(db),
 		Webhook:        NewWebhookRepository(db),
 		DatasetMapping: NewDatasetMappingRepository(db),
 		Setting:        NewSettingRepository(db),
+		Job:            NewJobRepository(db),
+		Schedule:       NewScheduleRepository(db),
+		InboundHook:    NewInboundHookRepository(db),
 	}
 }
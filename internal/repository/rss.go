@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/urlutil"
 	"gorm.io/gorm"
 )
 
@@ -13,7 +16,9 @@ func NewRSSRepository(db *gorm.DB) *RSSRepository {
 	return &RSSRepository{db: db}
 }
 
-func (r *RSSRepository) List(page, perPage int, category, keyword string) ([]model.RSSFeed, int64, error) {
+// List returns feeds filtered by category, keyword and health ("healthy" or "unhealthy", empty means all).
+// A feed is unhealthy once it has failed at least unhealthyAfter times in a row.
+func (r *RSSRepository) List(page, perPage int, category, keyword, health string, unhealthyAfter int) ([]model.RSSFeed, int64, error) {
 	var feeds []model.RSSFeed
 	var total int64
 
@@ -24,6 +29,12 @@ func (r *RSSRepository) List(page, perPage int, category, keyword string) ([]mod
 	if keyword != "" {
 		query = query.Where("name ILIKE ? OR url ILIKE ?", "%"+keyword+"%", "%"+keyword+"%")
 	}
+	switch health {
+	case "healthy":
+		query = query.Where("consecutive_failures < ?", unhealthyAfter)
+	case "unhealthy":
+		query = query.Where("consecutive_failures >= ?", unhealthyAfter)
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -45,20 +56,39 @@ func (r *RSSRepository) GetByID(id uint) (*model.RSSFeed, error) {
 	return &feed, nil
 }
 
-func (r *RSSRepository) GetByURL(url string) (*model.RSSFeed, error) {
+// GetByNormalizedURL returns the live feed whose normalized URL matches, other than excludeID
+func (r *RSSRepository) GetByNormalizedURL(normalized string, excludeID uint) (*model.RSSFeed, error) {
 	var feed model.RSSFeed
-	if err := r.db.Where("url = ?", url).First(&feed).Error; err != nil {
+	if err := r.db.Where("normalized_url = ? AND id <> ?", normalized, excludeID).First(&feed).Error; err != nil {
 		return nil, err
 	}
 	return &feed, nil
 }
 
 func (r *RSSRepository) Create(feed *model.RSSFeed) error {
+	feed.NormalizedURL = urlutil.Normalize(feed.URL)
 	return r.db.Create(feed).Error
 }
 
+// Update stores the editable fields only, so that it cannot overwrite the
+// outcome of a fetch made meanwhile
 func (r *RSSRepository) Update(feed *model.RSSFeed) error {
-	return r.db.Save(feed).Error
+	feed.NormalizedURL = urlutil.Normalize(feed.URL)
+	return r.db.Model(feed).
+		Select("name", "url", "normalized_url", "category", "description", "is_active",
+			"fetch_interval_minutes", "auto_ingest", "extract_full_text", "metadata").
+		Updates(feed).Error
+}
+
+// ResetFetchState clears the conditional request validators and failure
+// history of a feed
+func (r *RSSRepository) ResetFetchState(id uint) error {
+	return r.db.Model(&model.RSSFeed{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"etag":                 "",
+		"last_modified":        "",
+		"last_error":           "",
+		"consecutive_failures": 0,
+	}).Error
 }
 
 func (r *RSSRepository) Delete(id uint) error {
@@ -76,3 +106,35 @@ func (r *RSSRepository) GetActive() ([]model.RSSFeed, error) {
 	}
 	return feeds, nil
 }
+
+// RecordFetchSuccess stores the outcome of a successful (or 304 Not Modified) fetch and resets the failure counter.
+func (r *RSSRepository) RecordFetchSuccess(id uint, at time.Time, statusCode int, etag, lastModified string) error {
+	return r.db.Model(&model.RSSFeed{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_fetched_at":      at,
+		"last_checked_at":      at,
+		"last_status_code":     statusCode,
+		"etag":                 etag,
+		"last_modified":        lastModified,
+		"last_error":           "",
+		"consecutive_failures": 0,
+	}).Error
+}
+
+// RecordFetchFailure stores the outcome of a failed fetch and increments the failure counter.
+func (r *RSSRepository) RecordFetchFailure(id uint, at time.Time, statusCode int, errMsg string) error {
+	return r.db.Model(&model.RSSFeed{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_checked_at":      at,
+		"last_status_code":     statusCode,
+		"last_error":           errMsg,
+		"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+	}).Error
+}
+
+// GetAll returns every feed with its tags, ordered by category then name
+func (r *RSSRepository) GetAll() ([]model.RSSFeed, error) {
+	var feeds []model.RSSFeed
+	if err := r.db.Preload("Tags").Order("category, name, id").Find(&feeds).Error; err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
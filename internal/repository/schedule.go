@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"gorm.io/gorm"
+)
+
+type ScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduleRepository(db *gorm.DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+func (r *ScheduleRepository) List(page, perPage int) ([]model.Schedule, int64, error) {
+	var schedules []model.Schedule
+	var total int64
+
+	query := r.db.Model(&model.Schedule{})
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Offset(offset).Limit(perPage).Order("name").Find(&schedules).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return schedules, total, nil
+}
+
+func (r *ScheduleRepository) GetByID(id uint) (*model.Schedule, error) {
+	var schedule model.Schedule
+	if err := r.db.First(&schedule, id).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *ScheduleRepository) GetByName(name string) (*model.Schedule, error) {
+	var schedule model.Schedule
+	if err := r.db.Where("name = ?", name).First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *ScheduleRepository) Create(schedule *model.Schedule) error {
+	return r.db.Create(schedule).Error
+}
+
+// Update stores the editable fields only, so that it cannot overwrite the
+// outcome of a run that finished meanwhile
+func (r *ScheduleRepository) Update(schedule *model.Schedule) error {
+	return r.db.Model(schedule).
+		Select("name", "description", "cron", "timezone", "task", "params", "is_active", "next_run_at").
+		Updates(schedule).Error
+}
+
+func (r *ScheduleRepository) Delete(id uint) error {
+	return r.db.Delete(&model.Schedule{}, id).Error
+}
+
+// GetDue returns active schedules whose next run is at or before the given time
+func (r *ScheduleRepository) GetDue(now time.Time) ([]model.Schedule, error) {
+	var schedules []model.Schedule
+	if err := r.db.Where("is_active = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// Advance moves the next run of a schedule from one time to another. It
+// reports false when the run was already advanced, by another process or by
+// an edit, so that each activation runs once.
+func (r *ScheduleRepository) Advance(id uint, from time.Time, next *time.Time) (bool, error) {
+	result := r.db.Model(&model.Schedule{}).
+		Where("id = ? AND next_run_at = ?", id, from).
+		Update("next_run_at", next)
+	return result.RowsAffected == 1, result.Error
+}
+
+// RecordRun stores the outcome of a run
+func (r *ScheduleRepository) RecordRun(schedule *model.Schedule) error {
+	return r.db.Model(schedule).Updates(map[string]interface{}{
+		"last_run_at":      schedule.LastRunAt,
+		"last_status":      schedule.LastStatus,
+		"last_error":       schedule.LastError,
+		"last_result":      schedule.LastResult,
+		"last_duration_ms": schedule.LastDurationMs,
+	}).Error
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"github.com/singll/bellkeeper/internal/model"
+	"gorm.io/gorm"
+)
+
+type PageSnapshotRepository struct {
+	db *gorm.DB
+}
+
+func NewPageSnapshotRepository(db *gorm.DB) *PageSnapshotRepository {
+	return &PageSnapshotRepository{db: db}
+}
+
+func (r *PageSnapshotRepository) GetByDataSourceID(dataSourceID uint) (*model.PageSnapshot, error) {
+	var snapshot model.PageSnapshot
+	if err := r.db.Where("data_source_id = ?", dataSourceID).First(&snapshot).Error; err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (r *PageSnapshotRepository) Save(snapshot *model.PageSnapshot) error {
+	return r.db.Save(snapshot).Error
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/singll/bellkeeper/internal/model"
 	"gorm.io/gorm"
 )
@@ -47,8 +49,14 @@ func (r *WebhookRepository) Update(webhook *model.WebhookConfig) error {
 	return r.db.Save(webhook).Error
 }
 
+// Delete removes a webhook together with its event subscriptions
 func (r *WebhookRepository) Delete(id uint) error {
-	return r.db.Delete(&model.WebhookConfig{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookSubscription{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.WebhookConfig{}, id).Error
+	})
 }
 
 func (r *WebhookRepository) CreateHistory(history *model.WebhookHistory) error {
@@ -59,9 +67,10 @@ func (r *WebhookRepository) UpdateHistory(history *model.WebhookHistory) error {
 	return r.db.Save(history).Error
 }
 
+// GetHistory returns the latest deliveries of a webhook, without their attempt records
 func (r *WebhookRepository) GetHistory(webhookID uint, limit int) ([]model.WebhookHistory, error) {
 	var history []model.WebhookHistory
-	if err := r.db.Where("webhook_id = ?", webhookID).Order("created_at DESC").Limit(limit).Find(&history).Error; err != nil {
+	if err := r.db.Where("webhook_id = ? AND parent_id IS NULL", webhookID).Order("created_at DESC").Limit(limit).Find(&history).Error; err != nil {
 		return nil, err
 	}
 	return history, nil
@@ -69,8 +78,131 @@ func (r *WebhookRepository) GetHistory(webhookID uint, limit int) ([]model.Webho
 
 func (r *WebhookRepository) GetHistoryByStatus(webhookID uint, status string, limit int) ([]model.WebhookHistory, error) {
 	var history []model.WebhookHistory
-	if err := r.db.Where("webhook_id = ? AND status = ?", webhookID, status).Order("created_at DESC").Limit(limit).Find(&history).Error; err != nil {
+	if err := r.db.Where("webhook_id = ? AND status = ? AND parent_id IS NULL", webhookID, status).Order("created_at DESC").Limit(limit).Find(&history).Error; err != nil {
 		return nil, err
 	}
 	return history, nil
 }
+
+func (r *WebhookRepository) GetHistoryByID(id uint) (*model.WebhookHistory, error) {
+	var history model.WebhookHistory
+	if err := r.db.First(&history, id).Error; err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// GetAttempts returns the attempt records of a delivery in the order they were made
+func (r *WebhookRepository) GetAttempts(deliveryID uint) ([]model.WebhookHistory, error) {
+	var attempts []model.WebhookHistory
+	if err := r.db.Where("parent_id = ?", deliveryID).Order("attempt ASC").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// ListDeliveries returns the deliveries in a status across all webhooks, or of
+// one webhook when webhookID is set, newest first with their webhook loaded.
+func (r *WebhookRepository) ListDeliveries(status string, webhookID uint, page, perPage int) ([]model.WebhookHistory, int64, error) {
+	var deliveries []model.WebhookHistory
+	var total int64
+
+	query := r.db.Model(&model.WebhookHistory{}).Where("status = ? AND parent_id IS NULL", status)
+	if webhookID > 0 {
+		query = query.Where("webhook_id = ?", webhookID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	if err := query.Preload("Webhook").Offset(offset).Limit(perPage).Order("created_at DESC").Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// queuedStatuses are the statuses of deliveries waiting for their next attempt
+var queuedStatuses = []string{model.WebhookStatusPending, model.WebhookStatusRetrying}
+
+// GetDueRetries returns up to limit deliveries whose next attempt is due
+func (r *WebhookRepository) GetDueRetries(now time.Time, limit int) ([]model.WebhookHistory, error) {
+	var deliveries []model.WebhookHistory
+	err := r.db.Where("status IN ? AND next_retry_at <= ?", queuedStatuses, now).
+		Order("next_retry_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimRetry moves the next retry of a delivery from one time to another, and
+// reports false when another process changed it first. Pushing the retry past
+// the attempt keeps it due again should the attempt never finish.
+func (r *WebhookRepository) ClaimRetry(id uint, from, until time.Time) (bool, error) {
+	result := r.db.Model(&model.WebhookHistory{}).
+		Where("id = ? AND status IN ? AND next_retry_at = ?", id, queuedStatuses, from).
+		Update("next_retry_at", until)
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteHistoryBefore removes webhook deliveries created before the given time
+// together with their attempt records
+func (r *WebhookRepository) DeleteHistoryBefore(before time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		deliveries := tx.Model(&model.WebhookHistory{}).Select("id").Where("created_at < ? AND parent_id IS NULL", before)
+		result := tx.Where("parent_id IN (?)", deliveries).Delete(&model.WebhookHistory{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+
+		result = tx.Where("created_at < ? AND parent_id IS NULL", before).Delete(&model.WebhookHistory{})
+		deleted += result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
+// ListSubscriptions returns the event subscriptions of a webhook
+func (r *WebhookRepository) ListSubscriptions(webhookID uint) ([]model.WebhookSubscription, error) {
+	var subs []model.WebhookSubscription
+	if err := r.db.Where("webhook_id = ?", webhookID).Order("id ASC").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// GetSubscription returns an event subscription of a webhook
+func (r *WebhookRepository) GetSubscription(webhookID, id uint) (*model.WebhookSubscription, error) {
+	var sub model.WebhookSubscription
+	if err := r.db.Where("webhook_id = ?", webhookID).First(&sub, id).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *WebhookRepository) CreateSubscription(sub *model.WebhookSubscription) error {
+	return r.db.Create(sub).Error
+}
+
+func (r *WebhookRepository) UpdateSubscription(sub *model.WebhookSubscription) error {
+	return r.db.Save(sub).Error
+}
+
+func (r *WebhookRepository) DeleteSubscription(webhookID, id uint) error {
+	return r.db.Where("webhook_id = ?", webhookID).Delete(&model.WebhookSubscription{}, id).Error
+}
+
+// GetActiveSubscriptions returns the active subscriptions to any of the given
+// event type patterns, with their webhook loaded; it is nil for a deleted webhook.
+func (r *WebhookRepository) GetActiveSubscriptions(patterns []string) ([]model.WebhookSubscription, error) {
+	var subs []model.WebhookSubscription
+	err := r.db.Preload("Webhook").
+		Where("is_active = ? AND event_type IN ?", true, patterns).
+		Order("id ASC").
+		Find(&subs).Error
+	return subs, err
+}
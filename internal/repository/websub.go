@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"gorm.io/gorm"
+)
+
+type WebSubRepository struct {
+	db *gorm.DB
+}
+
+func NewWebSubRepository(db *gorm.DB) *WebSubRepository {
+	return &WebSubRepository{db: db}
+}
+
+func (r *WebSubRepository) GetByID(id uint) (*model.WebSubSubscription, error) {
+	var sub model.WebSubSubscription
+	if err := r.db.First(&sub, id).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *WebSubRepository) GetByFeedID(feedID uint) (*model.WebSubSubscription, error) {
+	var sub model.WebSubSubscription
+	if err := r.db.Where("feed_id = ?", feedID).First(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *WebSubRepository) Save(sub *model.WebSubSubscription) error {
+	return r.db.Save(sub).Error
+}
+
+// ActiveFeedIDs returns the IDs of feeds with an unexpired, verified subscription
+func (r *WebSubRepository) ActiveFeedIDs(now time.Time) (map[uint]bool, error) {
+	var ids []uint
+	err := r.db.Model(&model.WebSubSubscription{}).
+		Where("state = ? AND expires_at > ?", model.WebSubStateActive, now).
+		Pluck("feed_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		active[id] = true
+	}
+	return active, nil
+}
+
+// GetExpiring returns active subscriptions whose lease ends before the given time
+// and that have not been renewed since requestedBefore
+func (r *WebSubRepository) GetExpiring(before, requestedBefore time.Time) ([]model.WebSubSubscription, error) {
+	var subs []model.WebSubSubscription
+	err := r.db.Where("state = ? AND expires_at < ?", model.WebSubStateActive, before).
+		Where("requested_at IS NULL OR requested_at < ?", requestedBefore).
+		Find(&subs).Error
+	return subs, err
+}
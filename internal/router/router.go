@@ -12,6 +12,12 @@ func Setup(r *gin.Engine, handlers *handler.Handlers, mode string, apiKey string
 	r.GET("/api/health", handlers.Health.Check)
 	r.GET("/api/health/detailed", handlers.Health.Detailed)
 
+	// WebSub hub callbacks (public, verified by subscription secret)
+	registerWebSubRoutes(r, handlers.WebSub)
+
+	// Inbound webhooks (public, verified by hook token or signature)
+	registerInboundReceiveRoutes(r, handlers.Inbound)
+
 	// API routes (with Authelia auth + API Key support)
 	api := r.Group("/api")
 	api.Use(middleware.AutheliaAuth(mode, apiKey))
@@ -25,6 +31,9 @@ func Setup(r *gin.Engine, handlers *handler.Handlers, mode string, apiKey string
 	registerSettingRoutes(api, handlers.Setting)
 	registerWorkflowRoutes(api, handlers.Workflow)
 	registerSystemRoutes(api, handlers.System)
+	registerJobRoutes(api, handlers.Job)
+	registerScheduleRoutes(api, handlers.Schedule)
+	registerInboundRoutes(api, handlers.Inbound)
 }
 
 func registerTagRoutes(api *gin.RouterGroup, h *handler.TagHandler) {
@@ -43,9 +52,20 @@ func registerTagRoutes(api *gin.RouterGroup, h *handler.TagHandler) {
 func registerDataSourceRoutes(api *gin.RouterGroup, h *handler.DataSourceHandler) {
 	api.GET("/datasources", h.List)
 	api.POST("/datasources", h.Create)
+	api.POST("/datasources/import", h.Import)
+	api.GET("/datasources/export", h.Export)
 	api.GET("/datasources/:id", h.Get)
 	api.PUT("/datasources/:id", h.Update)
 	api.DELETE("/datasources/:id", h.Delete)
+	api.POST("/datasources/:id/ingest", h.Ingest)
+	api.POST("/datasources/:id/crawl", h.Crawl)
+	api.GET("/datasources/:id/candidates", h.Candidates)
+	api.POST("/datasources/:id/check", h.Check)
+	api.GET("/datasources/:id/snapshot", h.Snapshot)
+	api.GET("/datasources/:id/status", h.Status)
+	api.POST("/datasources/:id/github/sync", h.GitHubSync)
+	api.GET("/datasources/:id/github/items", h.GitHubItems)
+	api.GET("/candidates", h.ListCandidates)
 }
 
 func registerRSSRoutes(api *gin.RouterGroup, h *handler.RSSHandler) {
@@ -54,6 +74,18 @@ func registerRSSRoutes(api *gin.RouterGroup, h *handler.RSSHandler) {
 	api.GET("/rss/:id", h.Get)
 	api.PUT("/rss/:id", h.Update)
 	api.DELETE("/rss/:id", h.Delete)
+	api.GET("/rss/:id/entries", h.Entries)
+	api.GET("/rss/:id/websub", h.WebSub)
+	api.POST("/rss/:id/fetch", h.Fetch)
+	api.POST("/rss/preview", h.Preview)
+	api.POST("/rss/import/opml", h.ImportOPML)
+	api.GET("/rss/export/opml", h.ExportOPML)
+	api.GET("/entries", h.ListEntries)
+}
+
+func registerWebSubRoutes(r *gin.Engine, h *handler.WebSubHandler) {
+	r.GET("/websub/callback/:id", h.Verify)
+	r.POST("/websub/callback/:id", h.Push)
 }
 
 func registerWebhookRoutes(api *gin.RouterGroup, h *handler.WebhookHandler) {
@@ -64,6 +96,15 @@ func registerWebhookRoutes(api *gin.RouterGroup, h *handler.WebhookHandler) {
 	api.DELETE("/webhooks/:id", h.Delete)
 	api.POST("/webhooks/:id/trigger", h.Trigger)
 	api.GET("/webhooks/:id/history", h.History)
+	api.GET("/webhooks/dead", h.Dead)
+	api.GET("/webhooks/history/:history_id/attempts", h.Attempts)
+	api.POST("/webhooks/history/:history_id/requeue", h.Requeue)
+	api.POST("/webhooks/history/:history_id/replay", h.Replay)
+	api.GET("/webhooks/events", h.EventTypes)
+	api.GET("/webhooks/:id/subscriptions", h.Subscriptions)
+	api.POST("/webhooks/:id/subscriptions", h.CreateSubscription)
+	api.PUT("/webhooks/:id/subscriptions/:subscription_id", h.UpdateSubscription)
+	api.DELETE("/webhooks/:id/subscriptions/:subscription_id", h.DeleteSubscription)
 }
 
 func registerDatasetRoutes(api *gin.RouterGroup, h *handler.DatasetHandler) {
@@ -124,3 +165,33 @@ func registerWorkflowRoutes(api *gin.RouterGroup, h *handler.WorkflowHandler) {
 func registerSystemRoutes(api *gin.RouterGroup, h *handler.SystemHandler) {
 	api.POST("/system/restart", h.Restart)
 }
+
+func registerJobRoutes(api *gin.RouterGroup, h *handler.JobHandler) {
+	api.GET("/jobs", h.List)
+	api.GET("/jobs/:id", h.Get)
+	api.GET("/jobs/:id/events", h.Events)
+}
+
+func registerScheduleRoutes(api *gin.RouterGroup, h *handler.ScheduleHandler) {
+	api.GET("/schedules", h.List)
+	api.GET("/schedules/tasks", h.Tasks)
+	api.GET("/schedules/preview", h.Preview)
+	api.GET("/schedules/:id", h.Get)
+	api.POST("/schedules", h.Create)
+	api.PUT("/schedules/:id", h.Update)
+	api.DELETE("/schedules/:id", h.Delete)
+	api.POST("/schedules/:id/run", h.Run)
+}
+
+func registerInboundReceiveRoutes(r *gin.Engine, h *handler.InboundHookHandler) {
+	r.POST("/hooks/in/:slug", h.Receive)
+}
+
+func registerInboundRoutes(api *gin.RouterGroup, h *handler.InboundHookHandler) {
+	api.GET("/inbound-hooks", h.List)
+	api.POST("/inbound-hooks", h.Create)
+	api.GET("/inbound-hooks/:id", h.Get)
+	api.PUT("/inbound-hooks/:id", h.Update)
+	api.DELETE("/inbound-hooks/:id", h.Delete)
+	api.POST("/inbound-hooks/:id/preview", h.Preview)
+}
@@ -0,0 +1,452 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/extract"
+	"github.com/singll/bellkeeper/internal/pkg/robots"
+	"github.com/singll/bellkeeper/internal/pkg/urlutil"
+	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/datatypes"
+)
+
+// Crawler errors
+var (
+	// ErrCrawlInProgress is returned when a data source is crawled while another crawl of it is still running
+	ErrCrawlInProgress = errors.New("data source is already being crawled")
+	// ErrNotCrawlable is returned for data sources that are neither websites nor sitemaps
+	ErrNotCrawlable = errors.New("only website and sitemap data sources can be crawled")
+)
+
+// crawlSettingsKey is the DataSource.Metadata key holding per-source crawl settings
+const crawlSettingsKey = "crawl"
+
+// CrawlSettings overrides the crawler defaults for a data source. Zero values fall back to the configuration.
+type CrawlSettings struct {
+	MaxDepth int `json:"max_depth,omitempty"`
+	MaxPages int `json:"max_pages,omitempty"`
+}
+
+// skippedExtensions are link targets that are never articles.
+var skippedExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".svg": true, ".ico": true,
+	".css": true, ".js": true, ".json": true, ".xml": true, ".rss": true, ".atom": true,
+	".pdf": true, ".zip": true, ".gz": true, ".tar": true, ".rar": true, ".7z": true, ".exe": true, ".dmg": true,
+	".mp3": true, ".mp4": true, ".avi": true, ".mov": true, ".webm": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".eot": true,
+}
+
+// CrawlerService discovers pages of website and sitemap data sources and queues
+// them as candidate articles. Websites are crawled by following same-host links,
+// honoring robots.txt; sitemaps are read incrementally. Requests to the same
+// host are spaced by a politeness delay.
+type CrawlerService struct {
+	cfg           config.CrawlerConfig
+	urlDedup      bool
+	repo          *repository.DataSourceRepository
+	candidateRepo *repository.CandidateArticleRepository
+	datasetSvc    *DatasetService
+	extractSvc    *ExtractService
+
+	// hostNext is the earliest time the next request to each host may start
+	hostMu   sync.Mutex
+	hostNext map[string]time.Time
+
+	// inflight guards against crawling the same data source twice concurrently
+	inflight sync.Map
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewCrawlerService fetches pages through the extract service so crawls share
+// its user agent, timeout and HTML decoding.
+func NewCrawlerService(
+	cfg config.CrawlerConfig,
+	urlDedup bool,
+	repo *repository.DataSourceRepository,
+	candidateRepo *repository.CandidateArticleRepository,
+	datasetSvc *DatasetService,
+	extractSvc *ExtractService,
+) *CrawlerService {
+	return &CrawlerService{
+		cfg:           cfg,
+		urlDedup:      urlDedup,
+		repo:          repo,
+		candidateRepo: candidateRepo,
+		datasetSvc:    datasetSvc,
+		extractSvc:    extractSvc,
+		hostNext:      map[string]time.Time{},
+	}
+}
+
+// CrawlResult summarizes a single crawl.
+type CrawlResult struct {
+	DataSourceID uint `json:"data_source_id"`
+	Pages        int  `json:"pages"`      // pages or sitemap files fetched
+	Discovered   int  `json:"discovered"` // same-host links or sitemap URLs found
+	New          int  `json:"new"`        // candidates queued, including changed sitemap URLs
+	Unchanged    int  `json:"unchanged"`  // URLs already queued and not modified since
+	Duplicate    int  `json:"duplicate"`  // URLs already ingested into RagFlow
+	Disallowed   int  `json:"disallowed"` // links blocked by robots.txt
+	Errors       int  `json:"errors"`     // pages that could not be fetched
+}
+
+// Start launches the background crawl loop. It is a no-op when the crawler is disabled.
+func (s *CrawlerService) Start() {
+	if !s.cfg.Enabled {
+		log.Println("Website crawler disabled by configuration")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.run(ctx)
+	log.Printf("Website crawler started (poll interval %ds, crawl interval %dm)", s.cfg.PollInterval, s.cfg.Interval)
+}
+
+// Stop cancels the running crawl and waits for the loop to exit.
+func (s *CrawlerService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	log.Println("Website crawler stopped")
+}
+
+func (s *CrawlerService) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	interval := time.Duration(s.cfg.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.crawlDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.crawlDue(ctx)
+		}
+	}
+}
+
+// crawlDue crawls every website data source whose crawl interval has elapsed,
+// one at a time since most of the time is spent in politeness delays anyway.
+func (s *CrawlerService) crawlDue(ctx context.Context) {
+	sources, err := s.repo.GetDueForCrawl(
+		[]string{model.DataSourceTypeWebsite, model.DataSourceTypeSitemap},
+		time.Now().Add(-time.Duration(s.cfg.Interval)*time.Minute),
+	)
+	if err != nil {
+		log.Printf("warn: crawler failed to load data sources: %v", err)
+		return
+	}
+
+	for i := range sources {
+		if ctx.Err() != nil {
+			return
+		}
+		result, err := s.Crawl(ctx, &sources[i])
+		if err != nil {
+			log.Printf("warn: crawl failed for data source %d (%s): %v", sources[i].ID, sources[i].URL, err)
+			continue
+		}
+		log.Printf("Data source %d crawled: %d pages, %d URLs, %d queued, %d unchanged, %d duplicate, %d disallowed, %d errors",
+			result.DataSourceID, result.Pages, result.Discovered, result.New, result.Unchanged, result.Duplicate, result.Disallowed, result.Errors)
+	}
+}
+
+type crawlTarget struct {
+	url   string
+	depth int
+}
+
+// Crawl discovers the pages of a website or sitemap data source and queues them
+// as candidate articles. The start time of the run is recorded on the source;
+// sitemap runs also record it as their baseline when complete, so incremental
+// runs never miss pages modified while a run was going on.
+func (s *CrawlerService) Crawl(ctx context.Context, source *model.DataSource) (*CrawlResult, error) {
+	var run func(context.Context, *model.DataSource) (*CrawlResult, error)
+	switch source.Type {
+	case model.DataSourceTypeWebsite:
+		run = s.crawl
+	case model.DataSourceTypeSitemap:
+		run = s.crawlSitemap
+	default:
+		return nil, ErrNotCrawlable
+	}
+	if _, busy := s.inflight.LoadOrStore(source.ID, struct{}{}); busy {
+		return nil, fmt.Errorf("data source %d: %w", source.ID, ErrCrawlInProgress)
+	}
+	defer s.inflight.Delete(source.ID)
+
+	started := time.Now()
+	result, err := run(ctx, source)
+	if ctx.Err() == nil {
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		if recErr := s.repo.RecordCrawl(source.ID, started, errMsg); recErr != nil {
+			log.Printf("warn: failed to record crawl of data source %d: %v", source.ID, recErr)
+		}
+	}
+	return result, err
+}
+
+// crawl fetches the website URL and follows same-host links breadth-first up to
+// the configured depth and page budget. Every allowed link is queued as a
+// candidate article; only pages above the depth limit are fetched.
+func (s *CrawlerService) crawl(ctx context.Context, source *model.DataSource) (*CrawlResult, error) {
+	settings, err := s.crawlSettings(source)
+	if err != nil {
+		return nil, err
+	}
+	start, err := url.Parse(urlutil.Normalize(source.URL))
+	if err != nil || (start.Scheme != "http" && start.Scheme != "https") || start.Host == "" {
+		return nil, fmt.Errorf("invalid data source URL %q", source.URL)
+	}
+
+	agent := robots.ProductToken(s.extractSvc.userAgent)
+	rules := s.robotsRules(ctx, start)
+	delay := time.Duration(s.cfg.Delay) * time.Millisecond
+	if d := rules.CrawlDelay(agent); d > delay {
+		delay = d
+	}
+
+	result := &CrawlResult{DataSourceID: source.ID}
+	if !rules.Allowed(agent, start.RequestURI()) {
+		return result, errors.New("start URL is disallowed by robots.txt")
+	}
+
+	queue := []crawlTarget{{url: start.String()}}
+	seen := map[string]bool{start.String(): true}
+	for len(queue) > 0 && result.Pages < settings.MaxPages {
+		target := queue[0]
+		queue = queue[1:]
+
+		if err := s.waitForHost(ctx, start.Host, delay); err != nil {
+			return result, err
+		}
+		result.Pages++
+		links, err := s.fetchLinks(ctx, target.url)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			if target.depth == 0 {
+				return result, fmt.Errorf("failed to fetch start page: %w", err)
+			}
+			result.Errors++
+			log.Printf("warn: crawler failed to fetch %s: %v", target.url, err)
+			continue
+		}
+
+		for _, link := range links {
+			next, err := s.discover(source, start, rules, agent, target, link, seen, result)
+			if err != nil {
+				return result, err
+			}
+			if next != nil && next.depth < settings.MaxDepth {
+				queue = append(queue, *next)
+			}
+		}
+	}
+	return result, nil
+}
+
+// fetchLinks downloads an HTML page and returns the links on it.
+func (s *CrawlerService) fetchLinks(ctx context.Context, pageURL string) ([]extract.Link, error) {
+	body, finalURL, err := s.extractSvc.fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	_, links, err := extract.Links(body, finalURL)
+	return links, err
+}
+
+// discover queues a link found on target as a candidate article. It returns the
+// link as the next crawl target, or nil if the link is off-site, already seen,
+// not a page or disallowed.
+func (s *CrawlerService) discover(
+	source *model.DataSource,
+	start *url.URL,
+	rules *robots.Rules,
+	agent string,
+	target crawlTarget,
+	link extract.Link,
+	seen map[string]bool,
+	result *CrawlResult,
+) (*crawlTarget, error) {
+	normalized := urlutil.Normalize(link.URL)
+	u, err := url.Parse(normalized)
+	if err != nil || !sameHost(u, start) || seen[normalized] {
+		return nil, nil
+	}
+	seen[normalized] = true
+	if skippedExtensions[strings.ToLower(path.Ext(u.Path))] {
+		return nil, nil
+	}
+
+	result.Discovered++
+	if !rules.Allowed(agent, u.RequestURI()) {
+		result.Disallowed++
+		return nil, nil
+	}
+
+	created, err := s.candidateRepo.CreateIfNotExists(&model.CandidateArticle{
+		DataSourceID: source.ID,
+		URL:          truncateRunes(normalized, 2000),
+		Title:        truncateRunes(link.Text, 1000),
+		FoundOn:      truncateRunes(target.url, 2000),
+		Depth:        target.depth + 1,
+		Status:       model.CandidateStatusNew,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue candidate %q: %w", normalized, err)
+	}
+	if created {
+		result.New++
+	}
+	return &crawlTarget{url: normalized, depth: target.depth + 1}, nil
+}
+
+// sameHost compares hosts ignoring case and a leading "www.".
+func sameHost(a, b *url.URL) bool {
+	ha := strings.TrimPrefix(strings.ToLower(a.Hostname()), "www.")
+	hb := strings.TrimPrefix(strings.ToLower(b.Hostname()), "www.")
+	return ha == hb
+}
+
+// waitForHost blocks until the politeness delay since the previous request to
+// host has passed, reserving the next slot for the caller.
+func (s *CrawlerService) waitForHost(ctx context.Context, host string, delay time.Duration) error {
+	s.hostMu.Lock()
+	now := time.Now()
+	slot := s.hostNext[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	s.hostNext[host] = slot.Add(delay)
+	s.hostMu.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// robotsRules downloads the site's robots.txt. A missing file (4xx) allows
+// everything; an unreachable one (5xx, network error) disallows everything.
+func (s *CrawlerService) robotsRules(ctx context.Context, site *url.URL) *robots.Rules {
+	robotsURL := (&url.URL{Scheme: site.Scheme, Host: site.Host, Path: "/robots.txt"}).String()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	if err != nil {
+		return robots.DisallowAll
+	}
+	req.Header.Set("User-Agent", s.extractSvc.userAgent)
+
+	resp, err := s.extractSvc.client.Do(req)
+	if err != nil {
+		log.Printf("warn: failed to fetch %s: %v", robotsURL, err)
+		return robots.DisallowAll
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return robots.Parse(io.LimitReader(resp.Body, defaults.MaxRobotsBodySize))
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return robots.AllowAll
+	default:
+		log.Printf("warn: %s returned HTTP %d", robotsURL, resp.StatusCode)
+		return robots.DisallowAll
+	}
+}
+
+// crawlSettings returns the effective crawl settings of a data source.
+func (s *CrawlerService) crawlSettings(source *model.DataSource) (CrawlSettings, error) {
+	settings := CrawlSettings{MaxDepth: s.cfg.MaxDepth, MaxPages: s.cfg.MaxPages}
+	if len(source.Metadata) > 0 {
+		var meta struct {
+			Crawl *CrawlSettings `json:"crawl"`
+		}
+		if err := json.Unmarshal(source.Metadata, &meta); err != nil {
+			return settings, fmt.Errorf("invalid data source metadata: %w", err)
+		}
+		if meta.Crawl != nil {
+			if meta.Crawl.MaxDepth > 0 {
+				settings.MaxDepth = meta.Crawl.MaxDepth
+			}
+			if meta.Crawl.MaxPages > 0 {
+				settings.MaxPages = meta.Crawl.MaxPages
+			}
+		}
+	}
+	if settings.MaxDepth < 1 {
+		settings.MaxDepth = 1
+	}
+	if settings.MaxPages < 1 {
+		settings.MaxPages = 1
+	}
+	return settings, nil
+}
+
+// ListCandidates returns discovered candidate articles, optionally for one data source
+func (s *CrawlerService) ListCandidates(page, perPage int, dataSourceID uint, status, keyword string) ([]model.CandidateArticle, int64, error) {
+	return s.candidateRepo.List(page, perPage, dataSourceID, status, keyword)
+}
+
+// SetCrawlSettings validates the settings and stores them in the data source's
+// metadata, keeping any other metadata keys. Empty settings remove them. The source is not saved.
+func (s *DataSourceService) SetCrawlSettings(source *model.DataSource, settings CrawlSettings) error {
+	if settings.MaxDepth < 0 || settings.MaxPages < 0 {
+		return errors.New("max_depth and max_pages must not be negative")
+	}
+
+	meta := map[string]interface{}{}
+	if len(source.Metadata) > 0 {
+		if err := json.Unmarshal(source.Metadata, &meta); err != nil {
+			return fmt.Errorf("invalid data source metadata: %w", err)
+		}
+	}
+	if settings == (CrawlSettings{}) {
+		delete(meta, crawlSettingsKey)
+	} else {
+		meta[crawlSettingsKey] = settings
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	source.Metadata = datatypes.JSON(data)
+	return nil
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/sitemap"
+	"github.com/singll/bellkeeper/internal/pkg/urlutil"
+)
+
+// sitemapCheckBatch is how many URLs are checked against RagFlow per query.
+const sitemapCheckBatch = 500
+
+// crawlSitemap reads the sitemap (following sitemap indexes, up to the page
+// budget in files) and queues the URLs that are new or changed since the
+// previous complete run. Without one every URL counts as new. URLs with a
+// <lastmod> after the previous complete run are re-queued; URLs without one
+// are queued only if unknown. URLs that are already ingested are skipped when
+// URL dedup is on. Only a run that read every sitemap file without errors
+// becomes the baseline of the next one, so URLs a failed or truncated run
+// never saw are not taken for unchanged.
+func (s *CrawlerService) crawlSitemap(ctx context.Context, source *model.DataSource) (*CrawlResult, error) {
+	settings, err := s.crawlSettings(source)
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	lastRun := source.SitemapSyncedAt
+	delay := time.Duration(s.cfg.Delay) * time.Millisecond
+
+	result := &CrawlResult{DataSourceID: source.ID}
+	queue := []string{source.URL}
+	seenSitemaps := map[string]bool{source.URL: true}
+	var entries []candidateEntry
+	seenURLs := map[string]bool{}
+
+	for len(queue) > 0 && result.Pages < settings.MaxPages {
+		sitemapURL := queue[0]
+		queue = queue[1:]
+
+		u, err := url.Parse(sitemapURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			if sitemapURL == source.URL {
+				return nil, fmt.Errorf("invalid sitemap URL %q", source.URL)
+			}
+			result.Errors++
+			continue
+		}
+		if err := s.waitForHost(ctx, u.Host, delay); err != nil {
+			return result, err
+		}
+		result.Pages++
+
+		sm, err := s.fetchSitemap(ctx, sitemapURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			if sitemapURL == source.URL {
+				return result, fmt.Errorf("failed to fetch sitemap: %w", err)
+			}
+			result.Errors++
+			log.Printf("warn: crawler failed to fetch sitemap %s: %v", sitemapURL, err)
+			continue
+		}
+
+		// Child sitemaps not modified since the last run cannot list changed pages
+		for _, child := range sm.Sitemaps {
+			if seenSitemaps[child.Loc] || !modifiedSince(child.LastMod, lastRun, true) {
+				continue
+			}
+			seenSitemaps[child.Loc] = true
+			queue = append(queue, child.Loc)
+		}
+
+		for _, entry := range sm.URLs {
+			normalized := urlutil.Normalize(entry.Loc)
+			if seenURLs[normalized] {
+				continue
+			}
+			seenURLs[normalized] = true
+			result.Discovered++
+
+			if !modifiedSince(entry.LastMod, lastRun, true) {
+				result.Unchanged++
+				continue
+			}
+			entries = append(entries, candidateEntry{
+				url:     normalized,
+				foundOn: sitemapURL,
+				lastMod: entry.LastMod,
+				changed: lastRun != nil && modifiedSince(entry.LastMod, lastRun, false),
+			})
+		}
+	}
+	complete := len(queue) == 0 && result.Errors == 0
+
+	entries, err = s.dropIngested(entries, result)
+	if err != nil {
+		return result, err
+	}
+
+	for _, entry := range entries {
+		candidate := &model.CandidateArticle{
+			DataSourceID: source.ID,
+			URL:          truncateRunes(entry.url, 2000),
+			FoundOn:      truncateRunes(entry.foundOn, 2000),
+			LastModified: entry.lastMod,
+			Status:       model.CandidateStatusNew,
+		}
+		if entry.changed {
+			if err := s.candidateRepo.Requeue(candidate); err != nil {
+				return result, fmt.Errorf("failed to queue candidate %q: %w", entry.url, err)
+			}
+			result.New++
+			continue
+		}
+		created, err := s.candidateRepo.CreateIfNotExists(candidate)
+		if err != nil {
+			return result, fmt.Errorf("failed to queue candidate %q: %w", entry.url, err)
+		}
+		if created {
+			result.New++
+		} else {
+			result.Unchanged++
+		}
+	}
+
+	if complete {
+		if err := s.repo.RecordSitemapSync(source.ID, started); err != nil {
+			log.Printf("warn: failed to record sitemap sync of data source %d: %v", source.ID, err)
+		}
+	}
+	return result, nil
+}
+
+// candidateEntry is a sitemap URL waiting to be queued. changed is set when its
+// <lastmod> shows it was modified after the previous run.
+type candidateEntry struct {
+	url     string
+	foundOn string
+	lastMod *time.Time
+	changed bool
+}
+
+// modifiedSince reports whether lastMod is after the previous run. Without a
+// previous run everything is modified; without a lastmod the answer is unknownResult.
+func modifiedSince(lastMod, lastRun *time.Time, unknownResult bool) bool {
+	if lastRun == nil {
+		return true
+	}
+	if lastMod == nil {
+		return unknownResult
+	}
+	return lastMod.After(*lastRun)
+}
+
+// dropIngested removes URLs that RagFlow already has, checked in batches
+// through DatasetService.BatchCheckURLs with URL normalization.
+func (s *CrawlerService) dropIngested(entries []candidateEntry, result *CrawlResult) ([]candidateEntry, error) {
+	if !s.urlDedup || len(entries) == 0 {
+		return entries, nil
+	}
+
+	var kept []candidateEntry
+	for start := 0; start < len(entries); start += sitemapCheckBatch {
+		end := start + sitemapCheckBatch
+		if end > len(entries) {
+			end = len(entries)
+		}
+		batch := entries[start:end]
+
+		urls := make([]string, len(batch))
+		for i, entry := range batch {
+			urls[i] = entry.url
+		}
+		checks, err := s.datasetSvc.BatchCheckURLs(urls, true, false)
+		if err != nil {
+			return nil, fmt.Errorf("dedup check failed: %w", err)
+		}
+		for _, entry := range batch {
+			if check, ok := checks[entry.url]; ok && check.Exists {
+				result.Duplicate++
+				continue
+			}
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+// fetchSitemap downloads and parses a sitemap file.
+func (s *CrawlerService) fetchSitemap(ctx context.Context, sitemapURL string) (*sitemap.Sitemap, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", sitemapURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", s.extractSvc.userAgent)
+	req.Header.Set("Accept", "application/xml, text/xml;q=0.9, application/gzip;q=0.8, text/plain;q=0.5")
+
+	resp, err := s.extractSvc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+	sm, err := sitemap.Parse(resp.Body)
+	if errors.Is(err, sitemap.ErrUnknownFormat) {
+		return nil, fmt.Errorf("%s is not a sitemap: %w", sitemapURL, err)
+	}
+	return sm, err
+}
@@ -1,18 +1,32 @@
 package service
 
 import (
+	"errors"
+
 	"github.com/singll/bellkeeper/internal/model"
 	"github.com/singll/bellkeeper/internal/pkg/urlutil"
 	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Dataset mapping change actions, reported by dataset.mapping.changed events
+const (
+	MappingCreated = "created"
+	MappingUpdated = "updated"
+	MappingDeleted = "deleted"
 )
 
+// ErrDatasetMappingNotFound is returned when a dataset mapping does not exist.
+var ErrDatasetMappingNotFound = errors.New("dataset mapping not found")
+
 type DatasetService struct {
 	repo    *repository.DatasetMappingRepository
 	tagRepo *repository.TagRepository
+	events  *EventBus
 }
 
-func NewDatasetService(repo *repository.DatasetMappingRepository, tagRepo *repository.TagRepository) *DatasetService {
-	return &DatasetService{repo: repo, tagRepo: tagRepo}
+func NewDatasetService(repo *repository.DatasetMappingRepository, tagRepo *repository.TagRepository, events *EventBus) *DatasetService {
+	return &DatasetService{repo: repo, tagRepo: tagRepo, events: events}
 }
 
 func (s *DatasetService) List(page, perPage int) ([]model.DatasetMapping, int64, error) {
@@ -36,14 +50,18 @@ func (s *DatasetService) Create(mapping *model.DatasetMapping, tagIDs []uint) er
 		return err
 	}
 
+	var tags []model.Tag
 	if len(tagIDs) > 0 {
-		tags, err := s.tagRepo.GetByIDs(tagIDs)
-		if err != nil {
+		var err error
+		if tags, err = s.tagRepo.GetByIDs(tagIDs); err != nil {
+			return err
+		}
+		if err := s.repo.UpdateTags(mapping, tags); err != nil {
 			return err
 		}
-		return s.repo.UpdateTags(mapping, tags)
 	}
 
+	s.events.Publish(mappingChangedEvent(MappingCreated, mapping, tags))
 	return nil
 }
 
@@ -56,11 +74,48 @@ func (s *DatasetService) Update(mapping *model.DatasetMapping, tagIDs []uint) er
 	if err != nil {
 		return err
 	}
-	return s.repo.UpdateTags(mapping, tags)
+	if err := s.repo.UpdateTags(mapping, tags); err != nil {
+		return err
+	}
+
+	s.events.Publish(mappingChangedEvent(MappingUpdated, mapping, tags))
+	return nil
 }
 
 func (s *DatasetService) Delete(id uint) error {
-	return s.repo.Delete(id)
+	mapping, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrDatasetMappingNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	s.events.Publish(mappingChangedEvent(MappingDeleted, mapping, mapping.Tags))
+	return nil
+}
+
+// mappingChangedEvent describes a dataset mapping after a change; the event
+// is tagged with the tags routed to it
+func mappingChangedEvent(action string, mapping *model.DatasetMapping, tags []model.Tag) Event {
+	names := tagNames(tags)
+	event := NewEvent(EventDatasetMappingChanged, map[string]interface{}{
+		"action":       action,
+		"mapping_id":   mapping.ID,
+		"name":         mapping.Name,
+		"display_name": mapping.DisplayName,
+		"dataset_id":   mapping.DatasetID,
+		"is_default":   mapping.IsDefault,
+		"is_active":    mapping.IsActive,
+		"tags":         names,
+	})
+	event.Tags = names
+	event.Category = mapping.Name
+	event.DatasetID = mapping.DatasetID
+	return event
 }
 
 // GetByTagIDs finds dataset mappings that match any of the given tag IDs
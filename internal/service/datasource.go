@@ -1,17 +1,45 @@
 package service
 
 import (
+	"errors"
+
 	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/urlutil"
 	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/gorm"
 )
 
 type DataSourceService struct {
-	repo    *repository.DataSourceRepository
-	tagRepo *repository.TagRepository
+	repo         *repository.DataSourceRepository
+	tagRepo      *repository.TagRepository
+	tagSvc       *TagService
+	snapshotRepo *repository.PageSnapshotRepository
+	datasetSvc   *DatasetService
+	ragflowSvc   *RagFlowService
+	extractSvc   *ExtractService
+	urlDedup     bool
 }
 
-func NewDataSourceService(repo *repository.DataSourceRepository, tagRepo *repository.TagRepository) *DataSourceService {
-	return &DataSourceService{repo: repo, tagRepo: tagRepo}
+func NewDataSourceService(
+	repo *repository.DataSourceRepository,
+	tagRepo *repository.TagRepository,
+	tagSvc *TagService,
+	snapshotRepo *repository.PageSnapshotRepository,
+	datasetSvc *DatasetService,
+	ragflowSvc *RagFlowService,
+	extractSvc *ExtractService,
+	urlDedup bool,
+) *DataSourceService {
+	return &DataSourceService{
+		repo:         repo,
+		tagRepo:      tagRepo,
+		tagSvc:       tagSvc,
+		snapshotRepo: snapshotRepo,
+		datasetSvc:   datasetSvc,
+		ragflowSvc:   ragflowSvc,
+		extractSvc:   extractSvc,
+		urlDedup:     urlDedup,
+	}
 }
 
 func (s *DataSourceService) List(page, perPage int, category, keyword string) ([]model.DataSource, int64, error) {
@@ -22,8 +50,17 @@ func (s *DataSourceService) GetByID(id uint) (*model.DataSource, error) {
 	return s.repo.GetByID(id)
 }
 
+// Create stores a new source; a *DuplicateURLError is returned when another
+// source has the same normalized URL.
 func (s *DataSourceService) Create(source *model.DataSource, tagIDs []uint) error {
+	if err := s.checkURL(source); err != nil {
+		return err
+	}
 	if err := s.repo.Create(source); err != nil {
+		// A concurrent create may have taken the URL after the check
+		if dupErr := s.checkURL(source); dupErr != nil {
+			return dupErr
+		}
 		return err
 	}
 
@@ -38,8 +75,15 @@ func (s *DataSourceService) Create(source *model.DataSource, tagIDs []uint) erro
 	return nil
 }
 
+// Update stores the source and replaces its tags, with the URL check of Create
 func (s *DataSourceService) Update(source *model.DataSource, tagIDs []uint) error {
+	if err := s.checkURL(source); err != nil {
+		return err
+	}
 	if err := s.repo.Update(source); err != nil {
+		if dupErr := s.checkURL(source); dupErr != nil {
+			return dupErr
+		}
 		return err
 	}
 
@@ -53,3 +97,15 @@ func (s *DataSourceService) Update(source *model.DataSource, tagIDs []uint) erro
 func (s *DataSourceService) Delete(id uint) error {
 	return s.repo.Delete(id)
 }
+
+// checkURL returns a *DuplicateURLError if another source has the normalized URL of source
+func (s *DataSourceService) checkURL(source *model.DataSource) error {
+	existing, err := s.repo.GetByNormalizedURL(urlutil.Normalize(source.URL), source.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &DuplicateURLError{Resource: "datasource", ID: existing.ID, Name: existing.Name, URL: existing.URL}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/singll/bellkeeper/internal/model"
+)
+
+// DataSource ingest statuses
+const (
+	IngestStatusIngested  = "ingested"
+	IngestStatusDuplicate = "duplicate"
+)
+
+// DataSourceIngestResult describes the document created from a data source page.
+type DataSourceIngestResult struct {
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+	DocumentID string `json:"document_id,omitempty"`
+	DatasetID  string `json:"dataset_id,omitempty"`
+	Title      string `json:"title"`
+	Length     int    `json:"length"`
+	// Extracted is set when only the main article was kept, Cached when it came from the extraction cache
+	Extracted bool `json:"extracted"`
+	Cached    bool `json:"cached"`
+}
+
+// Ingest fetches the data source page and uploads it to RagFlow, routed by the
+// source's tags and category. Sources with full-text extraction upload only the
+// main article (cached per URL unless refresh is set); others upload the whole page.
+func (s *DataSourceService) Ingest(ctx context.Context, source *model.DataSource, refresh bool) (*DataSourceIngestResult, error) {
+	if s.urlDedup {
+		check, err := s.datasetSvc.CheckURL(source.URL, true, false)
+		if err != nil {
+			return nil, fmt.Errorf("dedup check failed: %w", err)
+		}
+		if check.Exists {
+			return &DataSourceIngestResult{
+				Status:     IngestStatusDuplicate,
+				Message:    "already ingested as " + check.StoredURL,
+				DocumentID: check.DocumentID,
+				DatasetID:  check.DatasetID,
+				Title:      check.Title,
+			}, nil
+		}
+	}
+
+	result := &DataSourceIngestResult{Extracted: source.ExtractFullText}
+	page, cached, err := s.fetchPage(ctx, source, refresh)
+	if err != nil {
+		return nil, err
+	}
+	result.Cached = cached
+
+	title, content := pageDocument(source, page)
+	result.Title = title
+	result.Length = page.Length
+
+	result.DocumentID, result.DatasetID, err = s.uploadDocument(source, title, source.URL, content)
+	if err != nil {
+		return nil, err
+	}
+	result.Status = IngestStatusIngested
+	if source.Watch {
+		s.recordIngest(source, page, result)
+	}
+	return result, nil
+}
+
+// uploadDocument uploads a document of the data source to RagFlow, routed by the
+// source's tags and category, and returns the document and dataset IDs.
+func (s *DataSourceService) uploadDocument(source *model.DataSource, title, docURL, content string) (documentID, datasetID string, err error) {
+	tagNames := make([]string, 0, len(source.Tags))
+	for _, t := range source.Tags {
+		tagNames = append(tagNames, t.Name)
+	}
+
+	resp, datasetID, err := s.ragflowSvc.UploadWithRouting(&UploadRequest{
+		Content:        content,
+		Filename:       documentFilename(title, fmt.Sprintf("datasource-%d", source.ID)),
+		Title:          title,
+		URL:            docURL,
+		Tags:           tagNames,
+		Category:       source.Category,
+		AutoCreateTags: true,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if resp.Code != 0 {
+		return "", "", fmt.Errorf("RagFlow error %d: %s", resp.Code, resp.Message)
+	}
+	if resp.Data != nil {
+		documentID, _ = resp.Data["id"].(string)
+	}
+	return documentID, datasetID, nil
+}
+
+// fetchPage downloads the data source page, keeping only the main article for
+// sources with full-text extraction. cached reports an extraction cache hit.
+func (s *DataSourceService) fetchPage(ctx context.Context, source *model.DataSource, refresh bool) (page *model.ExtractedPage, cached bool, err error) {
+	if source.ExtractFullText {
+		page, cached, err = s.extractSvc.Extract(ctx, source.URL, refresh)
+	} else {
+		page, err = s.extractSvc.Convert(ctx, source.URL)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch %s: %w", source.URL, err)
+	}
+	return page, cached, nil
+}
+
+// pageDocument returns the title and markdown body of the RagFlow document for a page.
+func pageDocument(source *model.DataSource, page *model.ExtractedPage) (title, content string) {
+	title = page.Title
+	if strings.TrimSpace(title) == "" {
+		title = source.Name
+	}
+
+	var b strings.Builder
+	b.WriteString("# " + title + "\n\n")
+	b.WriteString("Source: " + source.URL + "\n\n")
+	b.WriteString(page.Content)
+	return title, b.String()
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/urlutil"
+)
+
+// Data source import/export file formats
+const (
+	DataSourceFormatCSV  = "csv"
+	DataSourceFormatJSON = "json"
+)
+
+// Data source import result statuses
+const (
+	DataSourceImportCreated   = "created"
+	DataSourceImportUpdated   = "updated"
+	DataSourceImportUnchanged = "unchanged"
+	DataSourceImportRejected  = "rejected"
+	DataSourceImportFailed    = "failed"
+)
+
+// Data source import/export errors
+var (
+	// ErrUnsupportedFormat is returned for import/export formats other than CSV and JSON.
+	ErrUnsupportedFormat = errors.New("unsupported format, expected csv or json")
+	// ErrInvalidImportFile is returned when an import file cannot be parsed.
+	ErrInvalidImportFile = errors.New("invalid import file")
+)
+
+// dataSourceColumns is the CSV header written on export and recognized on import.
+var dataSourceColumns = []string{"name", "url", "type", "category", "description", "active", "tags"}
+
+// DataSourceRecord is a data source as exchanged in import and export files.
+// A nil Active or Tags leaves the current value unchanged when a record updates
+// an existing source.
+type DataSourceRecord struct {
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Type        string   `json:"type,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+	Tags        []string `json:"tags"`
+
+	// invalid is set when a CSV cell could not be parsed
+	invalid string
+}
+
+// DataSourceImportResult describes what happened, or with a dry run what
+// would happen, to a single record. Row counts records from 1, excluding the CSV header.
+type DataSourceImportResult struct {
+	Row          int      `json:"row"`
+	Name         string   `json:"name"`
+	URL          string   `json:"url"`
+	Status       string   `json:"status"`
+	DataSourceID uint     `json:"data_source_id,omitempty"`
+	Changes      []string `json:"changes,omitempty"`
+	Message      string   `json:"message,omitempty"`
+}
+
+// DataSourceImportSummary aggregates the per-record results of an import.
+type DataSourceImportSummary struct {
+	DryRun    bool                     `json:"dry_run"`
+	Total     int                      `json:"total"`
+	Created   int                      `json:"created"`
+	Updated   int                      `json:"updated"`
+	Unchanged int                      `json:"unchanged"`
+	Rejected  int                      `json:"rejected"`
+	Failed    int                      `json:"failed"`
+	Results   []DataSourceImportResult `json:"results"`
+}
+
+// ImportDataSources creates or updates a data source for every record of a CSV
+// or JSON file. Records are matched to existing sources by normalized URL; a
+// matched source takes the name, type, category and description of the record,
+// and its active flag and tags when given. With dryRun nothing is written and
+// the summary shows which records would be created, updated or rejected.
+func (s *DataSourceService) ImportDataSources(r io.Reader, format string, dryRun bool) (*DataSourceImportSummary, error) {
+	var records []DataSourceRecord
+	var err error
+	switch format {
+	case DataSourceFormatCSV:
+		records, err = readDataSourceCSV(r)
+	case DataSourceFormatJSON:
+		records, err = readDataSourceJSON(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	existing, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	byURL := make(map[string]*model.DataSource, len(existing))
+	for i := range existing {
+		byURL[urlutil.Normalize(existing[i].URL)] = &existing[i]
+	}
+
+	summary := &DataSourceImportSummary{
+		DryRun:  dryRun,
+		Total:   len(records),
+		Results: make([]DataSourceImportResult, 0, len(records)),
+	}
+	seen := make(map[string]int, len(records))
+	for i, record := range records {
+		result := s.importRecord(i+1, record, byURL, seen, dryRun)
+		switch result.Status {
+		case DataSourceImportCreated:
+			summary.Created++
+		case DataSourceImportUpdated:
+			summary.Updated++
+		case DataSourceImportUnchanged:
+			summary.Unchanged++
+		case DataSourceImportRejected:
+			summary.Rejected++
+		default:
+			summary.Failed++
+		}
+		summary.Results = append(summary.Results, result)
+	}
+	return summary, nil
+}
+
+// importRecord validates a record and creates or updates its data source.
+// seen maps the normalized URLs of earlier records to their row, so a URL
+// listed twice is only applied once.
+func (s *DataSourceService) importRecord(row int, record DataSourceRecord, byURL map[string]*model.DataSource, seen map[string]int, dryRun bool) DataSourceImportResult {
+	record = cleanRecord(record)
+	result := DataSourceImportResult{Row: row, Name: record.Name, URL: record.URL}
+
+	if msg := validateRecord(record); msg != "" {
+		result.Status = DataSourceImportRejected
+		result.Message = msg
+		return result
+	}
+	key := urlutil.Normalize(record.URL)
+	if first, ok := seen[key]; ok {
+		result.Status = DataSourceImportRejected
+		result.Message = fmt.Sprintf("duplicate of row %d", first)
+		return result
+	}
+	seen[key] = row
+
+	source, ok := byURL[key]
+	if !ok {
+		result.Status = DataSourceImportCreated
+		if dryRun {
+			return result
+		}
+		source = &model.DataSource{
+			Name:        record.Name,
+			URL:         record.URL,
+			Type:        record.Type,
+			Category:    record.Category,
+			Description: record.Description,
+			IsActive:    record.Active == nil || *record.Active,
+		}
+		if err := s.repo.Create(source); err != nil {
+			result.Status, result.Message = importFailure(err, s.checkURL(source))
+			return result
+		}
+		result.DataSourceID = source.ID
+		if len(record.Tags) > 0 {
+			if err := s.applyTagNames(source, record.Tags); err != nil {
+				result.Message = fmt.Sprintf("data source created but tags were not applied: %v", err)
+			}
+		}
+		return result
+	}
+
+	result.DataSourceID = source.ID
+	result.Changes = recordChanges(source, record)
+	if len(result.Changes) == 0 {
+		result.Status = DataSourceImportUnchanged
+		return result
+	}
+	result.Status = DataSourceImportUpdated
+	if dryRun {
+		return result
+	}
+
+	source.Name = record.Name
+	source.Type = record.Type
+	source.Category = record.Category
+	source.Description = record.Description
+	if record.Active != nil {
+		source.IsActive = *record.Active
+	}
+	if err := s.repo.Update(source); err != nil {
+		result.Status, result.Message = importFailure(err, s.checkURL(source))
+		return result
+	}
+	if record.Tags != nil {
+		if err := s.applyTagNames(source, record.Tags); err != nil {
+			result.Message = fmt.Sprintf("data source updated but tags were not applied: %v", err)
+		}
+	}
+	return result
+}
+
+// importFailure turns a failed write into a result status and message. A
+// write that failed because the URL is taken (dupErr) rejects the record.
+func importFailure(err, dupErr error) (string, string) {
+	var conflict *DuplicateURLError
+	if errors.As(dupErr, &conflict) {
+		return DataSourceImportRejected, conflict.Error()
+	}
+	return DataSourceImportFailed, err.Error()
+}
+
+// applyTagNames replaces the tags of a source, creating tags that do not exist yet.
+func (s *DataSourceService) applyTagNames(source *model.DataSource, names []string) error {
+	tags, err := s.tagSvc.GetOrCreateByNames(names)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateTags(source, tags)
+}
+
+// cleanRecord trims the fields of a record and applies the default type.
+func cleanRecord(record DataSourceRecord) DataSourceRecord {
+	record.Name = strings.TrimSpace(record.Name)
+	record.URL = strings.TrimSpace(record.URL)
+	record.Type = strings.ToLower(strings.TrimSpace(record.Type))
+	if record.Type == "" {
+		record.Type = model.DataSourceTypeWebsite
+	}
+	record.Category = strings.TrimSpace(record.Category)
+	record.Description = strings.TrimSpace(record.Description)
+	if record.Tags != nil {
+		// Keep an empty list distinct from nil: it clears the tags
+		record.Tags = append([]string{}, uniqueNames(record.Tags)...)
+	}
+	return record
+}
+
+// validateRecord returns why a record cannot be imported, or "" if it can.
+func validateRecord(record DataSourceRecord) string {
+	if record.invalid != "" {
+		return record.invalid
+	}
+	if record.Name == "" {
+		return "name is required"
+	}
+	if len([]rune(record.Name)) > 200 {
+		return "name is longer than 200 characters"
+	}
+	if record.URL == "" {
+		return "url is required"
+	}
+	if len(record.URL) > 1000 {
+		return "url is longer than 1000 characters"
+	}
+	u, err := url.Parse(record.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "url must be an absolute http or https URL"
+	}
+	if len(record.Type) > 50 {
+		return "type is longer than 50 characters"
+	}
+	if len([]rune(record.Category)) > 100 {
+		return "category is longer than 100 characters"
+	}
+	return ""
+}
+
+// recordChanges lists the fields an import of record would change on source.
+func recordChanges(source *model.DataSource, record DataSourceRecord) []string {
+	var changes []string
+	if source.Name != record.Name {
+		changes = append(changes, "name")
+	}
+	if source.Type != record.Type {
+		changes = append(changes, "type")
+	}
+	if source.Category != record.Category {
+		changes = append(changes, "category")
+	}
+	if source.Description != record.Description {
+		changes = append(changes, "description")
+	}
+	if record.Active != nil && source.IsActive != *record.Active {
+		changes = append(changes, "active")
+	}
+	if record.Tags != nil && !sameNames(tagNames(source.Tags), record.Tags) {
+		changes = append(changes, "tags")
+	}
+	return changes
+}
+
+// sameNames reports whether two name lists hold the same names in any order.
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func tagNames(tags []model.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// readDataSourceCSV parses a CSV file with a header row. The name and url
+// columns are required; the other columns of dataSourceColumns are optional
+// and unknown columns are ignored. Tags are separated by commas or semicolons.
+func readDataSourceCSV(r io.Reader) ([]DataSourceRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "is_active" {
+			name = "active"
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("CSV header must contain name and url columns")
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, errors.New("CSV header must contain name and url columns")
+	}
+
+	var records []DataSourceRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		cell := func(column string) (string, bool) {
+			i, ok := columns[column]
+			if !ok || i >= len(row) {
+				return "", ok
+			}
+			return strings.TrimSpace(row[i]), true
+		}
+		var record DataSourceRecord
+		record.Name, _ = cell("name")
+		record.URL, _ = cell("url")
+		record.Type, _ = cell("type")
+		record.Category, _ = cell("category")
+		record.Description, _ = cell("description")
+		if v, _ := cell("active"); v != "" {
+			active, err := parseActive(v)
+			if err != nil {
+				record.invalid = fmt.Sprintf("active %q is not a boolean", v)
+			}
+			record.Active = &active
+		}
+		if v, ok := cell("tags"); ok {
+			record.Tags = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' })
+			if record.Tags == nil {
+				record.Tags = []string{}
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// parseActive parses the active column, accepting yes/no besides strconv.ParseBool values.
+func parseActive(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// readDataSourceJSON parses a JSON array of records.
+func readDataSourceJSON(r io.Reader) ([]DataSourceRecord, error) {
+	var records []DataSourceRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("invalid JSON, expected an array of data sources: %w", err)
+	}
+	return records, nil
+}
+
+// ExportDataSources renders all data sources as CSV (with a UTF-8 BOM so
+// spreadsheet applications detect the encoding) or as a JSON array. The
+// output can be imported again unchanged.
+func (s *DataSourceService) ExportDataSources(format string) ([]byte, error) {
+	if format != DataSourceFormatCSV && format != DataSourceFormatJSON {
+		return nil, ErrUnsupportedFormat
+	}
+	sources, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]DataSourceRecord, 0, len(sources))
+	for _, source := range sources {
+		active := source.IsActive
+		records = append(records, DataSourceRecord{
+			Name:        source.Name,
+			URL:         source.URL,
+			Type:        source.Type,
+			Category:    source.Category,
+			Description: source.Description,
+			Active:      &active,
+			Tags:        tagNames(source.Tags),
+		})
+	}
+
+	if format == DataSourceFormatJSON {
+		return json.MarshalIndent(records, "", "  ")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	if err := w.Write(dataSourceColumns); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		row := []string{
+			record.Name,
+			record.URL,
+			record.Type,
+			record.Category,
+			record.Description,
+			strconv.FormatBool(*record.Active),
+			strings.Join(record.Tags, ","),
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
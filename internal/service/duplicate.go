@@ -0,0 +1,16 @@
+package service
+
+import "fmt"
+
+// DuplicateURLError is returned when a URL normalizes to the URL of an existing
+// data source or feed. It identifies the existing record so clients can link to it.
+type DuplicateURLError struct {
+	Resource string `json:"resource"` // "datasource" or "rss"
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+}
+
+func (e *DuplicateURLError) Error() string {
+	return fmt.Sprintf("URL is already used by %s %d (%s)", e.Resource, e.ID, e.URL)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+)
+
+// Event types published on the event bus
+const (
+	EventDocumentUploaded      = "document.uploaded"
+	EventDocumentDeleted       = "document.deleted"
+	EventTagCreated            = "tag.created"
+	EventFeedEntryNew          = "feed.entry.new"
+	EventDataSourceDown        = "datasource.down"
+	EventDatasetMappingChanged = "dataset.mapping.changed"
+)
+
+// EventTypes lists every event type the bus publishes
+var EventTypes = []string{
+	EventDocumentUploaded,
+	EventDocumentDeleted,
+	EventTagCreated,
+	EventFeedEntryNew,
+	EventDataSourceDown,
+	EventDatasetMappingChanged,
+}
+
+// Event is something that happened in Bellkeeper. Tags, Category and
+// DatasetID describe what the event concerns, where that applies, so that
+// subscribers can filter on them; the rest depends on the type and is in Data.
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Tags       []string               `json:"tags,omitempty"`
+	Category   string                 `json:"category,omitempty"`
+	DatasetID  string                 `json:"dataset_id,omitempty"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// NewEvent returns an event of the given type that occurred now.
+func NewEvent(eventType string, data map[string]interface{}) Event {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return Event{
+		ID:         hex.EncodeToString(id),
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Data:       data,
+	}
+}
+
+// eventTypePatterns returns every subscription pattern matching an event type,
+// from * down to the type itself.
+func eventTypePatterns(eventType string) []string {
+	patterns := []string{"*"}
+	parts := strings.Split(eventType, ".")
+	for i := 1; i < len(parts); i++ {
+		patterns = append(patterns, strings.Join(parts[:i], ".")+".*")
+	}
+	return append(patterns, eventType)
+}
+
+// EventBus hands the events published by services to the handlers subscribed
+// to them, in order, on a goroutine of its own. When the buffer is full the
+// publisher hands the event to the handlers itself rather than dropping it, so
+// a backlog slows publishing down instead of losing webhook deliveries. A nil
+// *EventBus drops every event, so services can be used without one.
+type EventBus struct {
+	events   chan Event
+	handlers []func(Event)
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{events: make(chan Event, defaults.EventBuffer)}
+}
+
+// Subscribe registers a handler receiving every event. It must be called before Start.
+func (b *EventBus) Subscribe(handler func(Event)) {
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish queues an event for the handlers.
+func (b *EventBus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	select {
+	case b.events <- event:
+	default:
+		log.Printf("warn: event bus is full, handling %s event %s synchronously", event.Type, event.ID)
+		b.dispatch(event)
+	}
+}
+
+// Start launches the goroutine handing out events.
+func (b *EventBus) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+
+	b.wg.Add(1)
+	go b.run(ctx)
+	log.Println("Event bus started")
+}
+
+// Stop hands out the events already queued and waits for the handlers to finish.
+func (b *EventBus) Stop() {
+	if b.cancel == nil {
+		return
+	}
+	b.cancel()
+	b.wg.Wait()
+	log.Println("Event bus stopped")
+}
+
+func (b *EventBus) run(ctx context.Context) {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case event := <-b.events:
+			b.dispatch(event)
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-b.events:
+					b.dispatch(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *EventBus) dispatch(event Event) {
+	for _, handler := range b.handlers {
+		b.call(handler, event)
+	}
+}
+
+// call runs a handler, logging a panic instead of stopping the bus.
+func (b *EventBus) call(handler func(Event), event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("warn: handler of %s event %s panicked: %v", event.Type, event.ID, r)
+		}
+	}()
+	handler(event)
+}
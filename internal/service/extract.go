@@ -0,0 +1,126 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/extract"
+	"github.com/singll/bellkeeper/internal/repository"
+	"golang.org/x/net/html/charset"
+	"gorm.io/gorm"
+)
+
+// ExtractService fetches web pages and extracts their main article as Markdown.
+// Extracted articles are cached per URL.
+type ExtractService struct {
+	repo      *repository.ExtractedPageRepository
+	userAgent string
+	client    *http.Client
+}
+
+// NewExtractService shares the RSS fetcher's user agent and timeout, since most
+// extractions are for feed entry links.
+func NewExtractService(cfg config.RSSConfig, repo *repository.ExtractedPageRepository) *ExtractService {
+	return &ExtractService{
+		repo:      repo,
+		userAgent: cfg.UserAgent,
+		client:    &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}
+}
+
+// Extract returns the main article of the page, served from the cache unless refresh is set.
+// cached reports whether the page came from the cache.
+func (s *ExtractService) Extract(ctx context.Context, pageURL string, refresh bool) (page *model.ExtractedPage, cached bool, err error) {
+	if !refresh {
+		page, err := s.repo.GetByURL(pageURL)
+		if err == nil {
+			return page, true, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+	}
+
+	body, finalURL, err := s.fetch(ctx, pageURL)
+	if err != nil {
+		return nil, false, err
+	}
+	article, err := extract.Extract(body, finalURL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	page = &model.ExtractedPage{
+		URL:     pageURL,
+		Title:   truncateRunes(article.Title, 1000),
+		Content: article.Markdown,
+		Length:  article.Length,
+	}
+	if err := s.repo.Upsert(page); err != nil {
+		return nil, false, fmt.Errorf("failed to cache extracted page: %w", err)
+	}
+	return page, false, nil
+}
+
+// Convert fetches the page and renders its whole body as Markdown. The result is not cached.
+func (s *ExtractService) Convert(ctx context.Context, pageURL string) (*model.ExtractedPage, error) {
+	body, finalURL, err := s.fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	article, err := extract.Convert(body, finalURL)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ExtractedPage{
+		URL:     pageURL,
+		Title:   article.Title,
+		Content: article.Markdown,
+		Length:  article.Length,
+	}, nil
+}
+
+// fetch downloads an HTML page and returns its body decoded to UTF-8 together
+// with the final URL after redirects, which relative links resolve against.
+func (s *ExtractService) fetch(ctx context.Context, pageURL string) (io.Reader, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+	req.Header.Set("Accept", "text/html, application/xhtml+xml;q=0.9, */*;q=0.5")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, "", fmt.Errorf("unsupported content type %q", mediaType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, defaults.MaxPageBodySize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+	body, err := charset.NewReader(bytes.NewReader(data), contentType)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode page: %w", err)
+	}
+	return body, resp.Request.URL.String(), nil
+}
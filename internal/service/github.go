@@ -0,0 +1,620 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/datatypes"
+)
+
+// GitHub sync errors
+var (
+	// ErrNotGitHub is returned when syncing a data source that is not of type github
+	ErrNotGitHub = errors.New("only github data sources can be synced")
+	// ErrSyncInProgress is returned when a data source is synced while another sync of it is still running
+	ErrSyncInProgress = errors.New("data source is already being synced")
+	// ErrGitHubNotFound is returned when the repository or a docs path does not exist
+	ErrGitHubNotFound = errors.New("not found on GitHub")
+)
+
+// githubSettingsKey is the DataSource.Metadata key holding the repository settings
+const githubSettingsKey = "github"
+
+// GitHubSettings selects what a github data source syncs. Owner and Repo default
+// to the github.com URL of the data source. Docs lists Markdown files or
+// directories (searched recursively) in the default branch.
+type GitHubSettings struct {
+	Owner              string   `json:"owner,omitempty"`
+	Repo               string   `json:"repo,omitempty"`
+	IncludePrereleases bool     `json:"include_prereleases,omitempty"`
+	MaxReleases        int      `json:"max_releases,omitempty"`
+	Readme             bool     `json:"readme,omitempty"`
+	Docs               []string `json:"docs,omitempty"`
+}
+
+func (g GitHubSettings) isEmpty() bool {
+	return g.Owner == "" && g.Repo == "" && !g.IncludePrereleases && g.MaxReleases == 0 && !g.Readme && len(g.Docs) == 0
+}
+
+// GitHubSyncResult summarizes a single sync of a github data source.
+type GitHubSyncResult struct {
+	DataSourceID uint `json:"data_source_id"`
+	Releases     int  `json:"releases"`  // new releases ingested
+	Files        int  `json:"files"`     // new or changed Markdown files ingested
+	Unchanged    int  `json:"unchanged"` // releases and files ingested before
+	Duplicate    int  `json:"duplicate"` // releases already in RagFlow by URL, recorded without uploading
+	Skipped      int  `json:"skipped"`   // drafts, prereleases and files that are too large
+	Errors       int  `json:"errors"`    // releases and files that could not be ingested
+}
+
+// GitHubService syncs github data sources: new releases are ingested once per
+// tag and README/docs Markdown files are re-ingested when their content changes.
+type GitHubService struct {
+	cfg       config.GitHubConfig
+	userAgent string
+	urlDedup  bool
+	repo      *repository.DataSourceRepository
+	itemRepo  *repository.GitHubItemRepository
+	sources   *DataSourceService
+	client    *http.Client
+
+	// inflight guards against syncing the same data source twice concurrently
+	inflight sync.Map
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewGitHubService uploads through the data source service so synced documents
+// are routed by the data source's tags and category like a manual ingest.
+func NewGitHubService(
+	cfg config.GitHubConfig,
+	userAgent string,
+	urlDedup bool,
+	repo *repository.DataSourceRepository,
+	itemRepo *repository.GitHubItemRepository,
+	sources *DataSourceService,
+) *GitHubService {
+	return &GitHubService{
+		cfg:       cfg,
+		userAgent: userAgent,
+		urlDedup:  urlDedup,
+		repo:      repo,
+		itemRepo:  itemRepo,
+		sources:   sources,
+		client:    &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}
+}
+
+// Start launches the background sync loop. It is a no-op when the GitHub sync is disabled.
+func (s *GitHubService) Start() {
+	if !s.cfg.Enabled {
+		log.Println("GitHub sync disabled by configuration")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.run(ctx)
+	log.Printf("GitHub sync started (poll interval %ds, sync interval %dm)", s.cfg.PollInterval, s.cfg.Interval)
+}
+
+// Stop cancels the running sync and waits for the loop to exit.
+func (s *GitHubService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	log.Println("GitHub sync stopped")
+}
+
+func (s *GitHubService) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	interval := time.Duration(s.cfg.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.syncDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.syncDue(ctx)
+		}
+	}
+}
+
+// syncDue syncs every github data source whose sync interval has elapsed.
+func (s *GitHubService) syncDue(ctx context.Context) {
+	sources, err := s.repo.GetDueForCrawl(
+		[]string{model.DataSourceTypeGitHub},
+		time.Now().Add(-time.Duration(s.cfg.Interval)*time.Minute),
+	)
+	if err != nil {
+		log.Printf("warn: GitHub sync failed to load data sources: %v", err)
+		return
+	}
+
+	for i := range sources {
+		if ctx.Err() != nil {
+			return
+		}
+		result, err := s.Sync(ctx, &sources[i])
+		if err != nil {
+			log.Printf("warn: GitHub sync failed for data source %d (%s): %v", sources[i].ID, sources[i].URL, err)
+			continue
+		}
+		log.Printf("Data source %d synced: %d releases, %d files, %d unchanged, %d duplicate, %d skipped, %d errors",
+			result.DataSourceID, result.Releases, result.Files, result.Unchanged, result.Duplicate, result.Skipped, result.Errors)
+	}
+}
+
+// ListItems returns the releases and files ingested for a data source, optionally of one kind
+func (s *GitHubService) ListItems(dataSourceID uint, kind string) ([]model.GitHubItem, error) {
+	return s.itemRepo.ListByDataSource(dataSourceID, kind)
+}
+
+// Sync ingests the new releases and the new or changed Markdown files of a
+// github data source. Like crawls, the start time and error of the run are
+// recorded in the source's last_crawled_at and last_crawl_error.
+func (s *GitHubService) Sync(ctx context.Context, source *model.DataSource) (*GitHubSyncResult, error) {
+	if source.Type != model.DataSourceTypeGitHub {
+		return nil, ErrNotGitHub
+	}
+	if _, busy := s.inflight.LoadOrStore(source.ID, struct{}{}); busy {
+		return nil, fmt.Errorf("data source %d: %w", source.ID, ErrSyncInProgress)
+	}
+	defer s.inflight.Delete(source.ID)
+
+	started := time.Now()
+	result, err := s.sync(ctx, source)
+	if ctx.Err() == nil {
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+		if recErr := s.repo.RecordCrawl(source.ID, started, errMsg); recErr != nil {
+			log.Printf("warn: failed to record sync of data source %d: %v", source.ID, recErr)
+		}
+	}
+	return result, err
+}
+
+func (s *GitHubService) sync(ctx context.Context, source *model.DataSource) (*GitHubSyncResult, error) {
+	settings, err := githubSettings(source)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.itemRepo.ListByDataSource(source.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]*model.GitHubItem, len(items))
+	for i := range items {
+		known[items[i].Kind+":"+items[i].Ref] = &items[i]
+	}
+
+	result := &GitHubSyncResult{DataSourceID: source.ID}
+	if err := s.syncReleases(ctx, source, settings, known, result); err != nil {
+		return result, err
+	}
+	if err := s.syncFiles(ctx, source, settings, known, result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+type githubRelease struct {
+	TagName     string     `json:"tag_name"`
+	Name        string     `json:"name"`
+	Body        string     `json:"body"`
+	HTMLURL     string     `json:"html_url"`
+	Draft       bool       `json:"draft"`
+	Prerelease  bool       `json:"prerelease"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+type githubContent struct {
+	Type     string `json:"type"` // file, dir, symlink, submodule
+	Path     string `json:"path"`
+	SHA      string `json:"sha"`
+	Size     int    `json:"size"`
+	HTMLURL  string `json:"html_url"`
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
+// syncReleases ingests the latest releases not ingested before, oldest first.
+func (s *GitHubService) syncReleases(ctx context.Context, source *model.DataSource, settings GitHubSettings, known map[string]*model.GitHubItem, result *GitHubSyncResult) error {
+	query := url.Values{}
+	query.Set("per_page", strconv.Itoa(settings.MaxReleases))
+	var releases []githubRelease
+	if err := s.get(ctx, repoPath(settings, "releases"), query, &releases); err != nil {
+		return fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	for i := len(releases) - 1; i >= 0; i-- {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		release := releases[i]
+		if release.Draft || (release.Prerelease && !settings.IncludePrereleases) {
+			result.Skipped++
+			continue
+		}
+		if known[model.GitHubItemRelease+":"+release.TagName] != nil {
+			result.Unchanged++
+			continue
+		}
+
+		name := release.Name
+		if strings.TrimSpace(name) == "" {
+			name = release.TagName
+		}
+		item := &model.GitHubItem{
+			DataSourceID: source.ID,
+			Kind:         model.GitHubItemRelease,
+			Ref:          truncateRunes(release.TagName, 500),
+			Title:        truncateRunes(fmt.Sprintf("%s/%s %s", settings.Owner, settings.Repo, name), 1000),
+			URL:          truncateRunes(release.HTMLURL, 2000),
+			PublishedAt:  release.PublishedAt,
+		}
+
+		if s.urlDedup && release.HTMLURL != "" {
+			check, err := s.sources.datasetSvc.CheckURL(release.HTMLURL, true, false)
+			if err != nil {
+				return fmt.Errorf("dedup check failed: %w", err)
+			}
+			if check.Exists {
+				item.DocumentID = check.DocumentID
+				item.DatasetID = check.DatasetID
+				if err := s.itemRepo.Save(item); err != nil {
+					return err
+				}
+				result.Duplicate++
+				continue
+			}
+		}
+
+		var b strings.Builder
+		b.WriteString("# " + item.Title + "\n\n")
+		b.WriteString("Source: " + release.HTMLURL + "\n\n")
+		b.WriteString("Tag: " + release.TagName)
+		if release.PublishedAt != nil {
+			b.WriteString(" (published " + release.PublishedAt.Format("2006-01-02") + ")")
+		}
+		b.WriteString("\n\n" + release.Body)
+
+		documentID, datasetID, err := s.sources.uploadDocument(source, item.Title, release.HTMLURL, b.String())
+		if err != nil {
+			result.Errors++
+			log.Printf("warn: failed to ingest release %s of data source %d: %v", release.TagName, source.ID, err)
+			continue
+		}
+		item.DocumentID, item.DatasetID = documentID, datasetID
+		if err := s.itemRepo.Save(item); err != nil {
+			return err
+		}
+		result.Releases++
+	}
+	return nil
+}
+
+// syncFiles ingests the README and the Markdown files under the docs paths.
+// Files seen before are re-ingested only when their blob SHA changed, replacing
+// the stale RagFlow document.
+func (s *GitHubService) syncFiles(ctx context.Context, source *model.DataSource, settings GitHubSettings, known map[string]*model.GitHubItem, result *GitHubSyncResult) error {
+	var files []githubContent
+	if settings.Readme {
+		var readme githubContent
+		err := s.get(ctx, repoPath(settings, "readme"), nil, &readme)
+		switch {
+		case err == nil:
+			files = append(files, readme)
+		case errors.Is(err, ErrGitHubNotFound):
+			// Repository without README
+		default:
+			return fmt.Errorf("failed to fetch README: %w", err)
+		}
+	}
+	for _, docsPath := range settings.Docs {
+		found, err := s.listMarkdown(ctx, settings, docsPath, defaults.GitHubMaxFiles-len(files))
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", docsPath, err)
+		}
+		files = append(files, found...)
+	}
+
+	seen := map[string]bool{}
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if seen[file.Path] {
+			continue
+		}
+		seen[file.Path] = true
+
+		item := known[model.GitHubItemFile+":"+file.Path]
+		if item != nil && item.SHA == file.SHA {
+			result.Unchanged++
+			continue
+		}
+		if err := s.syncFile(ctx, source, settings, file, item, result); err != nil {
+			result.Errors++
+			log.Printf("warn: failed to ingest %s of data source %d: %v", file.Path, source.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *GitHubService) syncFile(ctx context.Context, source *model.DataSource, settings GitHubSettings, file githubContent, item *model.GitHubItem, result *GitHubSyncResult) error {
+	// Directory listings do not include the content
+	if file.Content == "" {
+		if err := s.get(ctx, repoPath(settings, "contents", file.Path), nil, &file); err != nil {
+			return err
+		}
+	}
+	if file.Encoding != "base64" {
+		// Files over 1 MB come without content
+		result.Skipped++
+		return nil
+	}
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+	if err != nil {
+		return fmt.Errorf("invalid file content: %w", err)
+	}
+
+	title := fmt.Sprintf("%s/%s %s", settings.Owner, settings.Repo, file.Path)
+	var b strings.Builder
+	b.WriteString("# " + title + "\n\n")
+	b.WriteString("Source: " + file.HTMLURL + "\n\n")
+	b.Write(content)
+
+	if item == nil {
+		item = &model.GitHubItem{DataSourceID: source.ID, Kind: model.GitHubItemFile, Ref: truncateRunes(file.Path, 500)}
+	}
+	if item.DocumentID != "" && item.DatasetID != "" {
+		documentID, err := s.sources.replaceDocument(ctx, source, item.DatasetID, item.DocumentID, title, file.HTMLURL, b.String())
+		if documentID == "" {
+			return err
+		}
+		if err != nil {
+			log.Printf("warn: %v", err)
+		}
+		item.DocumentID = documentID
+	} else {
+		item.DocumentID, item.DatasetID, err = s.sources.uploadDocument(source, title, file.HTMLURL, b.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	item.SHA = file.SHA
+	item.Title = truncateRunes(title, 1000)
+	item.URL = truncateRunes(file.HTMLURL, 2000)
+	if err := s.itemRepo.Save(item); err != nil {
+		return err
+	}
+	result.Files++
+	return nil
+}
+
+// listMarkdown returns the Markdown files at a path, walking directories
+// recursively, up to limit files.
+func (s *GitHubService) listMarkdown(ctx context.Context, settings GitHubSettings, docsPath string, limit int) ([]githubContent, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	var raw json.RawMessage
+	if err := s.get(ctx, repoPath(settings, "contents", docsPath), nil, &raw); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+		var file githubContent
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return nil, err
+		}
+		if file.Type != "file" || !isMarkdownPath(file.Path) {
+			return nil, fmt.Errorf("%s is not a Markdown file", docsPath)
+		}
+		return []githubContent{file}, nil
+	}
+
+	var entries []githubContent
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+	var files []githubContent
+	for _, entry := range entries {
+		if len(files) >= limit {
+			break
+		}
+		switch {
+		case entry.Type == "file" && isMarkdownPath(entry.Path):
+			files = append(files, entry)
+		case entry.Type == "dir":
+			found, err := s.listMarkdown(ctx, settings, entry.Path, limit-len(files))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, found...)
+		}
+	}
+	return files, nil
+}
+
+// get calls the GitHub REST API and decodes the JSON response into out.
+func (s *GitHubService) get(ctx context.Context, apiPath string, query url.Values, out interface{}) error {
+	endpoint := strings.TrimRight(s.cfg.APIBaseURL, "/") + apiPath
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", s.userAgent)
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, defaults.MaxGitHubBodySize))
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%s: %w", apiPath, ErrGitHubNotFound)
+	case (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get("X-RateLimit-Remaining") == "0":
+		reset := resp.Header.Get("X-RateLimit-Reset")
+		if secs, err := strconv.ParseInt(reset, 10, 64); err == nil {
+			reset = time.Unix(secs, 0).Format(time.RFC3339)
+		}
+		return fmt.Errorf("GitHub API rate limit exceeded, resets at %s", reset)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("GitHub API returned HTTP %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("GitHub API returned HTTP %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// repoPath builds the API path of a repository resource, escaping each segment.
+func repoPath(settings GitHubSettings, resource string, subPath ...string) string {
+	p := "/repos/" + url.PathEscape(settings.Owner) + "/" + url.PathEscape(settings.Repo) + "/" + resource
+	for _, sp := range subPath {
+		for _, segment := range strings.Split(strings.Trim(sp, "/"), "/") {
+			if segment != "" {
+				p += "/" + url.PathEscape(segment)
+			}
+		}
+	}
+	return p
+}
+
+func isMarkdownPath(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".md", ".markdown", ".mdx":
+		return true
+	}
+	return false
+}
+
+// githubSettings returns the effective GitHub settings of a data source.
+func githubSettings(source *model.DataSource) (GitHubSettings, error) {
+	var settings GitHubSettings
+	if len(source.Metadata) > 0 {
+		var meta struct {
+			GitHub *GitHubSettings `json:"github"`
+		}
+		if err := json.Unmarshal(source.Metadata, &meta); err != nil {
+			return settings, fmt.Errorf("invalid data source metadata: %w", err)
+		}
+		if meta.GitHub != nil {
+			settings = *meta.GitHub
+		}
+	}
+	if settings.Owner == "" || settings.Repo == "" {
+		owner, repo, ok := parseGitHubURL(source.URL)
+		if !ok {
+			return settings, errors.New("github data source needs owner and repo, or a https://github.com/{owner}/{repo} URL")
+		}
+		settings.Owner, settings.Repo = owner, repo
+	}
+	if settings.MaxReleases <= 0 {
+		settings.MaxReleases = defaults.GitHubMaxReleases
+	}
+	if settings.MaxReleases > 100 {
+		settings.MaxReleases = 100
+	}
+	return settings, nil
+}
+
+// parseGitHubURL extracts owner and repo from a github.com repository URL.
+func parseGitHubURL(rawURL string) (owner, repo string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.EqualFold(strings.TrimPrefix(u.Host, "www."), "github.com") {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), true
+}
+
+// SetGitHubSettings validates the settings and stores them in the data source's
+// metadata, keeping any other metadata keys. Empty settings remove them. The source is not saved.
+func (s *DataSourceService) SetGitHubSettings(source *model.DataSource, settings GitHubSettings) error {
+	if settings.MaxReleases < 0 || settings.MaxReleases > 100 {
+		return errors.New("max_releases must be between 0 and 100")
+	}
+	if (settings.Owner == "") != (settings.Repo == "") {
+		return errors.New("owner and repo must be set together")
+	}
+	for i, p := range settings.Docs {
+		p = strings.Trim(strings.TrimSpace(p), "/")
+		if p == "" || strings.Contains("/"+p+"/", "/../") {
+			return fmt.Errorf("invalid docs path %q", settings.Docs[i])
+		}
+		settings.Docs[i] = p
+	}
+
+	meta := map[string]interface{}{}
+	if len(source.Metadata) > 0 {
+		if err := json.Unmarshal(source.Metadata, &meta); err != nil {
+			return fmt.Errorf("invalid data source metadata: %w", err)
+		}
+	}
+	if settings.isEmpty() {
+		delete(meta, githubSettingsKey)
+	} else {
+		meta[githubSettingsKey] = settings
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	source.Metadata = datatypes.JSON(data)
+	return nil
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+)
+
+// githubStub serves canned GitHub API responses by request path and records
+// the paths requested.
+type githubStub struct {
+	t         *testing.T
+	responses map[string]interface{}
+
+	mu        sync.Mutex
+	requested []string
+}
+
+func (g *githubStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	g.requested = append(g.requested, r.URL.Path)
+	g.mu.Unlock()
+
+	resp, ok := g.responses[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found"}`))
+		return
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		g.t.Errorf("encode %s: %v", r.URL.Path, err)
+	}
+}
+
+func (g *githubStub) wasRequested(p string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, r := range g.requested {
+		if r == p {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestGitHubService(t *testing.T, handler http.Handler) *GitHubService {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &GitHubService{
+		cfg:       config.GitHubConfig{APIBaseURL: srv.URL},
+		userAgent: "bellkeeper-test",
+		client:    srv.Client(),
+	}
+}
+
+var testGitHubSettings = GitHubSettings{Owner: "acme", Repo: "widget", MaxReleases: 10}
+
+func TestSyncReleasesSkipsAndKnownTags(t *testing.T) {
+	tests := []struct {
+		name          string
+		prereleases   bool
+		releases      []githubRelease
+		known         []string
+		wantSkipped   int
+		wantUnchanged int
+	}{
+		{
+			name: "drafts are skipped",
+			releases: []githubRelease{
+				{TagName: "v2.0.0", Draft: true},
+				{TagName: "v1.9.0", Draft: true},
+			},
+			wantSkipped: 2,
+		},
+		{
+			name: "prereleases are skipped by default",
+			releases: []githubRelease{
+				{TagName: "v2.0.0-rc.1", Prerelease: true},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name:        "drafts are skipped even with prereleases included",
+			prereleases: true,
+			releases: []githubRelease{
+				{TagName: "v2.0.0-rc.2", Prerelease: true, Draft: true},
+			},
+			wantSkipped: 1,
+		},
+		{
+			name: "known tags are unchanged",
+			releases: []githubRelease{
+				{TagName: "v1.1.0"},
+				{TagName: "v1.0.0"},
+			},
+			known:         []string{"v1.0.0", "v1.1.0"},
+			wantUnchanged: 2,
+		},
+		{
+			name:        "included prereleases with known tags are unchanged",
+			prereleases: true,
+			releases: []githubRelease{
+				{TagName: "v3.0.0-beta", Prerelease: true},
+				{TagName: "v2.9.0", Draft: true},
+			},
+			known:         []string{"v3.0.0-beta"},
+			wantSkipped:   1,
+			wantUnchanged: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &githubStub{t: t, responses: map[string]interface{}{
+				"/repos/acme/widget/releases": tt.releases,
+			}}
+			svc := newTestGitHubService(t, stub)
+
+			settings := testGitHubSettings
+			settings.IncludePrereleases = tt.prereleases
+			known := map[string]*model.GitHubItem{}
+			for _, tag := range tt.known {
+				known[model.GitHubItemRelease+":"+tag] = &model.GitHubItem{Kind: model.GitHubItemRelease, Ref: tag}
+			}
+
+			result := &GitHubSyncResult{}
+			source := &model.DataSource{ID: 1, Type: model.DataSourceTypeGitHub}
+			if err := svc.syncReleases(context.Background(), source, settings, known, result); err != nil {
+				t.Fatalf("syncReleases: %v", err)
+			}
+			if result.Skipped != tt.wantSkipped || result.Unchanged != tt.wantUnchanged {
+				t.Errorf("skipped %d, unchanged %d; want %d, %d", result.Skipped, result.Unchanged, tt.wantSkipped, tt.wantUnchanged)
+			}
+			if result.Releases != 0 || result.Errors != 0 {
+				t.Errorf("releases %d, errors %d; want none", result.Releases, result.Errors)
+			}
+		})
+	}
+}
+
+func TestSyncFilesDetectsChangedSHA(t *testing.T) {
+	readme := githubContent{Type: "file", Path: "README.md", SHA: "aaa", HTMLURL: "https://github.com/acme/widget/blob/main/README.md", Content: "IyBXaWRnZXQ=", Encoding: "base64"}
+	guide := githubContent{Type: "file", Path: "docs/guide.md", SHA: "bbb", HTMLURL: "https://github.com/acme/widget/blob/main/docs/guide.md"}
+	notes := githubContent{Type: "file", Path: "docs/notes.txt", SHA: "ccc"}
+	api := githubContent{Type: "file", Path: "docs/api/index.md", SHA: "ddd"}
+
+	// Changed files are fetched again; the stub answers with contents too large
+	// to inline, so they are counted as skipped without reaching RagFlow.
+	tooLarge := func(f githubContent) githubContent {
+		f.Encoding = "none"
+		return f
+	}
+
+	tests := []struct {
+		name          string
+		known         map[string]string // path -> SHA recorded by the last sync
+		wantUnchanged int
+		wantSkipped   int
+		wantFetched   []string
+		wantNotFetch  []string
+	}{
+		{
+			name:          "unchanged SHAs are not fetched",
+			known:         map[string]string{"README.md": "aaa", "docs/guide.md": "bbb", "docs/api/index.md": "ddd"},
+			wantUnchanged: 3,
+			wantNotFetch:  []string{"/repos/acme/widget/contents/docs/guide.md", "/repos/acme/widget/contents/docs/api/index.md"},
+		},
+		{
+			name:          "changed SHA is fetched again",
+			known:         map[string]string{"README.md": "aaa", "docs/guide.md": "old", "docs/api/index.md": "ddd"},
+			wantUnchanged: 2,
+			wantSkipped:   1,
+			wantFetched:   []string{"/repos/acme/widget/contents/docs/guide.md"},
+			wantNotFetch:  []string{"/repos/acme/widget/contents/docs/api/index.md"},
+		},
+		{
+			name:          "new files are fetched",
+			known:         map[string]string{"README.md": "aaa"},
+			wantUnchanged: 1,
+			wantSkipped:   2,
+			wantFetched:   []string{"/repos/acme/widget/contents/docs/guide.md", "/repos/acme/widget/contents/docs/api/index.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &githubStub{t: t, responses: map[string]interface{}{
+				"/repos/acme/widget/readme":                     readme,
+				"/repos/acme/widget/contents/docs":              []githubContent{guide, notes, {Type: "dir", Path: "docs/api"}},
+				"/repos/acme/widget/contents/docs/api":          []githubContent{api},
+				"/repos/acme/widget/contents/docs/guide.md":     tooLarge(guide),
+				"/repos/acme/widget/contents/docs/api/index.md": tooLarge(api),
+			}}
+			svc := newTestGitHubService(t, stub)
+
+			settings := testGitHubSettings
+			settings.Readme = true
+			settings.Docs = []string{"docs"}
+			known := map[string]*model.GitHubItem{}
+			for p, sha := range tt.known {
+				known[model.GitHubItemFile+":"+p] = &model.GitHubItem{Kind: model.GitHubItemFile, Ref: p, SHA: sha}
+			}
+
+			result := &GitHubSyncResult{}
+			source := &model.DataSource{ID: 1, Type: model.DataSourceTypeGitHub}
+			if err := svc.syncFiles(context.Background(), source, settings, known, result); err != nil {
+				t.Fatalf("syncFiles: %v", err)
+			}
+			if result.Unchanged != tt.wantUnchanged || result.Skipped != tt.wantSkipped || result.Errors != 0 {
+				t.Errorf("unchanged %d, skipped %d, errors %d; want %d, %d, 0",
+					result.Unchanged, result.Skipped, result.Errors, tt.wantUnchanged, tt.wantSkipped)
+			}
+			for _, p := range tt.wantFetched {
+				if !stub.wasRequested(p) {
+					t.Errorf("%s was not fetched", p)
+				}
+			}
+			for _, p := range tt.wantNotFetch {
+				if stub.wasRequested(p) {
+					t.Errorf("%s was fetched", p)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncFilesWithoutReadme(t *testing.T) {
+	stub := &githubStub{t: t, responses: map[string]interface{}{}}
+	svc := newTestGitHubService(t, stub)
+
+	settings := testGitHubSettings
+	settings.Readme = true
+	result := &GitHubSyncResult{}
+	source := &model.DataSource{ID: 1, Type: model.DataSourceTypeGitHub}
+	if err := svc.syncFiles(context.Background(), source, settings, map[string]*model.GitHubItem{}, result); err != nil {
+		t.Fatalf("syncFiles: %v", err)
+	}
+	if *result != (GitHubSyncResult{}) {
+		t.Errorf("result = %+v, want empty", *result)
+	}
+}
+
+func TestGitHubRateLimit(t *testing.T) {
+	reset := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		status    int
+		remaining string
+		wantErr   string
+	}{
+		{name: "403 with exhausted quota", status: http.StatusForbidden, remaining: "0", wantErr: "rate limit exceeded, resets at " + reset.Local().Format(time.RFC3339)},
+		{name: "429 with exhausted quota", status: http.StatusTooManyRequests, remaining: "0", wantErr: "rate limit exceeded"},
+		{name: "403 with quota left", status: http.StatusForbidden, remaining: "42", wantErr: "HTTP 403: Resource not accessible"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGitHubService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-RateLimit-Remaining", tt.remaining)
+				w.Header().Set("X-RateLimit-Reset", "1893553445")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"message":"Resource not accessible"}`))
+			}))
+
+			result := &GitHubSyncResult{}
+			source := &model.DataSource{ID: 1, Type: model.DataSourceTypeGitHub}
+			err := svc.syncReleases(context.Background(), source, testGitHubSettings, map[string]*model.GitHubItem{}, result)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if strings.Contains(tt.wantErr, "rate limit") && !strings.HasPrefix(err.Error(), "failed to list releases: ") {
+				t.Errorf("err = %v, want it wrapped by the release listing", err)
+			}
+		})
+	}
+}
@@ -6,17 +6,19 @@ import (
 	"time"
 
 	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
 	"github.com/singll/bellkeeper/internal/pkg/defaults"
 	"github.com/singll/bellkeeper/internal/repository"
 )
 
 type HealthService struct {
-	cfg      *config.Config
-	version  string
-	tagRepo  *repository.TagRepository
-	dsRepo   *repository.DataSourceRepository
-	rssRepo  *repository.RSSRepository
-	dataRepo *repository.DatasetMappingRepository
+	cfg        *config.Config
+	version    string
+	tagRepo    *repository.TagRepository
+	dsRepo     *repository.DataSourceRepository
+	statusRepo *repository.DataSourceStatusRepository
+	rssRepo    *repository.RSSRepository
+	dataRepo   *repository.DatasetMappingRepository
 }
 
 func NewHealthService(
@@ -24,16 +26,18 @@ func NewHealthService(
 	version string,
 	tagRepo *repository.TagRepository,
 	dsRepo *repository.DataSourceRepository,
+	statusRepo *repository.DataSourceStatusRepository,
 	rssRepo *repository.RSSRepository,
 	dataRepo *repository.DatasetMappingRepository,
 ) *HealthService {
 	return &HealthService{
-		cfg:      cfg,
-		version:  version,
-		tagRepo:  tagRepo,
-		dsRepo:   dsRepo,
-		rssRepo:  rssRepo,
-		dataRepo: dataRepo,
+		cfg:        cfg,
+		version:    version,
+		tagRepo:    tagRepo,
+		dsRepo:     dsRepo,
+		statusRepo: statusRepo,
+		rssRepo:    rssRepo,
+		dataRepo:   dataRepo,
 	}
 }
 
@@ -92,10 +96,26 @@ func (s *HealthService) Detailed() *DetailedHealth {
 		}
 	}
 
+	if s.statusRepo != nil {
+		if counts, err := s.statusRepo.CountByState(); err == nil {
+			metrics["datasources_up"] = counts[model.ReachabilityUp]
+			metrics["datasources_moved"] = counts[model.ReachabilityMoved]
+			metrics["datasources_failing"] = counts[model.ReachabilityFailing]
+			metrics["datasources_dead"] = counts[model.ReachabilityDead]
+		}
+		expiring := time.Now().AddDate(0, 0, defaults.TLSExpiryWarningDays)
+		if count, err := s.statusRepo.CountTLSExpiring(expiring); err == nil {
+			metrics["datasources_tls_expiring"] = count
+		}
+	}
+
 	if s.rssRepo != nil {
-		if _, total, _ := s.rssRepo.List(1, 1, "", ""); total > 0 {
+		if _, total, _ := s.rssRepo.List(1, 1, "", "", "", defaults.FeedUnhealthyFailures); total > 0 {
 			metrics["rss_feeds_count"] = total
 		}
+		if _, unhealthy, err := s.rssRepo.List(1, 1, "", "", "unhealthy", defaults.FeedUnhealthyFailures); err == nil {
+			metrics["rss_feeds_unhealthy"] = unhealthy
+		}
 	}
 
 	if s.dataRepo != nil {
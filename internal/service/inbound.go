@@ -0,0 +1,519 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/jsonpath"
+	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidInboundHook wraps validation errors of an inbound hook's slug, authentication or mapping.
+	ErrInvalidInboundHook = errors.New("invalid inbound hook")
+
+	// ErrInboundSlugTaken is returned when an inbound hook is saved under the slug of another one.
+	ErrInboundSlugTaken = errors.New("inbound hook slug already in use")
+
+	// ErrInboundHookNotFound is returned for requests to unknown or inactive inbound hooks
+	ErrInboundHookNotFound = errors.New("inbound hook not found")
+
+	// ErrInboundUnauthorized is returned when a request carries no valid token or signature
+	ErrInboundUnauthorized = errors.New("invalid inbound hook token or signature")
+
+	// ErrInboundPayload wraps errors of payloads that are not JSON or map to an empty document
+	ErrInboundPayload = errors.New("invalid inbound payload")
+
+	// ErrInboundUpload wraps errors of uploading a received document to RagFlow
+	ErrInboundUpload = errors.New("inbound upload failed")
+)
+
+var inboundSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,99}$`)
+
+// InboundMapping says where the fields of a document are found in the
+// payloads of an inbound hook. Every field is either a path into the payload,
+// see package jsonpath, or, when it does not start with $, a literal value.
+// A selected array counts as its members. Title, URL and Category take the
+// first value a path selects, Content joins them and Tags collects them all.
+// Without Content the whole payload is stored as the document.
+type InboundMapping struct {
+	Title    string   `json:"title,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Content  string   `json:"content,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Category string   `json:"category,omitempty"`
+}
+
+// InboundResult is the outcome of a request to an inbound hook
+type InboundResult struct {
+	Status     string `json:"status"`
+	Title      string `json:"title,omitempty"`
+	URL        string `json:"url,omitempty"`
+	DocumentID string `json:"document_id,omitempty"`
+	DatasetID  string `json:"dataset_id,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// InboundHookService receives the payloads posted to inbound hooks and
+// uploads the documents they map to, with the same dataset routing and URL
+// deduplication as the other ingestion paths.
+type InboundHookService struct {
+	repo       *repository.InboundHookRepository
+	datasetSvc *DatasetService
+	ragflowSvc *RagFlowService
+	urlDedup   bool
+}
+
+func NewInboundHookService(repo *repository.InboundHookRepository, datasetSvc *DatasetService, ragflowSvc *RagFlowService, urlDedup bool) *InboundHookService {
+	return &InboundHookService{
+		repo:       repo,
+		datasetSvc: datasetSvc,
+		ragflowSvc: ragflowSvc,
+		urlDedup:   urlDedup,
+	}
+}
+
+func (s *InboundHookService) List(page, perPage int) ([]model.InboundHook, int64, error) {
+	hooks, total, err := s.repo.List(page, perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range hooks {
+		markInboundSecret(&hooks[i])
+	}
+	return hooks, total, nil
+}
+
+func (s *InboundHookService) GetByID(id uint) (*model.InboundHook, error) {
+	hook, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	markInboundSecret(hook)
+	return hook, nil
+}
+
+func (s *InboundHookService) Create(hook *model.InboundHook) error {
+	if err := s.prepare(hook); err != nil {
+		return err
+	}
+	if err := s.repo.Create(hook); err != nil {
+		return err
+	}
+	markInboundSecret(hook)
+	return nil
+}
+
+func (s *InboundHookService) Update(hook *model.InboundHook) error {
+	if err := s.prepare(hook); err != nil {
+		return err
+	}
+	if err := s.repo.Update(hook); err != nil {
+		return err
+	}
+	markInboundSecret(hook)
+	return nil
+}
+
+func (s *InboundHookService) Delete(id uint) error {
+	return s.repo.Delete(id)
+}
+
+// markInboundSecret flags inbound hooks that have a secret
+func markInboundSecret(hook *model.InboundHook) {
+	hook.HasSecret = hook.Secret != ""
+}
+
+// prepare validates an inbound hook before it is stored, normalizing its
+// slug and mapping.
+func (s *InboundHookService) prepare(hook *model.InboundHook) error {
+	hook.Name = strings.TrimSpace(hook.Name)
+	hook.Slug = strings.ToLower(strings.TrimSpace(hook.Slug))
+	hook.SignatureHeader = strings.TrimSpace(hook.SignatureHeader)
+	hook.TimestampHeader = strings.TrimSpace(hook.TimestampHeader)
+	if hook.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidInboundHook)
+	}
+	if !inboundSlugPattern.MatchString(hook.Slug) {
+		return fmt.Errorf("%w: slug must be 1-100 lowercase letters, digits, - or _", ErrInvalidInboundHook)
+	}
+
+	if hook.AuthType == "" {
+		hook.AuthType = model.InboundAuthToken
+	}
+	if hook.AuthType != model.InboundAuthToken && hook.AuthType != model.InboundAuthHMAC {
+		return fmt.Errorf("%w: unknown auth type %q", ErrInvalidInboundHook, hook.AuthType)
+	}
+	if hook.Secret == "" {
+		return fmt.Errorf("%w: secret is required", ErrInvalidInboundHook)
+	}
+	if hook.TimestampHeader != "" && hook.AuthType != model.InboundAuthHMAC {
+		return fmt.Errorf("%w: timestamp_header requires hmac auth", ErrInvalidInboundHook)
+	}
+
+	var mapping InboundMapping
+	if err := decodeParams(json.RawMessage(hook.Mapping), &mapping); err != nil {
+		return fmt.Errorf("%w: mapping: %v", ErrInvalidInboundHook, err)
+	}
+	if _, err := newInboundMapper(mapping); err != nil {
+		return fmt.Errorf("%w: mapping: %v", ErrInvalidInboundHook, err)
+	}
+	data, err := json.Marshal(mapping)
+	if err != nil {
+		return err
+	}
+	hook.Mapping = data
+
+	existing, err := s.repo.GetBySlug(hook.Slug)
+	if err == nil && existing.ID != hook.ID {
+		return ErrInboundSlugTaken
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Receive authenticates a request to the inbound hook with the given slug,
+// maps its payload to a document and uploads it. Token hooks are
+// authenticated by the token, HMAC hooks by the signature header. The outcome
+// of authenticated requests is recorded on the hook; a failed upload returns
+// the result along with an ErrInboundUpload error.
+func (s *InboundHookService) Receive(slug, token string, header http.Header, body []byte) (*InboundResult, error) {
+	hook, err := s.repo.GetBySlug(slug)
+	if err != nil || !hook.IsActive {
+		return nil, ErrInboundHookNotFound
+	}
+	if !authenticateInbound(hook, token, header, body, time.Now()) {
+		return nil, ErrInboundUnauthorized
+	}
+
+	req, err := mapInboundPayload(hook, body)
+	if err != nil {
+		s.record(hook, &InboundResult{Status: model.InboundStatusFailed, Message: err.Error()})
+		return nil, err
+	}
+	result := s.ingest(req)
+	s.record(hook, result)
+	if result.Status == model.InboundStatusFailed {
+		return result, fmt.Errorf("%w: %s", ErrInboundUpload, result.Message)
+	}
+	return result, nil
+}
+
+// Preview maps a payload with the mapping of an inbound hook, without
+// authenticating or uploading it. Unknown hooks return ErrInboundHookNotFound.
+func (s *InboundHookService) Preview(id uint, body []byte) (*UploadRequest, error) {
+	hook, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInboundHookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mapInboundPayload(hook, body)
+}
+
+// ingest uploads a mapped document unless its URL was already ingested.
+func (s *InboundHookService) ingest(req *UploadRequest) *InboundResult {
+	result := &InboundResult{Title: req.Title, URL: req.URL}
+
+	if req.URL != "" && s.urlDedup {
+		check, err := s.datasetSvc.CheckURL(req.URL, true, false)
+		if err != nil {
+			result.Status = model.InboundStatusFailed
+			result.Message = "dedup check failed: " + err.Error()
+			return result
+		}
+		if check.Exists {
+			result.Status = model.InboundStatusDuplicate
+			result.Message = "already ingested as " + check.StoredURL
+			result.DocumentID = check.DocumentID
+			result.DatasetID = check.DatasetID
+			return result
+		}
+	}
+
+	resp, datasetID, err := s.ragflowSvc.UploadWithRouting(req)
+	result.DatasetID = datasetID
+	if err != nil {
+		result.Status = model.InboundStatusFailed
+		result.Message = err.Error()
+		return result
+	}
+	if resp.Code != 0 {
+		result.Status = model.InboundStatusFailed
+		result.Message = fmt.Sprintf("RagFlow error %d: %s", resp.Code, resp.Message)
+		return result
+	}
+
+	result.Status = model.InboundStatusIngested
+	if resp.Data != nil {
+		result.DocumentID, _ = resp.Data["id"].(string)
+	}
+	return result
+}
+
+// record stores the outcome of a request on its hook.
+func (s *InboundHookService) record(hook *model.InboundHook, result *InboundResult) {
+	if result.Status == model.InboundStatusFailed {
+		log.Printf("warn: inbound hook %d (%s) failed: %s", hook.ID, hook.Slug, result.Message)
+	}
+
+	now := time.Now()
+	hook.LastReceivedAt = &now
+	hook.LastStatus = result.Status
+	hook.LastError = ""
+	if result.Status == model.InboundStatusFailed {
+		hook.LastError = result.Message
+	}
+	hook.LastDocumentID = result.DocumentID
+	if err := s.repo.RecordReceipt(hook); err != nil {
+		log.Printf("warn: failed to record request to inbound hook %d: %v", hook.ID, err)
+	}
+}
+
+// authenticateInbound checks the token or the signature of a request. HMAC
+// hooks without a timestamp header sign the body only, so a captured request
+// stays valid.
+func authenticateInbound(hook *model.InboundHook, token string, header http.Header, body []byte, now time.Time) bool {
+	if hook.Secret == "" {
+		return false
+	}
+	if hook.AuthType == model.InboundAuthHMAC {
+		name := hook.SignatureHeader
+		if name == "" {
+			name = defaults.DefaultInboundSignatureHeader
+		}
+		signature := strings.TrimSpace(header.Get(name))
+		if signature == "" {
+			return false
+		}
+		if hook.TimestampHeader != "" {
+			return validTimestampedSignature(hook.Secret, header.Get(hook.TimestampHeader), signature, body, now)
+		}
+		// A bare hex digest is an HMAC-SHA256
+		if !strings.Contains(signature, "=") {
+			signature = "sha256=" + signature
+		}
+		return validHubSignature(hook.Secret, signature, body)
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(hook.Secret)) == 1
+}
+
+// validTimestampedSignature checks a signature made like SignWebhook, over the
+// timestamp, a dot and the body, and that the timestamp (Unix seconds) is
+// within InboundTimestampTolerance of now. The signature may carry a v1= or
+// sha256= prefix.
+func validTimestampedSignature(secret, timestamp, signature string, body []byte, now time.Time) bool {
+	timestamp = strings.TrimSpace(timestamp)
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	tolerance := defaults.InboundTimestampTolerance * time.Second
+	if skew := now.Sub(time.Unix(sec, 0)); skew > tolerance || skew < -tolerance {
+		return false
+	}
+
+	if _, digest, ok := strings.Cut(signature, "="); ok {
+		signature = digest
+	}
+	_, expected, _ := strings.Cut(SignWebhook(secret, timestamp, body), "=")
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+// mapInboundPayload turns a JSON payload into the document described by the
+// mapping of a hook.
+func mapInboundPayload(hook *model.InboundHook, body []byte) (*UploadRequest, error) {
+	var mapping InboundMapping
+	if len(hook.Mapping) > 0 {
+		if err := json.Unmarshal(hook.Mapping, &mapping); err != nil {
+			return nil, fmt.Errorf("inbound hook %d has an invalid mapping: %v", hook.ID, err)
+		}
+	}
+	mapper, err := newInboundMapper(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("inbound hook %d has an invalid mapping: %v", hook.ID, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var payload interface{}
+	if err := dec.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInboundPayload, err)
+	}
+
+	title := firstValue(mapper.title.values(payload))
+	url := firstValue(mapper.url.values(payload))
+
+	var content string
+	if mapping.Content == "" {
+		indented, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInboundPayload, err)
+		}
+		content = "```json\n" + string(indented) + "\n```"
+	} else {
+		content = strings.TrimSpace(strings.Join(mapper.content.values(payload), "\n\n"))
+	}
+	if content == "" {
+		return nil, fmt.Errorf("%w: content %q is empty", ErrInboundPayload, mapping.Content)
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+	for _, field := range mapper.tags {
+		for _, tag := range field.values(payload) {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return &UploadRequest{
+		Content:        inboundDocument(hook, title, url, content),
+		Filename:       documentFilename(title, fmt.Sprintf("inbound-%s-%d", hook.Slug, time.Now().Unix())),
+		Title:          title,
+		URL:            url,
+		Tags:           tags,
+		Category:       firstValue(mapper.category.values(payload)),
+		AutoCreateTags: true,
+	}, nil
+}
+
+// inboundDocument renders a received document as the document uploaded to RagFlow.
+func inboundDocument(hook *model.InboundHook, title, url, body string) string {
+	var b strings.Builder
+	if title != "" {
+		b.WriteString("# " + title + "\n\n")
+	}
+	if url != "" {
+		b.WriteString("Source: " + url + "\n")
+	}
+	b.WriteString("Inbound hook: " + hook.Name + "\n")
+	b.WriteString("Received: " + time.Now().Format(time.RFC3339) + "\n")
+	b.WriteString("\n")
+	b.WriteString(body)
+	return b.String()
+}
+
+// inboundField is a field of an inbound mapping: a path, or a literal value
+// when path is nil.
+type inboundField struct {
+	path    *jsonpath.Path
+	literal string
+}
+
+type inboundMapper struct {
+	title, url, content, category inboundField
+	tags                          []inboundField
+}
+
+func newInboundMapper(mapping InboundMapping) (*inboundMapper, error) {
+	var m inboundMapper
+	var err error
+	if m.title, err = parseInboundField(mapping.Title); err != nil {
+		return nil, fmt.Errorf("title: %v", err)
+	}
+	if m.url, err = parseInboundField(mapping.URL); err != nil {
+		return nil, fmt.Errorf("url: %v", err)
+	}
+	if m.content, err = parseInboundField(mapping.Content); err != nil {
+		return nil, fmt.Errorf("content: %v", err)
+	}
+	if m.category, err = parseInboundField(mapping.Category); err != nil {
+		return nil, fmt.Errorf("category: %v", err)
+	}
+	for _, expr := range mapping.Tags {
+		field, err := parseInboundField(expr)
+		if err != nil {
+			return nil, fmt.Errorf("tags: %v", err)
+		}
+		m.tags = append(m.tags, field)
+	}
+	return &m, nil
+}
+
+func parseInboundField(expr string) (inboundField, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return inboundField{literal: expr}, nil
+	}
+	path, err := jsonpath.Parse(expr)
+	if err != nil {
+		return inboundField{}, err
+	}
+	return inboundField{path: path}, nil
+}
+
+// values returns the non-empty strings a field selects in a payload. Selected
+// arrays contribute their members.
+func (f inboundField) values(payload interface{}) []string {
+	if f.path == nil {
+		if f.literal == "" {
+			return nil
+		}
+		return []string{f.literal}
+	}
+
+	var out []string
+	for _, v := range f.path.Get(payload) {
+		if arr, ok := v.([]interface{}); ok {
+			for _, member := range arr {
+				if s := inboundString(member); s != "" {
+					out = append(out, s)
+				}
+			}
+			continue
+		}
+		if s := inboundString(v); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// inboundString renders a JSON value as text; objects keep their JSON form.
+func inboundString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(val)
+	case json.Number:
+		return val.String()
+	case bool:
+		if val {
+			return "true"
+		}
+		return "false"
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
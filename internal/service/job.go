@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrJobsDisabled is returned when a job is enqueued while the worker pool is disabled.
+var ErrJobsDisabled = errors.New("background jobs are disabled")
+
+// JobFunc runs one attempt of a job. Its payload is the JSON passed to
+// Enqueue; the returned value is stored as the job result. Returning an
+// error schedules a retry until the job runs out of attempts. Jobs that work
+// through a list of items report each one to progress.
+type JobFunc func(ctx context.Context, payload json.RawMessage, progress *JobProgress) (interface{}, error)
+
+// JobService stores jobs in the jobs table and runs them on a pool of workers.
+// Workers claim jobs with SELECT ... FOR UPDATE SKIP LOCKED, so the queue
+// survives restarts and can be shared by several processes.
+type JobService struct {
+	cfg   config.JobsConfig
+	repo  *repository.JobRepository
+	funcs map[string]JobFunc
+	wake  chan struct{}
+
+	// subs holds the event subscribers of each job
+	subsMu sync.Mutex
+	subs   map[uint]map[chan JobEvent]struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewJobService(cfg config.JobsConfig, repo *repository.JobRepository) *JobService {
+	return &JobService{
+		cfg:   cfg,
+		repo:  repo,
+		funcs: make(map[string]JobFunc),
+		wake:  make(chan struct{}, 1),
+		subs:  make(map[uint]map[chan JobEvent]struct{}),
+	}
+}
+
+// Register sets the function that runs jobs of the given type. It must be
+// called before Start.
+func (s *JobService) Register(jobType string, fn JobFunc) {
+	s.funcs[jobType] = fn
+}
+
+// Enqueue stores a job that runs as soon as a worker is free.
+func (s *JobService) Enqueue(jobType string, payload interface{}) (*model.Job, error) {
+	if !s.cfg.Enabled {
+		return nil, ErrJobsDisabled
+	}
+	if _, ok := s.funcs[jobType]; !ok {
+		return nil, fmt.Errorf("unknown job type %q", jobType)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.Job{
+		Type:        jobType,
+		Status:      model.JobStatusQueued,
+		Payload:     data,
+		MaxAttempts: s.cfg.MaxAttempts,
+		RunAt:       time.Now(),
+	}
+	if job.MaxAttempts < 1 {
+		job.MaxAttempts = 1
+	}
+	if err := s.repo.Create(job); err != nil {
+		return nil, err
+	}
+
+	// Wake an idle worker instead of waiting for the next poll
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+func (s *JobService) List(page, perPage int, jobType, status string) ([]model.Job, int64, error) {
+	return s.repo.List(page, perPage, jobType, status)
+}
+
+func (s *JobService) GetByID(id uint) (*model.Job, error) {
+	return s.repo.GetByID(id)
+}
+
+// Start launches the workers and the cleanup of finished jobs. It is a no-op when jobs are disabled.
+func (s *JobService) Start() {
+	if !s.cfg.Enabled {
+		log.Println("Background jobs disabled by configuration")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	workers := s.cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.work(ctx)
+	}
+	s.wg.Add(1)
+	go s.cleanup(ctx)
+	log.Printf("Background jobs started (%d workers)", workers)
+}
+
+// Stop cancels running jobs and waits for the workers to exit. A job that
+// returns the context error is queued again without using up an attempt;
+// batch jobs store the items they had not attempted yet, so that they resume
+// with those.
+func (s *JobService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	log.Println("Background jobs stopped")
+}
+
+func (s *JobService) work(ctx context.Context) {
+	defer s.wg.Done()
+
+	interval := time.Duration(s.cfg.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	for ctx.Err() == nil {
+		now := time.Now()
+		job, err := s.repo.Claim(now, now.Add(-2*s.timeout()))
+		if err == nil {
+			s.publish(jobStatusEvent(job))
+			s.execute(ctx, job)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("warn: job worker failed to claim a job: %v", err)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// execute runs one attempt of a claimed job and stores the outcome.
+func (s *JobService) execute(ctx context.Context, job *model.Job) {
+	result, err := s.run(ctx, job)
+	now := time.Now()
+
+	switch {
+	case err == nil:
+		job.Status = model.JobStatusSucceeded
+		job.Error = ""
+		job.FinishedAt = &now
+		if data, marshalErr := json.Marshal(result); marshalErr == nil {
+			job.Result = data
+		} else {
+			log.Printf("warn: job %d result could not be stored: %v", job.ID, marshalErr)
+		}
+	case ctx.Err() != nil:
+		// Shutting down: give the attempt back
+		job.Status = model.JobStatusQueued
+		job.Attempts--
+		job.RunAt = now
+		job.Error = "interrupted by shutdown"
+	case job.Attempts < job.MaxAttempts:
+		job.Status = model.JobStatusQueued
+		job.RunAt = now.Add(s.backoff(job.Attempts))
+		job.Error = err.Error()
+		log.Printf("warn: job %d (%s) attempt %d/%d failed, retrying at %s: %v",
+			job.ID, job.Type, job.Attempts, job.MaxAttempts, job.RunAt.Format(time.RFC3339), err)
+	default:
+		job.Status = model.JobStatusFailed
+		job.Error = err.Error()
+		job.FinishedAt = &now
+		log.Printf("warn: job %d (%s) failed after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+	}
+
+	if err := s.repo.Save(job); err != nil {
+		log.Printf("warn: failed to store job %d: %v", job.ID, err)
+	}
+	s.publish(jobStatusEvent(job))
+}
+
+// run calls the job function with the attempt timeout, turning a panic into an error.
+func (s *JobService) run(ctx context.Context, job *model.Job) (result interface{}, err error) {
+	fn, ok := s.funcs[job.Type]
+	if !ok {
+		// Retrying cannot help
+		job.Attempts = job.MaxAttempts
+		return nil, fmt.Errorf("unknown job type %q", job.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return fn(ctx, json.RawMessage(job.Payload), &JobProgress{svc: s, job: job})
+}
+
+// backoff returns the delay before the retry that follows the given attempt,
+// doubling from the configured retry delay.
+func (s *JobService) backoff(attempt int) time.Duration {
+	delay := time.Duration(s.cfg.RetryDelay) * time.Second
+	if delay <= 0 {
+		delay = 30 * time.Second
+	}
+	limit := time.Duration(defaults.MaxJobBackoffMinutes) * time.Minute
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+func (s *JobService) timeout() time.Duration {
+	if s.cfg.Timeout <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(s.cfg.Timeout) * time.Minute
+}
+
+// cleanup deletes finished jobs older than the retention period once an hour.
+func (s *JobService) cleanup(ctx context.Context) {
+	defer s.wg.Done()
+	if s.cfg.RetentionDays <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		before := time.Now().AddDate(0, 0, -s.cfg.RetentionDays)
+		if n, err := s.repo.DeleteFinishedBefore(before); err != nil {
+			log.Printf("warn: failed to delete finished jobs: %v", err)
+		} else if n > 0 {
+			log.Printf("Deleted %d finished jobs older than %d days", n, s.cfg.RetentionDays)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+)
+
+// Job event types
+const (
+	JobEventStatus   = "status"
+	JobEventProgress = "progress"
+	JobEventItem     = "item"
+)
+
+// JobEvent is a change of a job streamed to clients watching it. Every event
+// carries the current status and item counters; item events also carry the
+// outcome of one item, shaped like an entry of the job's results.
+type JobEvent struct {
+	Type   string                 `json:"type"`
+	JobID  uint                   `json:"job_id"`
+	Status string                 `json:"status"`
+	Total  int                    `json:"total"`
+	Done   int                    `json:"done"`
+	Failed int                    `json:"failed"`
+	Error  string                 `json:"error,omitempty"`
+	Item   map[string]interface{} `json:"item,omitempty"`
+}
+
+// Finished reports whether the event is the last one of its job.
+func (e JobEvent) Finished() bool {
+	return e.Type == JobEventStatus &&
+		(e.Status == model.JobStatusSucceeded || e.Status == model.JobStatusFailed)
+}
+
+func jobStatusEvent(job *model.Job) JobEvent {
+	return JobEvent{
+		Type:   JobEventStatus,
+		JobID:  job.ID,
+		Status: job.Status,
+		Total:  job.Total,
+		Done:   job.Done,
+		Failed: job.Failed,
+		Error:  job.Error,
+	}
+}
+
+// JobProgress counts the items of a running job, stores the counters on the
+// job and publishes an event for each item. A nil *JobProgress ignores all
+// calls, so operations that report progress can also run outside a job.
+type JobProgress struct {
+	svc *JobService
+	job *model.Job
+	mu  sync.Mutex
+}
+
+// SetTotal announces how many items the job works through and resets the counters.
+func (p *JobProgress) SetTotal(total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.job.Total = total
+	p.job.Done = 0
+	p.job.Failed = 0
+	p.store(JobEventProgress, nil)
+}
+
+// Item records the outcome of one item. Failed items count as done too.
+func (p *JobProgress) Item(item map[string]interface{}, ok bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.job.Done++
+	if !ok {
+		p.job.Failed++
+	}
+	p.store(JobEventItem, item)
+}
+
+// Done returns how many items the current attempt has worked through.
+func (p *JobProgress) Done() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.job.Done
+}
+
+// Resume reads the partial result that an interrupted attempt left with
+// Interrupt into v. It reports whether there was one.
+func (p *JobProgress) Resume(v interface{}) bool {
+	if p == nil || len(p.job.Result) == 0 {
+		return false
+	}
+	if err := json.Unmarshal(p.job.Result, v); err != nil {
+		log.Printf("warn: failed to read partial result of job %d: %v", p.job.ID, err)
+		return false
+	}
+	return true
+}
+
+// Interrupt replaces the payload of the job with one holding only the items
+// not attempted yet and stores the result of those that were, to be picked
+// up with Resume by the next attempt. It returns err, the context error that
+// stopped the job, so that the job is queued again.
+func (p *JobProgress) Interrupt(payload, partial interface{}, err error) error {
+	if p == nil {
+		return err
+	}
+	remaining, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		return fmt.Errorf("failed to store remaining items: %w", marshalErr)
+	}
+	result, marshalErr := json.Marshal(partial)
+	if marshalErr != nil {
+		return fmt.Errorf("failed to store partial result: %w", marshalErr)
+	}
+	p.job.Payload = remaining
+	p.job.Result = result
+	return err
+}
+
+func (p *JobProgress) store(eventType string, item map[string]interface{}) {
+	if err := p.svc.repo.UpdateProgress(p.job); err != nil {
+		log.Printf("warn: failed to store progress of job %d: %v", p.job.ID, err)
+	}
+
+	event := jobStatusEvent(p.job)
+	event.Type = eventType
+	event.Item = item
+	p.svc.publish(event)
+}
+
+// Subscribe returns a channel receiving the events of a job published by this
+// process, and a function that ends the subscription. Events are dropped for
+// a subscriber that falls too far behind.
+func (s *JobService) Subscribe(id uint) (<-chan JobEvent, func()) {
+	ch := make(chan JobEvent, defaults.JobEventBuffer)
+
+	s.subsMu.Lock()
+	if s.subs[id] == nil {
+		s.subs[id] = make(map[chan JobEvent]struct{})
+	}
+	s.subs[id][ch] = struct{}{}
+	s.subsMu.Unlock()
+
+	return ch, func() {
+		s.subsMu.Lock()
+		delete(s.subs[id], ch)
+		if len(s.subs[id]) == 0 {
+			delete(s.subs, id)
+		}
+		s.subsMu.Unlock()
+	}
+}
+
+func (s *JobService) publish(event JobEvent) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+
+	for ch := range s.subs[event.JobID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Watch streams the events of a job, starting with its current state, until
+// the job finishes or ctx is done; the channel is closed then. Events of jobs
+// run by this process arrive as they happen. While none arrive the job row is
+// polled, so that jobs run by another process still report their status and
+// counters, though not their single items.
+func (s *JobService) Watch(ctx context.Context, id uint) (<-chan JobEvent, error) {
+	events, unsubscribe := s.Subscribe(id)
+
+	job, err := s.repo.GetByID(id)
+	if err != nil {
+		unsubscribe()
+		return nil, err
+	}
+
+	out := make(chan JobEvent)
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+
+		last := jobStatusEvent(job)
+		if !s.send(ctx, out, last) || last.Finished() {
+			return
+		}
+
+		ticker := time.NewTicker(defaults.JobEventPollSeconds * time.Second)
+		defer ticker.Stop()
+
+		quiet := true
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-events:
+				quiet = false
+				last = event
+				if !s.send(ctx, out, event) || event.Finished() {
+					return
+				}
+			case <-ticker.C:
+				if !quiet {
+					quiet = true
+					continue
+				}
+				job, err := s.repo.GetByID(id)
+				if err != nil {
+					log.Printf("warn: failed to poll job %d: %v", id, err)
+					return
+				}
+				event := jobStatusEvent(job)
+				if event.Status == last.Status && event.Total == last.Total &&
+					event.Done == last.Done && event.Failed == last.Failed {
+					continue
+				}
+				if event.Status == last.Status {
+					event.Type = JobEventProgress
+				}
+				last = event
+				if !s.send(ctx, out, event) || event.Finished() {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *JobService) send(ctx context.Context, out chan<- JobEvent, event JobEvent) bool {
+	select {
+	case out <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
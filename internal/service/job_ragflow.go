@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// RagFlow batch job types
+const (
+	JobTypeBatchUpload   = "ragflow.batch_upload"
+	JobTypeBatchDelete   = "ragflow.batch_delete"
+	JobTypeBatchTransfer = "ragflow.batch_transfer"
+)
+
+// BatchUploadJob is the payload of a ragflow.batch_upload job.
+type BatchUploadJob struct {
+	DatasetID string          `json:"dataset_id"`
+	Documents []UploadRequest `json:"documents"`
+}
+
+// BatchDeleteJob is the payload of a ragflow.batch_delete job.
+type BatchDeleteJob struct {
+	DatasetID   string   `json:"dataset_id"`
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// BatchTransferJob is the payload of a ragflow.batch_transfer job.
+type BatchTransferJob struct {
+	SourceDatasetID string   `json:"source_dataset_id"`
+	TargetDatasetID string   `json:"target_dataset_id"`
+	DocumentIDs     []string `json:"document_ids"`
+}
+
+// batchUploadResult is the result of a ragflow.batch_upload job.
+type batchUploadResult struct {
+	Results []map[string]interface{} `json:"results"`
+	Errors  []string                 `json:"errors"`
+}
+
+// batchDeleteResult is the result of a ragflow.batch_delete job.
+type batchDeleteResult struct {
+	Deleted []string `json:"deleted"`
+	Errors  []string `json:"errors"`
+}
+
+// batchTransferResult is the result of a ragflow.batch_transfer job.
+type batchTransferResult struct {
+	Total   int                      `json:"total"`
+	Success int                      `json:"success"`
+	Failed  int                      `json:"failed"`
+	Results []map[string]interface{} `json:"results"`
+}
+
+// RegisterJobs registers the batch operations as job types. The job result has
+// the shape of the synchronous response and every item is reported as
+// progress. A batch is retried only when every item failed, since items that
+// went through must not be repeated; partial failures are listed in the
+// result. The batches stop between items once ctx is done, so the attempt
+// timeout and shutdown interrupt them. An interrupted batch keeps the outcome
+// of the items it got through and is queued again with the rest.
+func (s *RagFlowService) RegisterJobs(jobs *JobService) {
+	jobs.Register(JobTypeBatchUpload, func(ctx context.Context, payload json.RawMessage, progress *JobProgress) (interface{}, error) {
+		var job BatchUploadJob
+		if err := json.Unmarshal(payload, &job); err != nil {
+			return nil, err
+		}
+		var result batchUploadResult
+		progress.Resume(&result)
+
+		results, errs := s.BatchUpload(ctx, job.DatasetID, job.Documents, progress)
+		if done := progress.Done(); ctx.Err() != nil && done < len(job.Documents) {
+			// The last error lists the documents left out
+			result.Results = append(result.Results, results...)
+			result.Errors = append(result.Errors, errs[:len(errs)-1]...)
+			job.Documents = job.Documents[done:]
+			return nil, progress.Interrupt(job, result, ctx.Err())
+		}
+		if len(job.Documents) > 0 && len(results) == 0 {
+			return nil, errors.New(firstOr(errs, "all uploads failed"))
+		}
+		result.Results = append(result.Results, results...)
+		result.Errors = append(result.Errors, errs...)
+		return result, nil
+	})
+
+	jobs.Register(JobTypeBatchDelete, func(ctx context.Context, payload json.RawMessage, progress *JobProgress) (interface{}, error) {
+		var job BatchDeleteJob
+		if err := json.Unmarshal(payload, &job); err != nil {
+			return nil, err
+		}
+		var result batchDeleteResult
+		progress.Resume(&result)
+
+		deleted, errs := s.BatchDeleteDocuments(ctx, job.DatasetID, job.DocumentIDs, progress)
+		if done := progress.Done(); ctx.Err() != nil && done < len(job.DocumentIDs) {
+			// The last error lists the documents left out
+			result.Deleted = append(result.Deleted, deleted...)
+			result.Errors = append(result.Errors, errs[:len(errs)-1]...)
+			job.DocumentIDs = job.DocumentIDs[done:]
+			return nil, progress.Interrupt(job, result, ctx.Err())
+		}
+		if len(job.DocumentIDs) > 0 && len(deleted) == 0 {
+			return nil, errors.New(firstOr(errs, "all deletions failed"))
+		}
+		result.Deleted = append(result.Deleted, deleted...)
+		result.Errors = append(result.Errors, errs...)
+		return result, nil
+	})
+
+	jobs.Register(JobTypeBatchTransfer, func(ctx context.Context, payload json.RawMessage, progress *JobProgress) (interface{}, error) {
+		var job BatchTransferJob
+		if err := json.Unmarshal(payload, &job); err != nil {
+			return nil, err
+		}
+		var result batchTransferResult
+		if !progress.Resume(&result) {
+			result.Total = len(job.DocumentIDs)
+		}
+
+		summary, err := s.BatchTransferDocuments(ctx, job.SourceDatasetID, job.TargetDatasetID, job.DocumentIDs, progress)
+		if err != nil {
+			return nil, err
+		}
+		success, _ := summary["success"].(int)
+		failed, _ := summary["failed"].(int)
+		results, _ := summary["results"].([]map[string]interface{})
+		result.Success += success
+		result.Failed += failed
+		result.Results = append(result.Results, results...)
+
+		if done := progress.Done(); ctx.Err() != nil && done < len(job.DocumentIDs) {
+			job.DocumentIDs = job.DocumentIDs[done:]
+			return nil, progress.Interrupt(job, result, ctx.Err())
+		}
+		if len(job.DocumentIDs) > 0 && success == 0 {
+			return nil, errors.New("all transfers failed")
+		}
+		return result, nil
+	})
+}
+
+// firstOr returns the first message, or fallback when there is none.
+func firstOr(messages []string, fallback string) string {
+	if len(messages) > 0 {
+		return messages[0]
+	}
+	return fallback
+}
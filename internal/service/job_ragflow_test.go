@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// roundTripFunc answers HTTP requests in process.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newDryRunDB returns a database that builds statements without connecting
+// to Postgres, for services whose writes the tests do not read back.
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open dry-run database: %v", err)
+	}
+	return db
+}
+
+func TestBatchUploadJobResumesAfterShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var uploaded []string
+	ragflow := &RagFlowService{
+		cfg: config.RagFlowConfig{BaseURL: "http://ragflow.test"},
+		client: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			var doc struct {
+				Name string `json:"name"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+				t.Errorf("decode upload: %v", err)
+			}
+			mu.Lock()
+			uploaded = append(uploaded, doc.Name)
+			if len(uploaded) == 2 {
+				// Shut down while the batch is halfway through
+				cancel()
+			}
+			mu.Unlock()
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"code":0,"data":{"id":"id-` + doc.Name + `"}}`)),
+			}, nil
+		})},
+	}
+	jobs := NewJobService(config.JobsConfig{Enabled: true, MaxAttempts: 3}, repository.NewJobRepository(newDryRunDB(t)))
+	ragflow.RegisterJobs(jobs)
+
+	payload, err := json.Marshal(BatchUploadJob{DatasetID: "ds", Documents: []UploadRequest{
+		{Filename: "a.md"}, {Filename: "b.md"}, {Filename: "c.md"}, {Filename: "d.md"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := &model.Job{ID: 1, Type: JobTypeBatchUpload, Status: model.JobStatusRunning, Payload: payload, Attempts: 1, MaxAttempts: 3}
+
+	jobs.execute(ctx, job)
+	if job.Status != model.JobStatusQueued || job.Attempts != 0 {
+		t.Fatalf("status %s, attempts %d; want queued, 0", job.Status, job.Attempts)
+	}
+	if got := strings.Join(uploaded, ","); got != "a.md,b.md" {
+		t.Fatalf("uploaded %s before shutdown, want a.md,b.md", got)
+	}
+
+	var remaining BatchUploadJob
+	if err := json.Unmarshal(job.Payload, &remaining); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if got := uploadFilenames(remaining.Documents); got != "c.md,d.md" || remaining.DatasetID != "ds" {
+		t.Errorf("queued again with %s in %q, want c.md,d.md in ds", got, remaining.DatasetID)
+	}
+	var partial batchUploadResult
+	if err := json.Unmarshal(job.Result, &partial); err != nil {
+		t.Fatalf("partial result: %v", err)
+	}
+	if len(partial.Results) != 2 || len(partial.Errors) != 0 {
+		t.Errorf("partial result has %d results, %d errors; want 2, 0", len(partial.Results), len(partial.Errors))
+	}
+
+	// The next attempt uploads the rest and keeps the earlier results
+	job.Status = model.JobStatusRunning
+	job.Attempts++
+	jobs.execute(context.Background(), job)
+	if job.Status != model.JobStatusSucceeded {
+		t.Fatalf("status %s (%s), want succeeded", job.Status, job.Error)
+	}
+	if got := strings.Join(uploaded, ","); got != "a.md,b.md,c.md,d.md" {
+		t.Errorf("uploaded %s, want every document once", got)
+	}
+	var result batchUploadResult
+	if err := json.Unmarshal(job.Result, &result); err != nil {
+		t.Fatalf("result: %v", err)
+	}
+	var names []string
+	for _, r := range result.Results {
+		names = append(names, r["filename"].(string))
+	}
+	if got := strings.Join(names, ","); got != "a.md,b.md,c.md,d.md" {
+		t.Errorf("result lists %s, want all four documents", got)
+	}
+}
+
+func uploadFilenames(docs []UploadRequest) string {
+	names := make([]string, len(docs))
+	for i, doc := range docs {
+		names[i] = doc.Filename
+	}
+	return strings.Join(names, ",")
+}
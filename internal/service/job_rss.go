@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// JobTypeFeedFetch fetches a single RSS feed.
+const JobTypeFeedFetch = "rss.fetch"
+
+// FeedFetchJob is the payload of an rss.fetch job.
+type FeedFetchJob struct {
+	FeedID uint `json:"feed_id"`
+}
+
+// RegisterJobs registers fetching a feed as a job type. The job result is the
+// FetchResult and every feed item is reported as progress. A failed fetch is
+// retried; entries are keyed by GUID, so a retry does not duplicate them.
+func (f *RSSFetcher) RegisterJobs(jobs *JobService) {
+	jobs.Register(JobTypeFeedFetch, func(ctx context.Context, payload json.RawMessage, progress *JobProgress) (interface{}, error) {
+		var job FeedFetchJob
+		if err := json.Unmarshal(payload, &job); err != nil {
+			return nil, err
+		}
+		rssFeed, err := f.repo.GetByID(job.FeedID)
+		if err != nil {
+			return nil, err
+		}
+		return f.Fetch(ctx, rssFeed, progress)
+	})
+}
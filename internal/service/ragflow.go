@@ -2,6 +2,7 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -21,14 +22,16 @@ type RagFlowService struct {
 	cfg         config.RagFlowConfig
 	datasetRepo *repository.DatasetMappingRepository
 	tagRepo     *repository.TagRepository
+	events      *EventBus
 	client      *http.Client
 }
 
-func NewRagFlowService(cfg config.RagFlowConfig, datasetRepo *repository.DatasetMappingRepository, tagRepo *repository.TagRepository) *RagFlowService {
+func NewRagFlowService(cfg config.RagFlowConfig, datasetRepo *repository.DatasetMappingRepository, tagRepo *repository.TagRepository, events *EventBus) *RagFlowService {
 	return &RagFlowService{
 		cfg:         cfg,
 		datasetRepo: datasetRepo,
 		tagRepo:     tagRepo,
+		events:      events,
 		client:      &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
 	}
 }
@@ -61,7 +64,7 @@ func (s *RagFlowService) Upload(req *UploadRequest) (*UploadResponse, error) {
 		datasetID = defaultMapping.DatasetID
 	}
 
-	return s.uploadToRagFlow(datasetID, req.Filename, req.Content)
+	return s.upload(context.Background(), datasetID, req)
 }
 
 // UploadWithRouting uploads with intelligent dataset routing based on tags/category
@@ -78,6 +81,7 @@ func (s *RagFlowService) UploadWithRouting(req *UploadRequest) (*UploadResponse,
 				if err := s.tagRepo.Create(tag); err != nil {
 					return nil, "", fmt.Errorf("failed to create tag %q: %w", tagName, err)
 				}
+				s.events.Publish(tagCreatedEvent(tag))
 			}
 			tagIDs = append(tagIDs, tag.ID)
 		}
@@ -108,7 +112,7 @@ func (s *RagFlowService) UploadWithRouting(req *UploadRequest) (*UploadResponse,
 	}
 
 	// Upload to RagFlow
-	resp, err := s.uploadToRagFlow(datasetID, req.Filename, req.Content)
+	resp, err := s.upload(context.Background(), datasetID, req)
 	if err != nil {
 		return nil, datasetID, err
 	}
@@ -183,7 +187,30 @@ func (s *RagFlowService) CheckURLEnhanced(rawURL string, normalize bool) (map[st
 	return map[string]interface{}{"exists": false}, nil
 }
 
-func (s *RagFlowService) uploadToRagFlow(datasetID, filename, content string) (*UploadResponse, error) {
+// upload uploads a document to a dataset and announces it once RagFlow accepted
+// it. The title, URL, tags and category of doc describe the document in the event.
+func (s *RagFlowService) upload(ctx context.Context, datasetID string, doc *UploadRequest) (*UploadResponse, error) {
+	resp, err := s.uploadToRagFlow(ctx, datasetID, doc.Filename, doc.Content)
+	if err != nil || resp.Code != 0 {
+		return resp, err
+	}
+
+	documentID, _ := resp.Data["id"].(string)
+	event := NewEvent(EventDocumentUploaded, map[string]interface{}{
+		"document_id": documentID,
+		"dataset_id":  datasetID,
+		"filename":    doc.Filename,
+		"title":       doc.Title,
+		"url":         doc.URL,
+	})
+	event.Tags = doc.Tags
+	event.Category = doc.Category
+	event.DatasetID = datasetID
+	s.events.Publish(event)
+	return resp, nil
+}
+
+func (s *RagFlowService) uploadToRagFlow(ctx context.Context, datasetID, filename, content string) (*UploadResponse, error) {
 	url := fmt.Sprintf("%s/api/v1/datasets/%s/documents", s.cfg.BaseURL, datasetID)
 
 	payload := map[string]interface{}{
@@ -196,7 +223,7 @@ func (s *RagFlowService) uploadToRagFlow(datasetID, filename, content string) (*
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -230,10 +257,18 @@ func (s *RagFlowService) ListDocuments(datasetID string, page, limit int) (map[s
 }
 
 // DeleteDocument deletes a document from RagFlow
-func (s *RagFlowService) DeleteDocument(datasetID, documentID string) error {
+func (s *RagFlowService) DeleteDocument(ctx context.Context, datasetID, documentID string) error {
+	title, docURL, tags := s.documentInfo(documentID)
+	return s.deleteDocument(ctx, datasetID, documentID, title, docURL, tags)
+}
+
+// deleteDocument deletes a document and publishes a document.deleted event
+// with the given title, URL and tags, which callers that move the document's
+// article tags look up beforehand.
+func (s *RagFlowService) deleteDocument(ctx context.Context, datasetID, documentID, title, docURL string, tags []string) error {
 	url := fmt.Sprintf("%s/api/v1/datasets/%s/documents/%s", s.cfg.BaseURL, datasetID, documentID)
 
-	req, err := http.NewRequest("DELETE", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return err
 	}
@@ -251,9 +286,35 @@ func (s *RagFlowService) DeleteDocument(datasetID, documentID string) error {
 		return fmt.Errorf("delete failed: %s", string(body))
 	}
 
+	event := NewEvent(EventDocumentDeleted, map[string]interface{}{
+		"document_id": documentID,
+		"dataset_id":  datasetID,
+		"title":       title,
+		"url":         docURL,
+	})
+	event.Tags = tags
+	event.DatasetID = datasetID
+	s.events.Publish(event)
 	return nil
 }
 
+// documentInfo returns the title, URL and tag names recorded for a document
+// by its article-tag associations, which are empty for untagged documents.
+func (s *RagFlowService) documentInfo(documentID string) (title, docURL string, tags []string) {
+	ats, err := s.datasetRepo.GetArticleTagsByDocumentID(documentID)
+	if err != nil {
+		log.Printf("warn: failed to look up tags of document %s: %v", documentID, err)
+		return "", "", nil
+	}
+	for _, at := range ats {
+		title, docURL = at.ArticleTitle, at.ArticleURL
+		if at.Tag.Name != "" {
+			tags = append(tags, at.Tag.Name)
+		}
+	}
+	return title, docURL, tags
+}
+
 // --- Batch B: RagFlow 高级操作 ---
 
 // ListDatasets lists all RagFlow datasets (knowledge bases)
@@ -310,36 +371,55 @@ func (s *RagFlowService) GetParsingStatus(datasetID, documentID string) (map[str
 	return s.doGet(url)
 }
 
-// BatchUpload uploads multiple documents to a dataset
-func (s *RagFlowService) BatchUpload(datasetID string, documents []UploadRequest) ([]map[string]interface{}, []string) {
+// BatchUpload uploads multiple documents to a dataset, reporting each one to
+// progress (which may be nil). Once ctx is done the remaining documents are
+// left out and reported as one error.
+func (s *RagFlowService) BatchUpload(ctx context.Context, datasetID string, documents []UploadRequest, progress *JobProgress) ([]map[string]interface{}, []string) {
 	var results []map[string]interface{}
 	var errors []string
 
-	for _, doc := range documents {
-		resp, err := s.uploadToRagFlow(datasetID, doc.Filename, doc.Content)
+	progress.SetTotal(len(documents))
+	for i, doc := range documents {
+		if err := ctx.Err(); err != nil {
+			errors = append(errors, notAttempted(len(documents)-i, len(documents), "documents", err))
+			break
+		}
+		resp, err := s.upload(ctx, datasetID, &doc)
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", doc.Filename, err))
+			progress.Item(map[string]interface{}{"filename": doc.Filename, "error": err.Error()}, false)
 			continue
 		}
-		results = append(results, map[string]interface{}{
+		result := map[string]interface{}{
 			"filename": doc.Filename,
 			"response": resp,
-		})
+		}
+		results = append(results, result)
+		progress.Item(result, true)
 	}
 
 	return results, errors
 }
 
-// BatchDeleteDocuments deletes multiple documents from a dataset
-func (s *RagFlowService) BatchDeleteDocuments(datasetID string, documentIDs []string) ([]string, []string) {
+// BatchDeleteDocuments deletes multiple documents from a dataset, reporting
+// each one to progress (which may be nil). Once ctx is done the remaining
+// documents are left out and reported as one error.
+func (s *RagFlowService) BatchDeleteDocuments(ctx context.Context, datasetID string, documentIDs []string, progress *JobProgress) ([]string, []string) {
 	var deleted []string
 	var errors []string
 
-	for _, docID := range documentIDs {
-		if err := s.DeleteDocument(datasetID, docID); err != nil {
+	progress.SetTotal(len(documentIDs))
+	for i, docID := range documentIDs {
+		if err := ctx.Err(); err != nil {
+			errors = append(errors, notAttempted(len(documentIDs)-i, len(documentIDs), "documents", err))
+			break
+		}
+		if err := s.DeleteDocument(ctx, datasetID, docID); err != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", docID, err))
+			progress.Item(map[string]interface{}{"document_id": docID, "error": err.Error()}, false)
 		} else {
 			deleted = append(deleted, docID)
+			progress.Item(map[string]interface{}{"document_id": docID}, true)
 		}
 	}
 
@@ -347,19 +427,26 @@ func (s *RagFlowService) BatchDeleteDocuments(datasetID string, documentIDs []st
 }
 
 // TransferDocument transfers a document from one dataset to another
-func (s *RagFlowService) TransferDocument(sourceDatasetID, targetDatasetID, documentID string) (map[string]interface{}, error) {
+func (s *RagFlowService) TransferDocument(ctx context.Context, sourceDatasetID, targetDatasetID, documentID string) (map[string]interface{}, error) {
 	downloadURL := fmt.Sprintf("%s/api/v1/datasets/%s/documents/%s/download", s.cfg.BaseURL, sourceDatasetID, documentID)
-	content, filename, err := s.downloadDocument(downloadURL)
+	content, filename, err := s.downloadDocument(ctx, downloadURL)
 	if err != nil {
 		return nil, fmt.Errorf("download failed: %w", err)
 	}
 
-	resp, err := s.uploadToRagFlow(targetDatasetID, filename, content)
+	title, docURL, tags := s.documentInfo(documentID)
+	resp, err := s.upload(ctx, targetDatasetID, &UploadRequest{
+		Filename: filename,
+		Content:  content,
+		Title:    title,
+		URL:      docURL,
+		Tags:     tags,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("upload to target failed: %w", err)
 	}
 
-	if err := s.DeleteDocument(sourceDatasetID, documentID); err != nil {
+	if err := s.deleteDocument(ctx, sourceDatasetID, documentID, title, docURL, tags); err != nil {
 		return map[string]interface{}{
 			"upload":        resp,
 			"delete_failed": true,
@@ -373,14 +460,22 @@ func (s *RagFlowService) TransferDocument(sourceDatasetID, targetDatasetID, docu
 	}, nil
 }
 
-// BatchTransferDocuments transfers multiple documents between datasets
-func (s *RagFlowService) BatchTransferDocuments(sourceDatasetID, targetDatasetID string, documentIDs []string) (map[string]interface{}, error) {
+// BatchTransferDocuments transfers multiple documents between datasets. Each
+// entry of results is reported to progress (which may be nil) as it completes.
+// Once ctx is done the remaining documents are left out and counted as skipped.
+func (s *RagFlowService) BatchTransferDocuments(ctx context.Context, sourceDatasetID, targetDatasetID string, documentIDs []string, progress *JobProgress) (map[string]interface{}, error) {
 	var results []map[string]interface{}
 	successCount := 0
 	failedCount := 0
+	skippedCount := 0
 
-	for _, docID := range documentIDs {
-		result, err := s.TransferDocument(sourceDatasetID, targetDatasetID, docID)
+	progress.SetTotal(len(documentIDs))
+	for i, docID := range documentIDs {
+		if ctx.Err() != nil {
+			skippedCount = len(documentIDs) - i
+			break
+		}
+		result, err := s.TransferDocument(ctx, sourceDatasetID, targetDatasetID, docID)
 		entry := map[string]interface{}{
 			"document_id": docID,
 		}
@@ -394,14 +489,25 @@ func (s *RagFlowService) BatchTransferDocuments(sourceDatasetID, targetDatasetID
 			successCount++
 		}
 		results = append(results, entry)
+		progress.Item(entry, err == nil)
 	}
 
-	return map[string]interface{}{
+	summary := map[string]interface{}{
 		"total":   len(documentIDs),
 		"success": successCount,
 		"failed":  failedCount,
 		"results": results,
-	}, nil
+	}
+	if skippedCount > 0 {
+		summary["skipped"] = skippedCount
+		summary["error"] = notAttempted(skippedCount, len(documentIDs), "documents", ctx.Err())
+	}
+	return summary, nil
+}
+
+// notAttempted describes the items a batch left out when its context ended.
+func notAttempted(remaining, total int, items string, err error) string {
+	return fmt.Sprintf("%d of %d %s not attempted: %v", remaining, total, items, err)
 }
 
 // UpdateDocumentMetadata updates document metadata
@@ -508,8 +614,8 @@ func (s *RagFlowService) doRequestJSON(method, url string, payload map[string]in
 	return result, nil
 }
 
-func (s *RagFlowService) downloadDocument(url string) (string, string, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (s *RagFlowService) downloadDocument(ctx context.Context, url string) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "", "", err
 	}
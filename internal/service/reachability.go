@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/urlutil"
+	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/gorm"
+)
+
+// maxReachabilityRedirects is how many redirects a reachability check follows.
+const maxReachabilityRedirects = 10
+
+// ReachabilityService periodically requests every active data source URL and
+// records status code, latency, redirect target and TLS certificate expiry.
+// Sources that are permanently redirected are flagged as moved; sources that
+// keep failing are flagged as dead.
+type ReachabilityService struct {
+	cfg        config.ReachabilityConfig
+	userAgent  string
+	repo       *repository.DataSourceRepository
+	statusRepo *repository.DataSourceStatusRepository
+	events     *EventBus
+	transport  http.RoundTripper
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewReachabilityService(
+	cfg config.ReachabilityConfig,
+	userAgent string,
+	repo *repository.DataSourceRepository,
+	statusRepo *repository.DataSourceStatusRepository,
+	events *EventBus,
+) *ReachabilityService {
+	return &ReachabilityService{
+		cfg:        cfg,
+		userAgent:  userAgent,
+		repo:       repo,
+		statusRepo: statusRepo,
+		events:     events,
+		transport:  http.DefaultTransport,
+	}
+}
+
+// Start launches the background check loop. It is a no-op when reachability checks are disabled.
+func (s *ReachabilityService) Start() {
+	if !s.cfg.Enabled {
+		log.Println("Data source reachability checks disabled by configuration")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.run(ctx)
+	log.Printf("Data source reachability checks started (poll interval %ds, check interval %dm)", s.cfg.PollInterval, s.cfg.Interval)
+}
+
+// Stop cancels running checks and waits for the loop to exit.
+func (s *ReachabilityService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	log.Println("Data source reachability checks stopped")
+}
+
+func (s *ReachabilityService) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	interval := time.Duration(s.cfg.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.checkDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkDue(ctx)
+		}
+	}
+}
+
+// checkDue checks every active data source whose check interval has elapsed.
+func (s *ReachabilityService) checkDue(ctx context.Context) {
+	sources, err := s.repo.GetDueForReachability(time.Now().Add(-time.Duration(s.cfg.Interval) * time.Minute))
+	if err != nil {
+		log.Printf("warn: reachability checker failed to load data sources: %v", err)
+		return
+	}
+	s.checkSources(ctx, sources)
+}
+
+// CheckAll checks every active data source right away, or only those listed
+// in ids, and returns how many sources ended up in each state. It runs even
+// when the periodic checks are disabled.
+func (s *ReachabilityService) CheckAll(ctx context.Context, ids []uint) (map[string]int, error) {
+	sources, err := s.repo.GetDueForReachability(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		wanted := make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			wanted[id] = true
+		}
+		selected := sources[:0]
+		for _, source := range sources {
+			if wanted[source.ID] {
+				selected = append(selected, source)
+			}
+		}
+		sources = selected
+	}
+
+	counts := s.checkSources(ctx, sources)
+	return counts, ctx.Err()
+}
+
+// checkSources checks the given data sources, bounded by the configured
+// concurrency, and counts the resulting states. Checks whose status could not
+// be stored are counted as "error".
+func (s *ReachabilityService) checkSources(ctx context.Context, sources []model.DataSource) map[string]int {
+	counts := make(map[string]int)
+	var mu sync.Mutex
+
+	concurrency := s.cfg.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i := range sources {
+		source := &sources[i]
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return counts
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			status, err := s.Check(ctx, source)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("warn: reachability check failed for data source %d: %v", source.ID, err)
+					mu.Lock()
+					counts["error"]++
+					mu.Unlock()
+				}
+				return
+			}
+			mu.Lock()
+			counts[status.State]++
+			mu.Unlock()
+			if status.State != model.ReachabilityUp {
+				log.Printf("Data source %d (%s) is %s: %s", source.ID, source.URL, status.State, reachabilityDetail(status))
+			}
+		}()
+	}
+	wg.Wait()
+	return counts
+}
+
+// GetStatus returns the latest reachability check of a data source
+func (s *ReachabilityService) GetStatus(dataSourceID uint) (*model.DataSourceStatus, error) {
+	return s.statusRepo.GetByDataSourceID(dataSourceID)
+}
+
+// Check requests the data source URL and records the outcome. The returned
+// error is only set when the status could not be stored; an unreachable URL
+// is a successful check with a failing or dead state. A source that turns
+// dead is announced as a datasource.down event.
+func (s *ReachabilityService) Check(ctx context.Context, source *model.DataSource) (*model.DataSourceStatus, error) {
+	status, err := s.statusRepo.GetByDataSourceID(source.ID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		status = &model.DataSourceStatus{DataSourceID: source.ID}
+	}
+
+	probe := s.probe(ctx, source.URL)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	previous := status.State
+
+	status.StatusCode = probe.statusCode
+	status.LatencyMs = probe.latency.Milliseconds()
+	status.FinalURL = truncateRunes(probe.finalURL, 2000)
+	status.TLSExpiresAt = probe.tlsExpiresAt
+	status.Error = ""
+	status.CheckedAt = time.Now()
+
+	switch {
+	case probe.err != nil || probe.statusCode >= 400:
+		status.ConsecutiveFailures++
+		if probe.err != nil {
+			status.Error = probe.err.Error()
+		} else {
+			status.Error = fmt.Sprintf("HTTP %d", probe.statusCode)
+		}
+		status.State = model.ReachabilityFailing
+		// A 410 needs no confirmation
+		if status.ConsecutiveFailures >= defaults.DataSourceDeadFailures || probe.statusCode == http.StatusGone {
+			status.State = model.ReachabilityDead
+		}
+	case probe.permanentRedirect && !sameLocation(probe.finalURL, source.URL):
+		status.ConsecutiveFailures = 0
+		status.State = model.ReachabilityMoved
+		status.LastUpAt = &status.CheckedAt
+	default:
+		status.ConsecutiveFailures = 0
+		status.State = model.ReachabilityUp
+		status.LastUpAt = &status.CheckedAt
+	}
+
+	if err := s.statusRepo.Save(status); err != nil {
+		return nil, err
+	}
+	if status.State == model.ReachabilityDead && previous != model.ReachabilityDead {
+		s.events.Publish(sourceDownEvent(source, status))
+	}
+	return status, nil
+}
+
+// sourceDownEvent describes a data source that turned dead; the event carries
+// the tags and category of the source
+func sourceDownEvent(source *model.DataSource, status *model.DataSourceStatus) Event {
+	event := NewEvent(EventDataSourceDown, map[string]interface{}{
+		"data_source_id":       source.ID,
+		"name":                 source.Name,
+		"url":                  source.URL,
+		"status_code":          status.StatusCode,
+		"error":                status.Error,
+		"consecutive_failures": status.ConsecutiveFailures,
+		"last_up_at":           status.LastUpAt,
+	})
+	event.Tags = tagNames(source.Tags)
+	event.Category = source.Category
+	return event
+}
+
+type reachabilityProbe struct {
+	statusCode        int
+	latency           time.Duration
+	finalURL          string
+	permanentRedirect bool // the first redirect was a 301 or 308
+	tlsExpiresAt      *time.Time
+	err               error
+}
+
+// probe sends a HEAD request and falls back to GET for servers that reject or
+// mishandle HEAD. The latency is that of the last request.
+func (s *ReachabilityService) probe(ctx context.Context, rawURL string) reachabilityProbe {
+	p := s.request(ctx, "HEAD", rawURL)
+	if p.err != nil || p.statusCode >= 400 {
+		if ctx.Err() == nil {
+			p = s.request(ctx, "GET", rawURL)
+		}
+	}
+	return p
+}
+
+func (s *ReachabilityService) request(ctx context.Context, method, rawURL string) reachabilityProbe {
+	var p reachabilityProbe
+	client := &http.Client{
+		Transport: s.transport,
+		Timeout:   time.Duration(s.cfg.Timeout) * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxReachabilityRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxReachabilityRedirects)
+			}
+			if len(via) == 1 && req.Response != nil {
+				code := req.Response.StatusCode
+				p.permanentRedirect = code == http.StatusMovedPermanently || code == http.StatusPermanentRedirect
+			}
+			return nil
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	p.latency = time.Since(start)
+	if err != nil {
+		p.err = err
+		return p
+	}
+	defer resp.Body.Close()
+	// Only the headers matter; drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	p.statusCode = resp.StatusCode
+	p.finalURL = resp.Request.URL.String()
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expires := resp.TLS.PeerCertificates[0].NotAfter
+		p.tlsExpiresAt = &expires
+	}
+	return p
+}
+
+// sameLocation reports whether two URLs differ only in ways Normalize ignores,
+// including the trailing slash of a bare host.
+func sameLocation(a, b string) bool {
+	return strings.TrimSuffix(urlutil.Normalize(a), "/") == strings.TrimSuffix(urlutil.Normalize(b), "/")
+}
+
+// reachabilityDetail describes why a data source is not up.
+func reachabilityDetail(status *model.DataSourceStatus) string {
+	if status.State == model.ReachabilityMoved {
+		return "redirected to " + status.FinalURL
+	}
+	return status.Error
+}
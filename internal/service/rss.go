@@ -1,29 +1,58 @@
 package service
 
 import (
+	"errors"
+
 	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/urlutil"
 	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/gorm"
 )
 
 type RSSService struct {
-	repo    *repository.RSSRepository
-	tagRepo *repository.TagRepository
+	repo      *repository.RSSRepository
+	entryRepo *repository.FeedEntryRepository
+	tagRepo   *repository.TagRepository
+	tagSvc    *TagService
 }
 
-func NewRSSService(repo *repository.RSSRepository, tagRepo *repository.TagRepository) *RSSService {
-	return &RSSService{repo: repo, tagRepo: tagRepo}
+func NewRSSService(repo *repository.RSSRepository, entryRepo *repository.FeedEntryRepository, tagRepo *repository.TagRepository, tagSvc *TagService) *RSSService {
+	return &RSSService{repo: repo, entryRepo: entryRepo, tagRepo: tagRepo, tagSvc: tagSvc}
 }
 
-func (s *RSSService) List(page, perPage int, category, keyword string) ([]model.RSSFeed, int64, error) {
-	return s.repo.List(page, perPage, category, keyword)
+// List returns feeds; health may be "healthy" or "unhealthy" to filter by fetch health
+func (s *RSSService) List(page, perPage int, category, keyword, health string) ([]model.RSSFeed, int64, error) {
+	feeds, total, err := s.repo.List(page, perPage, category, keyword, health, defaults.FeedUnhealthyFailures)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range feeds {
+		markFeedHealth(&feeds[i])
+	}
+	return feeds, total, nil
 }
 
 func (s *RSSService) GetByID(id uint) (*model.RSSFeed, error) {
-	return s.repo.GetByID(id)
+	feed, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	markFeedHealth(feed)
+	return feed, nil
 }
 
+// Create stores a new feed; a *DuplicateURLError is returned when another feed
+// has the same normalized URL.
 func (s *RSSService) Create(feed *model.RSSFeed, tagIDs []uint) error {
+	if err := s.checkURL(feed); err != nil {
+		return err
+	}
 	if err := s.repo.Create(feed); err != nil {
+		// A concurrent create may have taken the URL after the check
+		if dupErr := s.checkURL(feed); dupErr != nil {
+			return dupErr
+		}
 		return err
 	}
 
@@ -38,8 +67,15 @@ func (s *RSSService) Create(feed *model.RSSFeed, tagIDs []uint) error {
 	return nil
 }
 
+// Update stores the feed and replaces its tags, with the URL check of Create
 func (s *RSSService) Update(feed *model.RSSFeed, tagIDs []uint) error {
+	if err := s.checkURL(feed); err != nil {
+		return err
+	}
 	if err := s.repo.Update(feed); err != nil {
+		if dupErr := s.checkURL(feed); dupErr != nil {
+			return dupErr
+		}
 		return err
 	}
 
@@ -50,10 +86,45 @@ func (s *RSSService) Update(feed *model.RSSFeed, tagIDs []uint) error {
 	return s.repo.UpdateTags(feed, tags)
 }
 
+// ResetFetchState forgets the validators and failure history of a feed, which
+// belong to its previous URL
+func (s *RSSService) ResetFetchState(feed *model.RSSFeed) error {
+	if err := s.repo.ResetFetchState(feed.ID); err != nil {
+		return err
+	}
+	feed.ETag = ""
+	feed.LastModified = ""
+	feed.LastError = ""
+	feed.ConsecutiveFailures = 0
+	return nil
+}
+
 func (s *RSSService) Delete(id uint) error {
 	return s.repo.Delete(id)
 }
 
+// checkURL returns a *DuplicateURLError if another feed has the normalized URL of feed
+func (s *RSSService) checkURL(feed *model.RSSFeed) error {
+	existing, err := s.repo.GetByNormalizedURL(urlutil.Normalize(feed.URL), feed.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &DuplicateURLError{Resource: "rss", ID: existing.ID, Name: existing.Name, URL: existing.URL}
+}
+
 func (s *RSSService) GetActive() ([]model.RSSFeed, error) {
 	return s.repo.GetActive()
 }
+
+// ListEntries returns fetched entries, optionally limited to a single feed (feedID 0 means all feeds)
+func (s *RSSService) ListEntries(page, perPage int, feedID uint, keyword string) ([]model.FeedEntry, int64, error) {
+	return s.entryRepo.List(page, perPage, feedID, keyword)
+}
+
+// markFeedHealth flags feeds that have failed too many times in a row
+func markFeedHealth(feed *model.RSSFeed) {
+	feed.Unhealthy = feed.ConsecutiveFailures >= defaults.FeedUnhealthyFailures
+}
@@ -0,0 +1,562 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/feed"
+	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrFetchInProgress is returned when a feed is fetched while another fetch of it is still running.
+var ErrFetchInProgress = errors.New("feed is already being fetched")
+
+// RSSFetcher periodically polls active RSS feeds whose fetch interval has elapsed.
+type RSSFetcher struct {
+	cfg        config.RSSConfig
+	urlDedup   bool
+	repo       *repository.RSSRepository
+	entryRepo  *repository.FeedEntryRepository
+	websubRepo *repository.WebSubRepository
+	datasetSvc *DatasetService
+	ragflowSvc *RagFlowService
+	extractSvc *ExtractService
+	events     *EventBus
+	client     *http.Client
+
+	// inflight guards against polling the same feed twice concurrently
+	inflight sync.Map
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRSSFetcher(
+	cfg config.RSSConfig,
+	urlDedup bool,
+	repo *repository.RSSRepository,
+	entryRepo *repository.FeedEntryRepository,
+	websubRepo *repository.WebSubRepository,
+	datasetSvc *DatasetService,
+	ragflowSvc *RagFlowService,
+	extractSvc *ExtractService,
+	events *EventBus,
+) *RSSFetcher {
+	return &RSSFetcher{
+		cfg:        cfg,
+		urlDedup:   urlDedup,
+		repo:       repo,
+		entryRepo:  entryRepo,
+		websubRepo: websubRepo,
+		datasetSvc: datasetSvc,
+		ragflowSvc: ragflowSvc,
+		extractSvc: extractSvc,
+		events:     events,
+		client:     &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}
+}
+
+// FetchResult summarizes a single feed poll.
+type FetchResult struct {
+	FeedID    uint   `json:"feed_id"`
+	Title     string `json:"title"`
+	Format    string `json:"format"`
+	Items     int    `json:"items"`
+	New       int    `json:"new"`
+	Updated   int    `json:"updated"`
+	Unchanged int    `json:"unchanged"`
+	Skipped   int    `json:"skipped"`
+	Ingested  int    `json:"ingested"`
+	// NotModified is set when the server answered 304 and nothing was parsed
+	NotModified bool `json:"not_modified"`
+}
+
+// Start launches the background polling loop. It is a no-op when the fetcher is disabled.
+func (f *RSSFetcher) Start() {
+	if !f.cfg.FetcherEnabled {
+		log.Println("RSS fetcher disabled by configuration")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+
+	f.wg.Add(1)
+	go f.run(ctx)
+	log.Printf("RSS fetcher started (poll interval %ds, concurrency %d)", f.cfg.PollInterval, f.cfg.Concurrency)
+}
+
+// Stop cancels in-flight fetches and waits for the polling loop to exit.
+func (f *RSSFetcher) Stop() {
+	if f.cancel == nil {
+		return
+	}
+	f.cancel()
+	f.wg.Wait()
+	log.Println("RSS fetcher stopped")
+}
+
+func (f *RSSFetcher) run(ctx context.Context) {
+	defer f.wg.Done()
+
+	interval := time.Duration(f.cfg.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	f.pollDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.pollDue(ctx)
+		}
+	}
+}
+
+// pollDue fetches every active feed whose interval has elapsed, bounded by the configured concurrency.
+// Feeds with an active WebSub subscription are polled far less often, and
+// subscriptions close to expiry are renewed.
+func (f *RSSFetcher) pollDue(ctx context.Context) {
+	feeds, err := f.repo.GetActive()
+	if err != nil {
+		log.Printf("warn: RSS fetcher failed to load active feeds: %v", err)
+		return
+	}
+
+	now := time.Now()
+	pushed := map[uint]bool{}
+	if f.webSubEnabled() {
+		f.renewWebSubs(ctx)
+		if pushed, err = f.websubRepo.ActiveFeedIDs(now); err != nil {
+			log.Printf("warn: RSS fetcher failed to load WebSub subscriptions: %v", err)
+			pushed = map[uint]bool{}
+		}
+	}
+
+	concurrency := f.cfg.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i := range feeds {
+		rssFeed := &feeds[i]
+		if pushed[rssFeed.ID] {
+			if !isPushFeedDue(rssFeed, now) {
+				continue
+			}
+		} else if !isFeedDue(rssFeed, now) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := f.Fetch(ctx, rssFeed, nil)
+			if err != nil {
+				log.Printf("warn: RSS fetch failed for feed %d (%s): %v", rssFeed.ID, rssFeed.URL, err)
+				return
+			}
+			if result.NotModified {
+				log.Printf("RSS feed %d not modified", result.FeedID)
+				return
+			}
+			log.Printf("RSS feed %d fetched: %d items, %d new, %d updated, %d skipped, %d ingested",
+				result.FeedID, result.Items, result.New, result.Updated, result.Skipped, result.Ingested)
+		}()
+	}
+	wg.Wait()
+}
+
+// Fetch downloads and parses a single feed, stores its entries and records the
+// outcome (validators on success, error and failure count otherwise) on the feed.
+// Each item is reported to progress (which may be nil) once it is stored.
+func (f *RSSFetcher) Fetch(ctx context.Context, rssFeed *model.RSSFeed, progress *JobProgress) (*FetchResult, error) {
+	if _, busy := f.inflight.LoadOrStore(rssFeed.ID, struct{}{}); busy {
+		return nil, fmt.Errorf("feed %d: %w", rssFeed.ID, ErrFetchInProgress)
+	}
+	defer f.inflight.Delete(rssFeed.ID)
+
+	parsed, resp, err := f.download(ctx, rssFeed)
+	if err != nil {
+		f.recordFailure(ctx, rssFeed, resp.StatusCode, err)
+		return nil, err
+	}
+
+	result := &FetchResult{FeedID: rssFeed.ID, NotModified: resp.NotModified}
+	if !resp.NotModified {
+		result.Title = parsed.Title
+		result.Format = parsed.Format
+		result.Items = len(parsed.Items)
+		// Entries must be stored before the new validators, otherwise a failed
+		// save would be followed by a 304 and the items would never be seen again.
+		if err := f.saveEntries(ctx, rssFeed, parsed.Items, result, progress); err != nil {
+			f.recordFailure(ctx, rssFeed, resp.StatusCode, err)
+			return result, err
+		}
+	}
+
+	if err := f.repo.RecordFetchSuccess(rssFeed.ID, time.Now(), resp.StatusCode, resp.ETag, resp.LastModified); err != nil {
+		log.Printf("warn: failed to record fetch result for feed %d: %v", rssFeed.ID, err)
+	}
+	f.ensureWebSub(ctx, rssFeed, parsed)
+	return result, nil
+}
+
+// FeedPreview is a parsed feed that has not been saved.
+type FeedPreview struct {
+	URL         string      `json:"url"`
+	Format      string      `json:"format"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Link        string      `json:"link"`
+	TotalItems  int         `json:"total_items"`
+	Items       []feed.Item `json:"items"`
+}
+
+// Preview downloads and parses a feed URL without storing anything, returning
+// the feed metadata and its latest items.
+func (f *RSSFetcher) Preview(ctx context.Context, url string) (*FeedPreview, error) {
+	parsed, _, err := f.download(ctx, &model.RSSFeed{URL: url})
+	if err != nil {
+		return nil, err
+	}
+
+	items := parsed.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Published == nil || items[j].Published == nil {
+			return items[i].Published != nil
+		}
+		return items[i].Published.After(*items[j].Published)
+	})
+	if len(items) > defaults.FeedPreviewItems {
+		items = items[:defaults.FeedPreviewItems]
+	}
+
+	return &FeedPreview{
+		URL:         url,
+		Format:      parsed.Format,
+		Title:       parsed.Title,
+		Description: parsed.Description,
+		Link:        parsed.Link,
+		TotalItems:  len(parsed.Items),
+		Items:       items,
+	}, nil
+}
+
+// recordFailure stores a failed attempt so the feed backs off and shows up as unhealthy.
+// Attempts aborted by shutdown are not the feed's fault and are not counted.
+func (f *RSSFetcher) recordFailure(ctx context.Context, rssFeed *model.RSSFeed, statusCode int, fetchErr error) {
+	if ctx.Err() != nil {
+		return
+	}
+	if err := f.repo.RecordFetchFailure(rssFeed.ID, time.Now(), statusCode, fetchErr.Error()); err != nil {
+		log.Printf("warn: failed to record fetch failure for feed %d: %v", rssFeed.ID, err)
+	}
+}
+
+// entryEvent describes a new feed entry, after its ingest when the feed
+// auto-ingests; the event carries the tags and category of the feed
+func entryEvent(rssFeed *model.RSSFeed, entry *model.FeedEntry) Event {
+	event := NewEvent(EventFeedEntryNew, map[string]interface{}{
+		"entry_id":     entry.ID,
+		"feed_id":      rssFeed.ID,
+		"feed_name":    rssFeed.Name,
+		"title":        entry.Title,
+		"link":         entry.Link,
+		"author":       entry.Author,
+		"published_at": entry.PublishedAt,
+		"summary":      entry.Summary,
+		"status":       entry.Status,
+		"document_id":  entry.DocumentID,
+	})
+	event.Tags = tagNames(rssFeed.Tags)
+	event.Category = rssFeed.Category
+	event.DatasetID = entry.DatasetID
+	return event
+}
+
+// saveEntries inserts unseen items and refreshes items whose content changed.
+// Entries are keyed by (feed_id, guid), so re-polling never creates duplicates.
+// New entries of auto-ingest feeds are uploaded to RagFlow right away, and
+// every new entry is announced as a feed.entry.new event.
+// Entries rejected by the feed's filter rules are stored as skipped and never
+// ingested; changed entries that were not ingested are filtered again.
+func (f *RSSFetcher) saveEntries(ctx context.Context, rssFeed *model.RSSFeed, items []feed.Item, result *FetchResult, progress *JobProgress) error {
+	filter, err := loadFeedFilters(rssFeed)
+	if err != nil {
+		return fmt.Errorf("failed to load filter rules: %w", err)
+	}
+
+	progress.SetTotal(len(items))
+	for _, item := range items {
+		guid := entryGUID(item)
+		hash := entryContentHash(item)
+
+		existing, err := f.entryRepo.GetByFeedAndGUID(rssFeed.ID, guid)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to look up entry %q: %w", guid, err)
+		}
+
+		if existing == nil {
+			entry := &model.FeedEntry{FeedID: rssFeed.ID, GUID: guid, Status: model.EntryStatusNew}
+			applyFeedItem(entry, item, hash)
+			if filter != nil {
+				if reason := filter.skipReason(item); reason != "" {
+					entry.Status = model.EntryStatusSkipped
+					entry.StatusMessage = reason
+				}
+			}
+			if err := f.entryRepo.Create(entry); err != nil {
+				return fmt.Errorf("failed to store entry %q: %w", guid, err)
+			}
+			if entry.Status == model.EntryStatusSkipped {
+				result.Skipped++
+				reportEntry(progress, entry, "skipped")
+				continue
+			}
+			result.New++
+
+			if rssFeed.AutoIngest {
+				if err := f.ingestEntry(ctx, rssFeed, entry); err != nil {
+					return fmt.Errorf("failed to record ingest result for entry %q: %w", guid, err)
+				}
+				if entry.Status == model.EntryStatusIngested {
+					result.Ingested++
+				}
+			}
+			f.events.Publish(entryEvent(rssFeed, entry))
+			reportEntry(progress, entry, "new")
+			continue
+		}
+
+		if existing.ContentHash == hash {
+			result.Unchanged++
+			reportEntry(progress, existing, "unchanged")
+			continue
+		}
+		applyFeedItem(existing, item, hash)
+		released := refilterEntry(existing, item, filter)
+		if err := f.entryRepo.Update(existing); err != nil {
+			return fmt.Errorf("failed to update entry %q: %w", guid, err)
+		}
+		if existing.Status == model.EntryStatusSkipped {
+			result.Skipped++
+			reportEntry(progress, existing, "skipped")
+			continue
+		}
+		result.Updated++
+		if released && rssFeed.AutoIngest {
+			if err := f.ingestEntry(ctx, rssFeed, existing); err != nil {
+				return fmt.Errorf("failed to record ingest result for entry %q: %w", guid, err)
+			}
+			if existing.Status == model.EntryStatusIngested {
+				result.Ingested++
+			}
+		}
+		reportEntry(progress, existing, "updated")
+	}
+	return nil
+}
+
+// refilterEntry applies the filter rules to the changed content of an entry
+// that was not ingested, skipping it or releasing it from an earlier skip.
+// Entries already handed to RagFlow keep their status. It reports whether the
+// entry was released, so that it can be ingested like a new one.
+func refilterEntry(entry *model.FeedEntry, item feed.Item, filter *feedFilter) bool {
+	if entry.Status != model.EntryStatusNew && entry.Status != model.EntryStatusSkipped {
+		return false
+	}
+	var reason string
+	if filter != nil {
+		reason = filter.skipReason(item)
+	}
+	if reason != "" {
+		entry.Status = model.EntryStatusSkipped
+		entry.StatusMessage = reason
+		return false
+	}
+	released := entry.Status == model.EntryStatusSkipped
+	entry.Status = model.EntryStatusNew
+	entry.StatusMessage = ""
+	return released
+}
+
+// reportEntry reports a stored entry as a progress item; change is one of
+// new, updated, unchanged or skipped. Entries whose ingest failed count as failed.
+func reportEntry(progress *JobProgress, entry *model.FeedEntry, change string) {
+	progress.Item(map[string]interface{}{
+		"entry_id": entry.ID,
+		"guid":     entry.GUID,
+		"title":    entry.Title,
+		"change":   change,
+		"status":   entry.Status,
+		"message":  entry.StatusMessage,
+	}, entry.Status != model.EntryStatusFailed)
+}
+
+func applyFeedItem(entry *model.FeedEntry, item feed.Item, hash string) {
+	entry.Title = truncateRunes(item.Title, 1000)
+	entry.Link = truncateRunes(item.Link, 2000)
+	entry.Author = truncateRunes(item.Author, 200)
+	entry.PublishedAt = item.Published
+	entry.Summary = item.Summary
+	entry.Content = item.Content
+	entry.ContentHash = hash
+}
+
+// entryGUID returns a stable identifier for an item, falling back to a hash of
+// its title and date when the feed provides neither a GUID nor a link.
+func entryGUID(item feed.Item) string {
+	if item.GUID != "" {
+		return truncateRunes(item.GUID, 1000)
+	}
+	var published string
+	if item.Published != nil {
+		published = item.Published.Format(time.RFC3339)
+	}
+	sum := sha256.Sum256([]byte(item.Title + "\n" + published))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// entryContentHash fingerprints the fields that make an entry worth re-processing.
+func entryContentHash(item feed.Item) string {
+	h := sha256.New()
+	for _, part := range []string{item.Title, item.Link, item.Summary, item.Content} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// truncateRunes shortens s to at most n runes so it fits a sized column.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// feedResponse carries the HTTP details of a feed download.
+type feedResponse struct {
+	StatusCode   int
+	NotModified  bool
+	ETag         string
+	LastModified string
+}
+
+// download performs a conditional GET using the feed's stored validators.
+// On 304 Not Modified the returned feed is nil and the previous validators are kept
+// unless the server sent new ones.
+func (f *RSSFetcher) download(ctx context.Context, rssFeed *model.RSSFeed) (*feed.Feed, feedResponse, error) {
+	result := feedResponse{ETag: rssFeed.ETag, LastModified: rssFeed.LastModified}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rssFeed.URL, nil)
+	if err != nil {
+		return nil, result, err
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/rdf+xml;q=0.9, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
+	if rssFeed.ETag != "" {
+		req.Header.Set("If-None-Match", rssFeed.ETag)
+	}
+	if rssFeed.LastModified != "" {
+		req.Header.Set("If-Modified-Since", rssFeed.LastModified)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, result, err
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		result.ETag = truncateRunes(etag, 500)
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		result.LastModified = truncateRunes(lastModified, 100)
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		result.NotModified = true
+		return nil, result, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, result, fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, defaults.MaxFeedBodySize))
+	if err != nil {
+		return nil, result, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Resolve relative links against the final URL in case the feed was redirected
+	parsed, err := feed.Parse(body, resp.Header.Get("Content-Type"), resp.Request.URL.String())
+	if err != nil {
+		return nil, result, err
+	}
+	return parsed, result, nil
+}
+
+// isFeedDue reports whether the feed's retry delay has elapsed since its last fetch attempt.
+func isFeedDue(rssFeed *model.RSSFeed, now time.Time) bool {
+	last := rssFeed.LastCheckedAt
+	if last == nil {
+		last = rssFeed.LastFetchedAt
+	}
+	if last == nil {
+		return true
+	}
+	return !now.Before(last.Add(feedRetryDelay(rssFeed)))
+}
+
+// feedRetryDelay is the feed's fetch interval, doubled for every consecutive
+// failure and capped at MaxFeedBackoffMinutes (or the interval itself if larger).
+func feedRetryDelay(rssFeed *model.RSSFeed) time.Duration {
+	interval := rssFeed.FetchIntervalMinutes
+	if interval <= 0 {
+		interval = defaults.DefaultFetchInterval
+	}
+	maxDelay := defaults.MaxFeedBackoffMinutes
+	if interval > maxDelay {
+		maxDelay = interval
+	}
+
+	delay := interval
+	for i := 0; i < rssFeed.ConsecutiveFailures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return time.Duration(delay) * time.Minute
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/feed"
+	"gorm.io/datatypes"
+)
+
+// feedFiltersKey is the RSSFeed.Metadata key holding the feed's filter rules.
+const feedFiltersKey = "filters"
+
+// Filter rule fields
+const (
+	FilterFieldAny     = "any"
+	FilterFieldTitle   = "title"
+	FilterFieldContent = "content"
+	FilterFieldAuthor  = "author"
+)
+
+// FeedFilterRule matches an entry field by case-insensitive keyword or by regular expression.
+type FeedFilterRule struct {
+	Field   string `json:"field"` // title, content, author or any (default)
+	Keyword string `json:"keyword,omitempty"`
+	Regex   string `json:"regex,omitempty"`
+}
+
+// FeedFilters decide which fetched entries are kept. When include rules exist an
+// entry must match at least one of them; an entry matching any exclude rule, or
+// whose content is shorter than MinContentLength characters, is skipped.
+type FeedFilters struct {
+	Include          []FeedFilterRule `json:"include,omitempty"`
+	Exclude          []FeedFilterRule `json:"exclude,omitempty"`
+	MinContentLength int              `json:"min_content_length,omitempty"`
+}
+
+func (f FeedFilters) empty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && f.MinContentLength <= 0
+}
+
+// compiledRule is a FeedFilterRule with its regular expression prepared.
+type compiledRule struct {
+	FeedFilterRule
+	re *regexp.Regexp
+}
+
+// feedFilter is the compiled form of FeedFilters used while saving entries.
+type feedFilter struct {
+	include          []compiledRule
+	exclude          []compiledRule
+	minContentLength int
+}
+
+func compileFeedFilters(f FeedFilters) (*feedFilter, error) {
+	include, err := compileRules("include", f.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileRules("exclude", f.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	if f.MinContentLength < 0 {
+		return nil, fmt.Errorf("min_content_length must not be negative")
+	}
+	return &feedFilter{include: include, exclude: exclude, minContentLength: f.MinContentLength}, nil
+}
+
+func compileRules(kind string, rules []FeedFilterRule) ([]compiledRule, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, r := range rules {
+		switch r.Field {
+		case "":
+			r.Field = FilterFieldAny
+		case FilterFieldAny, FilterFieldTitle, FilterFieldContent, FilterFieldAuthor:
+		default:
+			return nil, fmt.Errorf("%s rule %d: unknown field %q", kind, i+1, r.Field)
+		}
+		if (r.Keyword == "") == (r.Regex == "") {
+			return nil, fmt.Errorf("%s rule %d: exactly one of keyword or regex is required", kind, i+1)
+		}
+
+		c := compiledRule{FeedFilterRule: r}
+		if r.Regex != "" {
+			re, err := regexp.Compile(r.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("%s rule %d: invalid regex: %w", kind, i+1, err)
+			}
+			c.re = re
+		} else {
+			c.Keyword = strings.ToLower(r.Keyword)
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// skipReason returns why the item is filtered out, or "" when it should be kept.
+func (f *feedFilter) skipReason(item feed.Item) string {
+	for _, r := range f.exclude {
+		if r.matches(item) {
+			return "matched exclude rule " + r.describe()
+		}
+	}
+	if len(f.include) > 0 {
+		matched := false
+		for _, r := range f.include {
+			if r.matches(item) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return "matched no include rule"
+		}
+	}
+	if f.minContentLength > 0 {
+		text := strings.TrimSpace(item.Content)
+		if text == "" {
+			text = strings.TrimSpace(item.Summary)
+		}
+		if n := utf8.RuneCountInString(text); n < f.minContentLength {
+			return fmt.Sprintf("content length %d is below minimum %d", n, f.minContentLength)
+		}
+	}
+	return ""
+}
+
+func (r compiledRule) matches(item feed.Item) bool {
+	var values []string
+	switch r.Field {
+	case FilterFieldTitle:
+		values = []string{item.Title}
+	case FilterFieldContent:
+		values = []string{item.Summary, item.Content}
+	case FilterFieldAuthor:
+		values = []string{item.Author}
+	default:
+		values = []string{item.Title, item.Summary, item.Content, item.Author}
+	}
+
+	for _, v := range values {
+		if r.re != nil {
+			if r.re.MatchString(v) {
+				return true
+			}
+		} else if strings.Contains(strings.ToLower(v), r.Keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r compiledRule) describe() string {
+	if r.re != nil {
+		return fmt.Sprintf("%s regex %q", r.Field, r.Regex)
+	}
+	return fmt.Sprintf("%s keyword %q", r.Field, r.Keyword)
+}
+
+// loadFeedFilters reads and compiles the filter rules stored in the feed's metadata.
+// It returns nil when the feed has no rules.
+func loadFeedFilters(rssFeed *model.RSSFeed) (*feedFilter, error) {
+	if len(rssFeed.Metadata) == 0 {
+		return nil, nil
+	}
+	var meta struct {
+		Filters *FeedFilters `json:"filters"`
+	}
+	if err := json.Unmarshal(rssFeed.Metadata, &meta); err != nil {
+		return nil, fmt.Errorf("invalid feed metadata: %w", err)
+	}
+	if meta.Filters == nil || meta.Filters.empty() {
+		return nil, nil
+	}
+	return compileFeedFilters(*meta.Filters)
+}
+
+// SetFilters validates the rules and stores them in the feed's metadata,
+// keeping any other metadata keys. Empty rules remove the filters. The feed is not saved.
+func (s *RSSService) SetFilters(rssFeed *model.RSSFeed, filters FeedFilters) error {
+	if _, err := compileFeedFilters(filters); err != nil {
+		return err
+	}
+
+	meta := map[string]interface{}{}
+	if len(rssFeed.Metadata) > 0 {
+		if err := json.Unmarshal(rssFeed.Metadata, &meta); err != nil {
+			return fmt.Errorf("invalid feed metadata: %w", err)
+		}
+	}
+	if filters.empty() {
+		delete(meta, feedFiltersKey)
+	} else {
+		meta[feedFiltersKey] = filters
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	rssFeed.Metadata = datatypes.JSON(data)
+	return nil
+}
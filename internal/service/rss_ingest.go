@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/extract"
+)
+
+// ingestEntry pushes a newly discovered entry into RagFlow using the feed's tags
+// and category for dataset routing. The outcome is recorded on the entry itself;
+// an error is only returned when the entry row could not be updated.
+func (f *RSSFetcher) ingestEntry(ctx context.Context, rssFeed *model.RSSFeed, entry *model.FeedEntry) error {
+	if entry.Link != "" && f.urlDedup {
+		check, err := f.datasetSvc.CheckURL(entry.Link, true, false)
+		if err != nil {
+			return f.finishIngest(entry, model.EntryStatusFailed, "dedup check failed: "+err.Error(), "", "")
+		}
+		if check.Exists {
+			return f.finishIngest(entry, model.EntryStatusDuplicate, "already ingested as "+check.StoredURL, check.DocumentID, check.DatasetID)
+		}
+	}
+
+	tagNames := make([]string, 0, len(rssFeed.Tags))
+	for _, t := range rssFeed.Tags {
+		tagNames = append(tagNames, t.Name)
+	}
+
+	resp, datasetID, err := f.ragflowSvc.UploadWithRouting(&UploadRequest{
+		Content:        entryDocument(rssFeed, entry, f.entryBody(ctx, rssFeed, entry)),
+		Filename:       entryFilename(entry),
+		Title:          entry.Title,
+		URL:            entry.Link,
+		Tags:           tagNames,
+		Category:       rssFeed.Category,
+		AutoCreateTags: true,
+	})
+	if err != nil {
+		return f.finishIngest(entry, model.EntryStatusFailed, err.Error(), "", datasetID)
+	}
+	if resp.Code != 0 {
+		return f.finishIngest(entry, model.EntryStatusFailed, fmt.Sprintf("RagFlow error %d: %s", resp.Code, resp.Message), "", datasetID)
+	}
+
+	var documentID string
+	if resp.Data != nil {
+		documentID, _ = resp.Data["id"].(string)
+	}
+	return f.finishIngest(entry, model.EntryStatusIngested, "", documentID, datasetID)
+}
+
+func (f *RSSFetcher) finishIngest(entry *model.FeedEntry, status, message, documentID, datasetID string) error {
+	if status == model.EntryStatusFailed {
+		log.Printf("warn: auto-ingest failed for feed entry %d (%s): %s", entry.ID, entry.Link, message)
+	}
+	entry.Status = status
+	entry.StatusMessage = message
+	entry.DocumentID = documentID
+	entry.DatasetID = datasetID
+	return f.entryRepo.Update(entry)
+}
+
+// entryBody returns the Markdown body of an entry. For feeds with full-text
+// extraction the linked article is used when it is longer than what the feed
+// carries; otherwise, or when extraction fails, the feed's own HTML is converted.
+func (f *RSSFetcher) entryBody(ctx context.Context, rssFeed *model.RSSFeed, entry *model.FeedEntry) string {
+	html := entry.Content
+	if strings.TrimSpace(html) == "" {
+		html = entry.Summary
+	}
+	body := extract.HTMLToMarkdown(html, entry.Link)
+
+	if rssFeed.ExtractFullText && entry.Link != "" {
+		page, _, err := f.extractSvc.Extract(ctx, entry.Link, false)
+		if err != nil {
+			log.Printf("warn: full-text extraction failed for feed entry %d (%s), using feed content: %v", entry.ID, entry.Link, err)
+		} else if len([]rune(page.Content)) > len([]rune(body)) {
+			body = page.Content
+		}
+	}
+	return body
+}
+
+// entryDocument renders an entry and its Markdown body as the document uploaded to RagFlow.
+func entryDocument(rssFeed *model.RSSFeed, entry *model.FeedEntry, body string) string {
+	var b strings.Builder
+	b.WriteString("# " + entry.Title + "\n\n")
+	if entry.Link != "" {
+		b.WriteString("Source: " + entry.Link + "\n")
+	}
+	b.WriteString("Feed: " + rssFeed.Name + "\n")
+	if entry.Author != "" {
+		b.WriteString("Author: " + entry.Author + "\n")
+	}
+	if entry.PublishedAt != nil {
+		b.WriteString("Published: " + entry.PublishedAt.Format(time.RFC3339) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(body)
+	return b.String()
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[\\/:*?"<>|\s]+`)
+
+// entryFilename builds a filesystem-safe document name from the entry title.
+func entryFilename(entry *model.FeedEntry) string {
+	return documentFilename(entry.Title, fmt.Sprintf("feed-entry-%d", entry.ID))
+}
+
+// documentFilename turns a title into a filesystem-safe Markdown file name.
+func documentFilename(title, fallback string) string {
+	name := strings.Trim(unsafeFilenameChars.ReplaceAllString(title, "_"), "_.")
+	name = truncateRunes(name, 100)
+	if name == "" {
+		name = fallback
+	}
+	return name + ".md"
+}
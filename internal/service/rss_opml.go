@@ -0,0 +1,185 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/opml"
+	"github.com/singll/bellkeeper/internal/pkg/urlutil"
+	"gorm.io/gorm"
+)
+
+// OPML import result statuses
+const (
+	OPMLImportCreated = "created"
+	OPMLImportSkipped = "skipped"
+	OPMLImportFailed  = "failed"
+)
+
+// OPMLImportResult describes what happened to a single feed outline.
+type OPMLImportResult struct {
+	URL      string   `json:"url"`
+	Name     string   `json:"name"`
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Status   string   `json:"status"`
+	FeedID   uint     `json:"feed_id,omitempty"`
+	Message  string   `json:"message,omitempty"`
+}
+
+// OPMLImportSummary aggregates the per-feed results of an OPML import.
+type OPMLImportSummary struct {
+	Total   int                `json:"total"`
+	Created int                `json:"created"`
+	Skipped int                `json:"skipped"`
+	Failed  int                `json:"failed"`
+	Results []OPMLImportResult `json:"results"`
+}
+
+// ImportOPML creates a feed for every subscription in the document.
+// The outermost folder becomes the feed category; deeper folders and the
+// outline's category attribute become tags. Feeds whose normalized URL already exists are skipped.
+func (s *RSSService) ImportOPML(r io.Reader) (*OPMLImportSummary, error) {
+	doc, err := opml.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	subs := doc.Subscriptions()
+	summary := &OPMLImportSummary{Total: len(subs), Results: make([]OPMLImportResult, 0, len(subs))}
+	for _, sub := range subs {
+		result := s.importSubscription(sub)
+		switch result.Status {
+		case OPMLImportCreated:
+			summary.Created++
+		case OPMLImportSkipped:
+			summary.Skipped++
+		default:
+			summary.Failed++
+		}
+		summary.Results = append(summary.Results, result)
+	}
+	return summary, nil
+}
+
+func (s *RSSService) importSubscription(sub opml.Subscription) OPMLImportResult {
+	result := OPMLImportResult{URL: sub.XMLURL, Name: truncateRunes(sub.Title, 200)}
+	if len(sub.Folders) > 0 {
+		result.Category = truncateRunes(sub.Folders[0], 100)
+		result.Tags = uniqueNames(append(sub.Folders[1:], sub.Categories...))
+	} else {
+		result.Tags = uniqueNames(sub.Categories)
+	}
+
+	existing, err := s.repo.GetByNormalizedURL(urlutil.Normalize(sub.XMLURL), 0)
+	if err == nil {
+		result.Status = OPMLImportSkipped
+		result.FeedID = existing.ID
+		result.Message = "feed URL already exists"
+		return result
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		result.Status = OPMLImportFailed
+		result.Message = err.Error()
+		return result
+	}
+
+	rssFeed := &model.RSSFeed{
+		Name:                 result.Name,
+		URL:                  sub.XMLURL,
+		Category:             result.Category,
+		Description:          sub.Description,
+		IsActive:             true,
+		FetchIntervalMinutes: defaults.DefaultFetchInterval,
+	}
+	if err := s.repo.Create(rssFeed); err != nil {
+		result.Status = OPMLImportFailed
+		result.Message = err.Error()
+		return result
+	}
+	result.Status = OPMLImportCreated
+	result.FeedID = rssFeed.ID
+
+	if len(result.Tags) > 0 {
+		tags, err := s.tagSvc.GetOrCreateByNames(result.Tags)
+		if err == nil {
+			err = s.repo.UpdateTags(rssFeed, tags)
+		}
+		if err != nil {
+			result.Message = fmt.Sprintf("feed created but tags were not applied: %v", err)
+		}
+	}
+	return result
+}
+
+// ExportOPML renders all feeds as an OPML document, grouping them into one
+// folder per category and listing their tags in the category attribute.
+func (s *RSSService) ExportOPML() ([]byte, error) {
+	feeds, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &opml.Document{
+		Version: "2.0",
+		Head: opml.Head{
+			Title:       "Bellkeeper RSS subscriptions",
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	folders := make(map[string]int)
+	for _, f := range feeds {
+		names := make([]string, 0, len(f.Tags))
+		for _, t := range f.Tags {
+			names = append(names, t.Name)
+		}
+		outline := opml.Outline{
+			Text:        f.Name,
+			Title:       f.Name,
+			Type:        "rss",
+			XMLURL:      f.URL,
+			Description: f.Description,
+			Category:    strings.Join(names, ","),
+		}
+
+		if f.Category == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, outline)
+			continue
+		}
+		idx, ok := folders[f.Category]
+		if !ok {
+			idx = len(doc.Body.Outlines)
+			folders[f.Category] = idx
+			doc.Body.Outlines = append(doc.Body.Outlines, opml.Outline{Text: f.Category, Title: f.Category})
+		}
+		doc.Body.Outlines[idx].Outlines = append(doc.Body.Outlines[idx].Outlines, outline)
+	}
+
+	var buf bytes.Buffer
+	if err := opml.Write(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// uniqueNames trims names and drops blanks and duplicates while keeping order.
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var out []string
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	return out
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"github.com/singll/bellkeeper/internal/pkg/feed"
+	"gorm.io/gorm"
+)
+
+// WebSub callback errors
+var (
+	// ErrWebSubNotFound is returned for callbacks of unknown subscriptions, inactive feeds or when WebSub is disabled
+	ErrWebSubNotFound = errors.New("websub subscription not found")
+	// ErrWebSubSignature is returned when pushed content is not signed with the subscription secret
+	ErrWebSubSignature = errors.New("invalid websub signature")
+	// ErrWebSubVerification is returned when a verification request does not match the subscription
+	ErrWebSubVerification = errors.New("websub verification rejected")
+)
+
+// WebSub hub modes
+const (
+	WebSubModeSubscribe   = "subscribe"
+	WebSubModeUnsubscribe = "unsubscribe"
+	WebSubModeDenied      = "denied"
+)
+
+// webSubEnabled reports whether push subscriptions can be made. Hubs need a
+// publicly reachable callback, so a public base URL is required.
+func (f *RSSFetcher) webSubEnabled() bool {
+	return f.cfg.WebSub.Enabled && f.cfg.WebSub.PublicBaseURL != ""
+}
+
+// GetWebSubscription returns the WebSub subscription of a feed
+func (f *RSSFetcher) GetWebSubscription(feedID uint) (*model.WebSubSubscription, error) {
+	return f.websubRepo.GetByFeedID(feedID)
+}
+
+// ensureWebSub subscribes to the hub advertised by a freshly parsed feed. Active
+// subscriptions are left alone (renewWebSubs extends them) and failed attempts
+// are retried after WebSubRetryMinutes.
+func (f *RSSFetcher) ensureWebSub(ctx context.Context, rssFeed *model.RSSFeed, parsed *feed.Feed) {
+	if !f.webSubEnabled() || parsed == nil || parsed.Hub == "" {
+		return
+	}
+	topic := parsed.Self
+	if topic == "" {
+		topic = rssFeed.URL
+	}
+
+	sub, err := f.websubRepo.GetByFeedID(rssFeed.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("warn: failed to load WebSub subscription of feed %d: %v", rssFeed.ID, err)
+		return
+	}
+
+	now := time.Now()
+	if sub != nil && sub.HubURL == parsed.Hub && sub.TopicURL == topic {
+		if sub.State == model.WebSubStateActive {
+			return
+		}
+		if sub.RequestedAt != nil && now.Before(sub.RequestedAt.Add(defaults.WebSubRetryMinutes*time.Minute)) {
+			return
+		}
+	} else {
+		// New hub or topic: start over with a fresh secret
+		if sub == nil {
+			sub = &model.WebSubSubscription{FeedID: rssFeed.ID}
+		}
+		secret, err := randomSecret()
+		if err != nil {
+			log.Printf("warn: failed to generate WebSub secret for feed %d: %v", rssFeed.ID, err)
+			return
+		}
+		sub.HubURL = truncateRunes(parsed.Hub, 1000)
+		sub.TopicURL = truncateRunes(topic, 1000)
+		sub.Secret = secret
+		sub.State = model.WebSubStatePending
+		sub.ExpiresAt = nil
+	}
+
+	f.subscribe(ctx, sub)
+}
+
+// renewWebSubs re-subscribes active subscriptions whose lease is about to expire.
+func (f *RSSFetcher) renewWebSubs(ctx context.Context) {
+	if !f.webSubEnabled() {
+		return
+	}
+
+	now := time.Now()
+	subs, err := f.websubRepo.GetExpiring(
+		now.Add(defaults.WebSubRenewMarginHours*time.Hour),
+		now.Add(-defaults.WebSubRetryMinutes*time.Minute),
+	)
+	if err != nil {
+		log.Printf("warn: failed to load expiring WebSub subscriptions: %v", err)
+		return
+	}
+
+	for i := range subs {
+		if ctx.Err() != nil {
+			return
+		}
+		sub := &subs[i]
+		rssFeed, err := f.repo.GetByID(sub.FeedID)
+		if err != nil || !rssFeed.IsActive {
+			continue
+		}
+		f.subscribe(ctx, sub)
+	}
+}
+
+// subscribe sends a subscribe request to the hub. The hub confirms it
+// asynchronously through the verification callback. Renewals keep the
+// subscription active so pushes are still accepted meanwhile.
+func (f *RSSFetcher) subscribe(ctx context.Context, sub *model.WebSubSubscription) {
+	now := time.Now()
+	sub.RequestedAt = &now
+	if sub.State != model.WebSubStateActive {
+		sub.State = model.WebSubStatePending
+	}
+	// The callback URL contains the subscription ID, so the row must exist first
+	if err := f.websubRepo.Save(sub); err != nil {
+		log.Printf("warn: failed to save WebSub subscription of feed %d: %v", sub.FeedID, err)
+		return
+	}
+
+	if err := f.sendSubscribe(ctx, sub); err != nil {
+		log.Printf("warn: WebSub subscribe failed for feed %d at %s: %v", sub.FeedID, sub.HubURL, err)
+		sub.LastError = err.Error()
+		if sub.State != model.WebSubStateActive {
+			sub.State = model.WebSubStateFailed
+		}
+	} else {
+		sub.LastError = ""
+	}
+	if err := f.websubRepo.Save(sub); err != nil {
+		log.Printf("warn: failed to save WebSub subscription of feed %d: %v", sub.FeedID, err)
+	}
+}
+
+func (f *RSSFetcher) sendSubscribe(ctx context.Context, sub *model.WebSubSubscription) error {
+	form := url.Values{}
+	form.Set("hub.mode", WebSubModeSubscribe)
+	form.Set("hub.topic", sub.TopicURL)
+	form.Set("hub.callback", f.webSubCallbackURL(sub.ID))
+	form.Set("hub.secret", sub.Secret)
+	if f.cfg.WebSub.LeaseSeconds > 0 {
+		form.Set("hub.lease_seconds", strconv.Itoa(f.cfg.WebSub.LeaseSeconds))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", sub.HubURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("hub returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (f *RSSFetcher) webSubCallbackURL(id uint) string {
+	return fmt.Sprintf("%s/websub/callback/%d", strings.TrimRight(f.cfg.WebSub.PublicBaseURL, "/"), id)
+}
+
+// VerifyWebSub handles the hub's verification of intent. Subscribe requests are
+// confirmed only for the topic Bellkeeper asked for; denials are recorded.
+// Bellkeeper never unsubscribes itself, so unsubscribe requests are rejected.
+func (f *RSSFetcher) VerifyWebSub(id uint, mode, topic string, leaseSeconds int, reason string) error {
+	if !f.webSubEnabled() {
+		return ErrWebSubNotFound
+	}
+	sub, err := f.websubRepo.GetByID(id)
+	if err != nil {
+		return ErrWebSubNotFound
+	}
+	if topic != sub.TopicURL {
+		return fmt.Errorf("%w: unexpected topic %q", ErrWebSubVerification, topic)
+	}
+
+	now := time.Now()
+	switch mode {
+	case WebSubModeSubscribe:
+		if sub.State != model.WebSubStatePending && sub.State != model.WebSubStateActive {
+			return fmt.Errorf("%w: subscription is %s", ErrWebSubVerification, sub.State)
+		}
+		if leaseSeconds <= 0 {
+			leaseSeconds = f.cfg.WebSub.LeaseSeconds
+		}
+		expires := now.Add(time.Duration(leaseSeconds) * time.Second)
+		sub.State = model.WebSubStateActive
+		sub.LeaseSeconds = leaseSeconds
+		sub.ExpiresAt = &expires
+		sub.LastError = ""
+	case WebSubModeDenied:
+		sub.State = model.WebSubStateDenied
+		sub.ExpiresAt = nil
+		sub.LastError = reason
+	default:
+		return fmt.Errorf("%w: unsupported mode %q", ErrWebSubVerification, mode)
+	}
+
+	return f.websubRepo.Save(sub)
+}
+
+// HandlePush stores content distributed by the hub through the same pipeline as
+// polling. Pushes that are not signed with the subscription secret are rejected
+// with ErrWebSubSignature.
+func (f *RSSFetcher) HandlePush(ctx context.Context, id uint, signature, contentType string, body []byte) (*FetchResult, error) {
+	if !f.webSubEnabled() {
+		return nil, ErrWebSubNotFound
+	}
+	sub, err := f.websubRepo.GetByID(id)
+	if err != nil || sub.State != model.WebSubStateActive {
+		return nil, ErrWebSubNotFound
+	}
+	rssFeed, err := f.repo.GetByID(sub.FeedID)
+	if err != nil || !rssFeed.IsActive {
+		return nil, ErrWebSubNotFound
+	}
+
+	if !validHubSignature(sub.Secret, signature, body) {
+		return nil, fmt.Errorf("feed %d: %w", rssFeed.ID, ErrWebSubSignature)
+	}
+
+	if _, busy := f.inflight.LoadOrStore(rssFeed.ID, struct{}{}); busy {
+		return nil, fmt.Errorf("feed %d: %w", rssFeed.ID, ErrFetchInProgress)
+	}
+	defer f.inflight.Delete(rssFeed.ID)
+
+	parsed, err := feed.Parse(body, contentType, rssFeed.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FetchResult{FeedID: rssFeed.ID, Title: parsed.Title, Format: parsed.Format, Items: len(parsed.Items)}
+	if err := f.saveEntries(ctx, rssFeed, parsed.Items, result, nil); err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	sub.LastPushAt = &now
+	if err := f.websubRepo.Save(sub); err != nil {
+		log.Printf("warn: failed to record WebSub push for feed %d: %v", rssFeed.ID, err)
+	}
+	return result, nil
+}
+
+// validHubSignature checks an X-Hub-Signature header ("sha256=<hex>") against
+// the body. Every subscription is made with a secret, so a missing one fails.
+func validHubSignature(secret, signature string, body []byte) bool {
+	if secret == "" {
+		return false
+	}
+	method, sig, ok := strings.Cut(signature, "=")
+	if !ok {
+		return false
+	}
+
+	var newHash func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isPushFeedDue is isFeedDue for feeds kept up to date by WebSub, which are only
+// polled every WebSubFallbackPollMinutes as a safety net.
+func isPushFeedDue(rssFeed *model.RSSFeed, now time.Time) bool {
+	if !isFeedDue(rssFeed, now) {
+		return false
+	}
+	last := rssFeed.LastCheckedAt
+	if last == nil {
+		last = rssFeed.LastFetchedAt
+	}
+	return last == nil || !now.Before(last.Add(defaults.WebSubFallbackPollMinutes*time.Minute))
+}
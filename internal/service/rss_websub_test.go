@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/feed"
+	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	testTopic   = "https://example.com/feed.xml"
+	testBaseURL = "https://bellkeeper.example.com"
+)
+
+// newTestDB returns an in-memory SQLite database with the tables of the given models.
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection would get its own in-memory database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newTestWebSubFetcher(t *testing.T) (*RSSFetcher, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t, &model.Tag{}, &model.RSSFeed{}, &model.FeedEntry{}, &model.WebSubSubscription{})
+	cfg := config.RSSConfig{
+		UserAgent: "bellkeeper-test",
+		WebSub:    config.WebSubConfig{Enabled: true, PublicBaseURL: testBaseURL + "/", LeaseSeconds: 86400},
+	}
+	fetcher := NewRSSFetcher(cfg, false,
+		repository.NewRSSRepository(db),
+		repository.NewFeedEntryRepository(db),
+		repository.NewWebSubRepository(db),
+		nil, nil, nil, nil)
+	return fetcher, db
+}
+
+// createTestSubscription stores an active feed with a subscription in the given state.
+func createTestSubscription(t *testing.T, db *gorm.DB, state, secret string) *model.WebSubSubscription {
+	t.Helper()
+	rssFeed := &model.RSSFeed{Name: "Example", URL: testTopic, IsActive: true}
+	if err := db.Create(rssFeed).Error; err != nil {
+		t.Fatalf("create feed: %v", err)
+	}
+	sub := &model.WebSubSubscription{FeedID: rssFeed.ID, HubURL: "https://hub.example.com/", TopicURL: testTopic, Secret: secret, State: state}
+	if err := db.Create(sub).Error; err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	return sub
+}
+
+func TestEnsureWebSubSubscribes(t *testing.T) {
+	var mu sync.Mutex
+	var form url.Values
+	var contentType string
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		form = r.PostForm
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hub.Close()
+
+	fetcher, db := newTestWebSubFetcher(t)
+	fetcher.client = hub.Client()
+	rssFeed := &model.RSSFeed{Name: "Example", URL: "https://example.com/rss", IsActive: true}
+	if err := db.Create(rssFeed).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher.ensureWebSub(context.Background(), rssFeed, &feed.Feed{Hub: hub.URL, Self: testTopic})
+
+	sub, err := fetcher.GetWebSubscription(rssFeed.ID)
+	if err != nil {
+		t.Fatalf("subscription not stored: %v", err)
+	}
+	if sub.State != model.WebSubStatePending || sub.LastError != "" {
+		t.Errorf("state %s (%s), want pending", sub.State, sub.LastError)
+	}
+	if sub.Secret == "" {
+		t.Fatal("subscription has no secret")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if contentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	want := map[string]string{
+		"hub.mode":          WebSubModeSubscribe,
+		"hub.topic":         testTopic,
+		"hub.callback":      fmt.Sprintf("%s/websub/callback/%d", testBaseURL, sub.ID),
+		"hub.secret":        sub.Secret,
+		"hub.lease_seconds": "86400",
+	}
+	for key, value := range want {
+		if got := form.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestEnsureWebSubHubError(t *testing.T) {
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "topic not allowed", http.StatusForbidden)
+	}))
+	defer hub.Close()
+
+	fetcher, db := newTestWebSubFetcher(t)
+	fetcher.client = hub.Client()
+	rssFeed := &model.RSSFeed{Name: "Example", URL: testTopic, IsActive: true}
+	if err := db.Create(rssFeed).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher.ensureWebSub(context.Background(), rssFeed, &feed.Feed{Hub: hub.URL})
+
+	sub, err := fetcher.GetWebSubscription(rssFeed.ID)
+	if err != nil {
+		t.Fatalf("subscription not stored: %v", err)
+	}
+	if sub.State != model.WebSubStateFailed || sub.LastError != "hub returned HTTP 403: topic not allowed" {
+		t.Errorf("state %s (%q), want failed with the hub's answer", sub.State, sub.LastError)
+	}
+}
+
+func TestVerifyWebSub(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     string
+		mode      string
+		topic     string
+		wantErr   error
+		wantState string
+	}{
+		{name: "subscribe confirmed", state: model.WebSubStatePending, mode: WebSubModeSubscribe, topic: testTopic, wantState: model.WebSubStateActive},
+		{name: "renewal confirmed", state: model.WebSubStateActive, mode: WebSubModeSubscribe, topic: testTopic, wantState: model.WebSubStateActive},
+		{name: "topic mismatch", state: model.WebSubStatePending, mode: WebSubModeSubscribe, topic: "https://evil.example.com/feed.xml", wantErr: ErrWebSubVerification, wantState: model.WebSubStatePending},
+		{name: "unsubscribe rejected", state: model.WebSubStateActive, mode: WebSubModeUnsubscribe, topic: testTopic, wantErr: ErrWebSubVerification, wantState: model.WebSubStateActive},
+		{name: "denied subscription not confirmed", state: model.WebSubStateDenied, mode: WebSubModeSubscribe, topic: testTopic, wantErr: ErrWebSubVerification, wantState: model.WebSubStateDenied},
+		{name: "denial recorded", state: model.WebSubStatePending, mode: WebSubModeDenied, topic: testTopic, wantState: model.WebSubStateDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher, db := newTestWebSubFetcher(t)
+			sub := createTestSubscription(t, db, tt.state, "s3cret")
+
+			err := fetcher.VerifyWebSub(sub.ID, tt.mode, tt.topic, 3600, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, err := fetcher.GetWebSubscription(sub.FeedID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.State != tt.wantState {
+				t.Errorf("state = %s, want %s", stored.State, tt.wantState)
+			}
+			if tt.wantErr == nil && tt.wantState == model.WebSubStateActive &&
+				(stored.LeaseSeconds != 3600 || stored.ExpiresAt == nil) {
+				t.Errorf("lease %d, expires %v; want 3600 and an expiry", stored.LeaseSeconds, stored.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestVerifyWebSubUnknownSubscription(t *testing.T) {
+	fetcher, _ := newTestWebSubFetcher(t)
+	if err := fetcher.VerifyWebSub(42, WebSubModeSubscribe, testTopic, 0, ""); !errors.Is(err, ErrWebSubNotFound) {
+		t.Errorf("err = %v, want ErrWebSubNotFound", err)
+	}
+}
+
+const testPushBody = `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+<title>Example</title>
+<link>https://example.com/</link>
+<item><title>First</title><link>https://example.com/1</link><guid>1</guid><description>One</description></item>
+<item><title>Second</title><link>https://example.com/2</link><guid>2</guid><description>Two</description></item>
+</channel></rss>`
+
+func hubSignature(method string, newHash func() hash.Hash, secret, body string) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(body))
+	return method + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandlePush(t *testing.T) {
+	tests := []struct {
+		name        string
+		secret      string
+		signature   string
+		wantErr     error
+		wantEntries int64
+	}{
+		{name: "sha256 signature", secret: "s3cret", signature: hubSignature("sha256", sha256.New, "s3cret", testPushBody), wantEntries: 2},
+		{name: "sha1 signature", secret: "s3cret", signature: hubSignature("sha1", sha1.New, "s3cret", testPushBody), wantEntries: 2},
+		{name: "missing signature", secret: "s3cret", wantErr: ErrWebSubSignature},
+		{name: "wrong secret", secret: "s3cret", signature: hubSignature("sha256", sha256.New, "other", testPushBody), wantErr: ErrWebSubSignature},
+		{name: "signature of another body", secret: "s3cret", signature: hubSignature("sha256", sha256.New, "s3cret", "<rss/>"), wantErr: ErrWebSubSignature},
+		{name: "unsupported method", secret: "s3cret", signature: "md5=0123456789abcdef", wantErr: ErrWebSubSignature},
+		{name: "malformed signature", secret: "s3cret", signature: "sha256", wantErr: ErrWebSubSignature},
+		{name: "subscription without secret", signature: hubSignature("sha256", sha256.New, "", testPushBody), wantErr: ErrWebSubSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher, db := newTestWebSubFetcher(t)
+			sub := createTestSubscription(t, db, model.WebSubStateActive, tt.secret)
+
+			result, err := fetcher.HandlePush(context.Background(), sub.ID, tt.signature, "application/rss+xml", []byte(testPushBody))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			var entries int64
+			if err := db.Model(&model.FeedEntry{}).Where("feed_id = ?", sub.FeedID).Count(&entries).Error; err != nil {
+				t.Fatal(err)
+			}
+			if entries != tt.wantEntries {
+				t.Errorf("%d entries stored, want %d", entries, tt.wantEntries)
+			}
+
+			stored, err := fetcher.GetWebSubscription(sub.FeedID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != nil {
+				if stored.LastPushAt != nil {
+					t.Error("rejected push was recorded")
+				}
+				return
+			}
+			if result.New != 2 || result.Items != 2 {
+				t.Errorf("result %+v, want 2 new items", result)
+			}
+			if stored.LastPushAt == nil {
+				t.Error("push was not recorded")
+			}
+		})
+	}
+}
+
+func TestHandlePushInactiveSubscription(t *testing.T) {
+	fetcher, db := newTestWebSubFetcher(t)
+	sub := createTestSubscription(t, db, model.WebSubStatePending, "s3cret")
+
+	signature := hubSignature("sha256", sha256.New, "s3cret", testPushBody)
+	if _, err := fetcher.HandlePush(context.Background(), sub.ID, signature, "application/rss+xml", []byte(testPushBody)); !errors.Is(err, ErrWebSubNotFound) {
+		t.Errorf("err = %v, want ErrWebSubNotFound", err)
+	}
+}
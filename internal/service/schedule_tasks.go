@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+)
+
+// Scheduled task names
+const (
+	ScheduleTaskWebhookTrigger  = "webhook.trigger"
+	ScheduleTaskWorkflowTrigger = "workflow.trigger"
+	ScheduleTaskDataSourceCheck = "datasources.check"
+	ScheduleTaskHistoryPurge    = "webhook_history.purge"
+)
+
+// WebhookTriggerParams are the params of a webhook.trigger schedule. Without a
+// payload the body template of the webhook is sent.
+type WebhookTriggerParams struct {
+	WebhookID uint                   `json:"webhook_id"`
+	Payload   map[string]interface{} `json:"payload,omitempty"`
+	Variables map[string]string      `json:"variables,omitempty"`
+}
+
+// WorkflowTriggerParams are the params of a workflow.trigger schedule.
+type WorkflowTriggerParams struct {
+	Workflow string                 `json:"workflow"`
+	Payload  map[string]interface{} `json:"payload,omitempty"`
+}
+
+// DataSourceCheckParams are the params of a datasources.check schedule. An
+// empty list checks every active data source.
+type DataSourceCheckParams struct {
+	DataSourceIDs []uint `json:"data_source_ids,omitempty"`
+}
+
+// WebhookHistoryPurgeParams are the params of a webhook_history.purge schedule.
+type WebhookHistoryPurgeParams struct {
+	OlderThanDays int `json:"older_than_days"`
+}
+
+// RegisterScheduleTasks registers triggering a webhook and purging old
+// webhook history as scheduled tasks.
+func (s *WebhookService) RegisterScheduleTasks(sched *SchedulerService) {
+	sched.RegisterTask(ScheduleTaskWebhookTrigger,
+		func(raw json.RawMessage) error {
+			var params WebhookTriggerParams
+			if err := decodeParams(raw, &params); err != nil {
+				return err
+			}
+			if params.WebhookID == 0 {
+				return errors.New("webhook_id is required")
+			}
+			return nil
+		},
+		func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var params WebhookTriggerParams
+			if err := decodeParams(raw, &params); err != nil {
+				return nil, err
+			}
+			history, err := s.TriggerWithVariables(params.WebhookID, params.Payload, params.Variables)
+			if err != nil {
+				return nil, err
+			}
+			if history.Status != model.WebhookStatusSuccess {
+				return nil, fmt.Errorf("webhook delivery %d is %s after HTTP %d", history.ID, history.Status, history.ResponseCode)
+			}
+			return map[string]interface{}{
+				"history_id":    history.ID,
+				"response_code": history.ResponseCode,
+				"duration_ms":   history.DurationMs,
+			}, nil
+		})
+
+	sched.RegisterTask(ScheduleTaskHistoryPurge,
+		func(raw json.RawMessage) error {
+			var params WebhookHistoryPurgeParams
+			if err := decodeParams(raw, &params); err != nil {
+				return err
+			}
+			if params.OlderThanDays < 1 {
+				return errors.New("older_than_days must be at least 1")
+			}
+			return nil
+		},
+		func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var params WebhookHistoryPurgeParams
+			if err := decodeParams(raw, &params); err != nil {
+				return nil, err
+			}
+			deleted, err := s.PurgeHistory(time.Now().AddDate(0, 0, -params.OlderThanDays))
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"deleted": deleted}, nil
+		})
+}
+
+// RegisterScheduleTasks registers triggering an n8n workflow as a scheduled task.
+func (s *WorkflowService) RegisterScheduleTasks(sched *SchedulerService) {
+	sched.RegisterTask(ScheduleTaskWorkflowTrigger,
+		func(raw json.RawMessage) error {
+			var params WorkflowTriggerParams
+			if err := decodeParams(raw, &params); err != nil {
+				return err
+			}
+			if params.Workflow == "" {
+				return errors.New("workflow is required")
+			}
+			return nil
+		},
+		func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var params WorkflowTriggerParams
+			if err := decodeParams(raw, &params); err != nil {
+				return nil, err
+			}
+			return s.Trigger(params.Workflow, params.Payload)
+		})
+}
+
+// RegisterScheduleTasks registers an immediate reachability check of data
+// sources as a scheduled task. The result counts the sources per state.
+func (s *ReachabilityService) RegisterScheduleTasks(sched *SchedulerService) {
+	sched.RegisterTask(ScheduleTaskDataSourceCheck,
+		func(raw json.RawMessage) error {
+			var params DataSourceCheckParams
+			return decodeParams(raw, &params)
+		},
+		func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+			var params DataSourceCheckParams
+			if err := decodeParams(raw, &params); err != nil {
+				return nil, err
+			}
+			return s.CheckAll(ctx, params.DataSourceIDs)
+		})
+}
+
+// decodeParams decodes the params of a schedule, rejecting unknown fields so
+// that a typo does not silently fall back to a default. Empty params decode
+// as an empty object.
+func decodeParams(raw json.RawMessage, v interface{}) error {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
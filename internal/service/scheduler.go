@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/cron"
+	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidSchedule wraps validation errors of a schedule's cron expression, timezone, task or params.
+	ErrInvalidSchedule = errors.New("invalid schedule")
+
+	// ErrScheduleNameTaken is returned when a schedule is saved under the name of another one.
+	ErrScheduleNameTaken = errors.New("schedule name already in use")
+
+	// ErrScheduleRunning is returned when a schedule is run while its previous run has not finished.
+	ErrScheduleRunning = errors.New("schedule is already running")
+)
+
+// ScheduleTaskFunc runs a scheduled task with the params stored on the
+// schedule. The returned value is stored as the result of the run.
+type ScheduleTaskFunc func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+type scheduleTask struct {
+	validate func(params json.RawMessage) error
+	run      ScheduleTaskFunc
+}
+
+// SchedulerService runs the schedules stored in the schedules table whenever
+// their cron expression activates. A due schedule is advanced to its next
+// activation before it runs, with a conditional update, so several processes
+// can share the table and activations missed while the server was down
+// collapse into a single late run.
+type SchedulerService struct {
+	cfg     config.SchedulerConfig
+	repo    *repository.ScheduleRepository
+	tasks   map[string]scheduleTask
+	running sync.Map // schedule ID -> struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewSchedulerService(cfg config.SchedulerConfig, repo *repository.ScheduleRepository) *SchedulerService {
+	return &SchedulerService{
+		cfg:   cfg,
+		repo:  repo,
+		tasks: make(map[string]scheduleTask),
+	}
+}
+
+// RegisterTask makes a task available to schedules. validate checks the
+// params when a schedule is saved. It must be called before Start.
+func (s *SchedulerService) RegisterTask(name string, validate func(params json.RawMessage) error, run ScheduleTaskFunc) {
+	s.tasks[name] = scheduleTask{validate: validate, run: run}
+}
+
+// Tasks returns the names of the registered tasks
+func (s *SchedulerService) Tasks() []string {
+	names := make([]string, 0, len(s.tasks))
+	for name := range s.tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *SchedulerService) List(page, perPage int) ([]model.Schedule, int64, error) {
+	return s.repo.List(page, perPage)
+}
+
+func (s *SchedulerService) GetByID(id uint) (*model.Schedule, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *SchedulerService) Create(schedule *model.Schedule) error {
+	if err := s.prepare(schedule); err != nil {
+		return err
+	}
+	return s.repo.Create(schedule)
+}
+
+func (s *SchedulerService) Update(schedule *model.Schedule) error {
+	if err := s.prepare(schedule); err != nil {
+		return err
+	}
+	return s.repo.Update(schedule)
+}
+
+func (s *SchedulerService) Delete(id uint) error {
+	return s.repo.Delete(id)
+}
+
+// Preview returns the next n activations of a cron expression in the given
+// timezone, or in the default one when it is empty.
+func (s *SchedulerService) Preview(expr, timezone string, n int) ([]time.Time, error) {
+	spec, loc, err := s.parse(expr, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]time.Time, 0, n)
+	t := time.Now().In(loc)
+	for len(runs) < n {
+		t = spec.Next(t)
+		if t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+	return runs, nil
+}
+
+// RunNow runs a schedule immediately, whether or not it is active, and
+// returns it with the outcome. The next scheduled run is left unchanged.
+func (s *SchedulerService) RunNow(ctx context.Context, id uint) (*model.Schedule, error) {
+	schedule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.acquire(schedule.ID) {
+		return nil, ErrScheduleRunning
+	}
+	defer s.release(schedule.ID)
+
+	s.execute(ctx, schedule)
+	return schedule, nil
+}
+
+// prepare validates a schedule before it is stored and computes its next run.
+func (s *SchedulerService) prepare(schedule *model.Schedule) error {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	schedule.Cron = strings.TrimSpace(schedule.Cron)
+	schedule.Timezone = strings.TrimSpace(schedule.Timezone)
+	if schedule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSchedule)
+	}
+
+	task, ok := s.tasks[schedule.Task]
+	if !ok {
+		return fmt.Errorf("%w: unknown task %q", ErrInvalidSchedule, schedule.Task)
+	}
+	if err := task.validate(json.RawMessage(schedule.Params)); err != nil {
+		return fmt.Errorf("%w: params: %v", ErrInvalidSchedule, err)
+	}
+
+	spec, loc, err := s.parse(schedule.Cron, schedule.Timezone)
+	if err != nil {
+		return err
+	}
+	next := spec.Next(time.Now().In(loc))
+	if next.IsZero() {
+		return fmt.Errorf("%w: cron expression %q never activates", ErrInvalidSchedule, schedule.Cron)
+	}
+	schedule.NextRunAt = nil
+	if schedule.IsActive {
+		schedule.NextRunAt = &next
+	}
+
+	existing, err := s.repo.GetByName(schedule.Name)
+	if err == nil && existing.ID != schedule.ID {
+		return ErrScheduleNameTaken
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// parse parses a cron expression and loads its timezone.
+func (s *SchedulerService) parse(expr, timezone string) (*cron.Schedule, *time.Location, error) {
+	spec, err := cron.Parse(expr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	if timezone == "" {
+		timezone = s.cfg.Timezone
+	}
+	loc := time.Local
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, timezone)
+		}
+	}
+	return spec, loc, nil
+}
+
+// Start launches the loop that runs due schedules. It is a no-op when the scheduler is disabled.
+func (s *SchedulerService) Start() {
+	if !s.cfg.Enabled {
+		log.Println("Scheduler disabled by configuration")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.run(ctx)
+	log.Printf("Scheduler started (poll interval %ds)", s.cfg.PollInterval)
+}
+
+// Stop cancels running schedules and waits for them to finish.
+func (s *SchedulerService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	log.Println("Scheduler stopped")
+}
+
+func (s *SchedulerService) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	interval := time.Duration(s.cfg.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.runDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runDue(ctx)
+		}
+	}
+}
+
+// runDue advances every due schedule to its next activation and starts the
+// runs this process won. A schedule whose previous run is still going skips
+// the activation.
+func (s *SchedulerService) runDue(ctx context.Context) {
+	now := time.Now()
+	schedules, err := s.repo.GetDue(now)
+	if err != nil {
+		log.Printf("warn: scheduler failed to load due schedules: %v", err)
+		return
+	}
+
+	for i := range schedules {
+		schedule := &schedules[i]
+
+		spec, loc, err := s.parse(schedule.Cron, schedule.Timezone)
+		if err != nil {
+			// Edited outside the API: park it instead of failing every poll
+			log.Printf("warn: schedule %d (%s) paused until it is saved again: %v", schedule.ID, schedule.Name, err)
+			if _, err := s.repo.Advance(schedule.ID, *schedule.NextRunAt, nil); err != nil {
+				log.Printf("warn: failed to pause schedule %d: %v", schedule.ID, err)
+			}
+			continue
+		}
+
+		var next *time.Time
+		if t := spec.Next(now.In(loc)); !t.IsZero() {
+			next = &t
+		}
+		won, err := s.repo.Advance(schedule.ID, *schedule.NextRunAt, next)
+		if err != nil {
+			log.Printf("warn: failed to advance schedule %d: %v", schedule.ID, err)
+			continue
+		}
+		if !won {
+			continue
+		}
+		schedule.NextRunAt = next
+
+		if !s.acquire(schedule.ID) {
+			log.Printf("warn: schedule %d (%s) skipped, its previous run has not finished", schedule.ID, schedule.Name)
+			continue
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.release(schedule.ID)
+			s.execute(ctx, schedule)
+		}()
+	}
+}
+
+// execute runs the task of a schedule and records the outcome.
+func (s *SchedulerService) execute(ctx context.Context, schedule *model.Schedule) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	start := time.Now()
+	result, err := s.call(ctx, schedule)
+
+	schedule.LastRunAt = &start
+	schedule.LastDurationMs = int(time.Since(start).Milliseconds())
+	schedule.LastResult = nil
+	if err != nil {
+		schedule.LastStatus = model.ScheduleStatusFailed
+		schedule.LastError = err.Error()
+		log.Printf("warn: schedule %d (%s) failed: %v", schedule.ID, schedule.Name, err)
+	} else {
+		schedule.LastStatus = model.ScheduleStatusSucceeded
+		schedule.LastError = ""
+		if data, marshalErr := json.Marshal(result); marshalErr == nil {
+			schedule.LastResult = data
+		} else {
+			log.Printf("warn: schedule %d result could not be stored: %v", schedule.ID, marshalErr)
+		}
+	}
+
+	if err := s.repo.RecordRun(schedule); err != nil {
+		log.Printf("warn: failed to record run of schedule %d: %v", schedule.ID, err)
+	}
+}
+
+// call runs the task of a schedule, turning a panic into an error.
+func (s *SchedulerService) call(ctx context.Context, schedule *model.Schedule) (result interface{}, err error) {
+	task, ok := s.tasks[schedule.Task]
+	if !ok {
+		return nil, fmt.Errorf("unknown task %q", schedule.Task)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return task.run(ctx, json.RawMessage(schedule.Params))
+}
+
+// acquire marks a schedule as running and reports false when it already was.
+func (s *SchedulerService) acquire(id uint) bool {
+	_, running := s.running.LoadOrStore(id, struct{}{})
+	return !running
+}
+
+func (s *SchedulerService) release(id uint) {
+	s.running.Delete(id)
+}
+
+func (s *SchedulerService) timeout() time.Duration {
+	if s.cfg.Timeout <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(s.cfg.Timeout) * time.Minute
+}
@@ -16,19 +16,85 @@ type Services struct {
 	RagFlow    *RagFlowService
 	Health     *HealthService
 	Workflow   *WorkflowService
+	Jobs       *JobService
+	Scheduler  *SchedulerService
+	Events     *EventBus
+	Inbound    *InboundHookService
+
+	RSSFetcher   *RSSFetcher
+	Crawler      *CrawlerService
+	Watcher      *WatcherService
+	GitHub       *GitHubService
+	Reachability *ReachabilityService
 }
 
 // NewServices creates all service instances
 func NewServices(repos *repository.Repositories, cfg *config.Config, version string) *Services {
+	events := NewEventBus()
+	datasetSvc := NewDatasetService(repos.DatasetMapping, repos.Tag, events)
+	ragflowSvc := NewRagFlowService(cfg.RagFlow, repos.DatasetMapping, repos.Tag, events)
+	tagSvc := NewTagService(repos.Tag, events)
+	extractSvc := NewExtractService(cfg.RSS, repos.ExtractedPage)
+	dataSourceSvc := NewDataSourceService(repos.DataSource, repos.Tag, tagSvc, repos.PageSnapshot, datasetSvc, ragflowSvc, extractSvc, cfg.Features.URLDedup)
+	jobSvc := NewJobService(cfg.Jobs, repos.Job)
+	ragflowSvc.RegisterJobs(jobSvc)
+	rssFetcher := NewRSSFetcher(cfg.RSS, cfg.Features.URLDedup, repos.RSS, repos.FeedEntry, repos.WebSub, datasetSvc, ragflowSvc, extractSvc, events)
+	rssFetcher.RegisterJobs(jobSvc)
+	webhookSvc := NewWebhookService(cfg.Webhooks, repos.Webhook)
+	webhookSvc.RegisterEvents(events)
+	workflowSvc := NewWorkflowService(cfg.N8N, repos.Setting)
+	reachabilitySvc := NewReachabilityService(cfg.Reachability, cfg.RSS.UserAgent, repos.DataSource, repos.SourceStatus, events)
+	schedulerSvc := NewSchedulerService(cfg.Scheduler, repos.Schedule)
+	webhookSvc.RegisterScheduleTasks(schedulerSvc)
+	workflowSvc.RegisterScheduleTasks(schedulerSvc)
+	reachabilitySvc.RegisterScheduleTasks(schedulerSvc)
+
 	return &Services{
-		Tag:        NewTagService(repos.Tag),
-		DataSource: NewDataSourceService(repos.DataSource, repos.Tag),
-		RSS:        NewRSSService(repos.RSS, repos.Tag),
-		Webhook:    NewWebhookService(repos.Webhook),
-		Dataset:    NewDatasetService(repos.DatasetMapping, repos.Tag),
-		Setting:    NewSettingService(repos.Setting),
-		RagFlow:    NewRagFlowService(cfg.RagFlow, repos.DatasetMapping, repos.Tag),
-		Health:     NewHealthService(cfg, version, repos.Tag, repos.DataSource, repos.RSS, repos.DatasetMapping),
-		Workflow:   NewWorkflowService(cfg.N8N, repos.Setting),
+		Tag:          tagSvc,
+		DataSource:   dataSourceSvc,
+		RSS:          NewRSSService(repos.RSS, repos.FeedEntry, repos.Tag, tagSvc),
+		Webhook:      webhookSvc,
+		Dataset:      datasetSvc,
+		Setting:      NewSettingService(repos.Setting),
+		RagFlow:      ragflowSvc,
+		Health:       NewHealthService(cfg, version, repos.Tag, repos.DataSource, repos.SourceStatus, repos.RSS, repos.DatasetMapping),
+		Workflow:     workflowSvc,
+		Jobs:         jobSvc,
+		Scheduler:    schedulerSvc,
+		Events:       events,
+		Inbound:      NewInboundHookService(repos.InboundHook, datasetSvc, ragflowSvc, cfg.Features.URLDedup),
+		RSSFetcher:   rssFetcher,
+		Crawler:      NewCrawlerService(cfg.Crawler, cfg.Features.URLDedup, repos.DataSource, repos.Candidate, datasetSvc, extractSvc),
+		Watcher:      NewWatcherService(cfg.Watch, repos.DataSource, repos.PageSnapshot, dataSourceSvc),
+		Reachability: reachabilitySvc,
+		GitHub:       NewGitHubService(cfg.GitHub, cfg.RSS.UserAgent, cfg.Features.URLDedup, repos.DataSource, repos.GitHubItem, dataSourceSvc),
 	}
 }
+
+// Start launches background workers such as the event bus, the job queue, the
+// scheduler, the webhook deliveries, the RSS fetcher, the website crawler, the page watcher, the
+// GitHub sync and the reachability checker.
+func (s *Services) Start() {
+	s.Events.Start()
+	s.Jobs.Start()
+	s.Scheduler.Start()
+	s.Webhook.Start()
+	s.RSSFetcher.Start()
+	s.Crawler.Start()
+	s.Watcher.Start()
+	s.GitHub.Start()
+	s.Reachability.Start()
+}
+
+// Stop shuts down background workers and waits for them to finish.
+func (s *Services) Stop() {
+	s.Scheduler.Stop()
+	s.Webhook.Stop()
+	s.RSSFetcher.Stop()
+	s.Crawler.Stop()
+	s.Watcher.Stop()
+	s.GitHub.Stop()
+	s.Reachability.Stop()
+	s.Jobs.Stop()
+	s.Events.Stop()
+}
@@ -7,11 +7,12 @@ import (
 )
 
 type TagService struct {
-	repo *repository.TagRepository
+	repo   *repository.TagRepository
+	events *EventBus
 }
 
-func NewTagService(repo *repository.TagRepository) *TagService {
-	return &TagService{repo: repo}
+func NewTagService(repo *repository.TagRepository, events *EventBus) *TagService {
+	return &TagService{repo: repo, events: events}
 }
 
 func (s *TagService) List(page, perPage int, keyword string) ([]model.Tag, int64, error) {
@@ -23,7 +24,11 @@ func (s *TagService) GetByID(id uint) (*model.Tag, error) {
 }
 
 func (s *TagService) Create(tag *model.Tag) error {
-	return s.repo.Create(tag)
+	if err := s.repo.Create(tag); err != nil {
+		return err
+	}
+	s.events.Publish(tagCreatedEvent(tag))
+	return nil
 }
 
 func (s *TagService) Update(tag *model.Tag) error {
@@ -40,7 +45,7 @@ func (s *TagService) GetOrCreateByNames(names []string) ([]model.Tag, error) {
 		tag, err := s.repo.GetByName(name)
 		if err != nil {
 			tag = &model.Tag{Name: name, Color: defaults.DefaultTagColor}
-			if err := s.repo.Create(tag); err != nil {
+			if err := s.Create(tag); err != nil {
 				return nil, err
 			}
 		}
@@ -92,7 +97,7 @@ func (s *TagService) BatchGetOrCreate(names []string, autoCreate bool) (found []
 			found = append(found, t)
 		} else if autoCreate {
 			tag := &model.Tag{Name: name, Color: defaults.DefaultTagColor}
-			if err := s.repo.Create(tag); err != nil {
+			if err := s.Create(tag); err != nil {
 				return nil, nil, nil, err
 			}
 			created = append(created, *tag)
@@ -102,3 +107,15 @@ func (s *TagService) BatchGetOrCreate(names []string, autoCreate bool) (found []
 	}
 	return found, created, notFound, nil
 }
+
+// tagCreatedEvent describes a new tag; the event is tagged with it
+func tagCreatedEvent(tag *model.Tag) Event {
+	event := NewEvent(EventTagCreated, map[string]interface{}{
+		"tag_id":      tag.ID,
+		"name":        tag.Name,
+		"color":       tag.Color,
+		"description": tag.Description,
+	})
+	event.Tags = []string{tag.Name}
+	return event
+}
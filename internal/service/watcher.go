@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/config"
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Watch errors
+var (
+	// ErrNotWatched is returned when checking a data source that is not marked as watched
+	ErrNotWatched = errors.New("data source is not watched")
+	// ErrCheckInProgress is returned when a data source is checked while another check of it is still running
+	ErrCheckInProgress = errors.New("data source is already being checked")
+)
+
+// Watch check statuses
+const (
+	WatchStatusBaseline  = "baseline"  // first check, the current page became the snapshot
+	WatchStatusUnchanged = "unchanged" // same normalized text
+	WatchStatusMinor     = "minor"     // changed less than min_change, ignored
+	WatchStatusChanged   = "changed"   // changed materially, new version uploaded when the page was ingested
+)
+
+// WatchResult describes a single change check of a watched data source.
+type WatchResult struct {
+	DataSourceID uint    `json:"data_source_id"`
+	Status       string  `json:"status"`
+	Message      string  `json:"message,omitempty"`
+	Change       float64 `json:"change"` // changed share of the text compared to the snapshot
+	Length       int     `json:"length"`
+	DocumentID   string  `json:"document_id,omitempty"`
+	DatasetID    string  `json:"dataset_id,omitempty"`
+	// PreviousDocumentID is the stale RagFlow document replaced by DocumentID
+	PreviousDocumentID string `json:"previous_document_id,omitempty"`
+}
+
+// WatcherService periodically re-fetches watched data sources and compares the
+// extracted text with the snapshot of the last ingested version. Material
+// changes are uploaded to the same RagFlow dataset as a new document, the
+// article-tag associations are moved over and the stale document is deleted.
+type WatcherService struct {
+	cfg          config.WatchConfig
+	repo         *repository.DataSourceRepository
+	snapshotRepo *repository.PageSnapshotRepository
+	sources      *DataSourceService
+
+	// inflight guards against checking the same data source twice concurrently
+	inflight sync.Map
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWatcherService fetches and uploads pages through the data source service so
+// checks produce the same documents as a manual ingest.
+func NewWatcherService(
+	cfg config.WatchConfig,
+	repo *repository.DataSourceRepository,
+	snapshotRepo *repository.PageSnapshotRepository,
+	sources *DataSourceService,
+) *WatcherService {
+	return &WatcherService{
+		cfg:          cfg,
+		repo:         repo,
+		snapshotRepo: snapshotRepo,
+		sources:      sources,
+	}
+}
+
+// Start launches the background check loop. It is a no-op when watching is disabled.
+func (s *WatcherService) Start() {
+	if !s.cfg.Enabled {
+		log.Println("Page watcher disabled by configuration")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.run(ctx)
+	log.Printf("Page watcher started (poll interval %ds, check interval %dm)", s.cfg.PollInterval, s.cfg.Interval)
+}
+
+// Stop cancels the running check and waits for the loop to exit.
+func (s *WatcherService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	log.Println("Page watcher stopped")
+}
+
+func (s *WatcherService) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	interval := time.Duration(s.cfg.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.checkDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkDue(ctx)
+		}
+	}
+}
+
+// checkDue checks every watched data source whose check interval has elapsed.
+func (s *WatcherService) checkDue(ctx context.Context) {
+	sources, err := s.repo.GetDueForWatch(time.Now().Add(-time.Duration(s.cfg.Interval) * time.Minute))
+	if err != nil {
+		log.Printf("warn: watcher failed to load data sources: %v", err)
+		return
+	}
+
+	for i := range sources {
+		if ctx.Err() != nil {
+			return
+		}
+		result, err := s.Check(ctx, &sources[i])
+		if err != nil {
+			log.Printf("warn: change check failed for data source %d (%s): %v", sources[i].ID, sources[i].URL, err)
+			continue
+		}
+		if result.Status == WatchStatusChanged {
+			log.Printf("Data source %d changed (%.1f%% of text): document %s replaced by %s",
+				result.DataSourceID, result.Change*100, result.PreviousDocumentID, result.DocumentID)
+		}
+	}
+}
+
+// GetSnapshot returns the snapshot of a watched data source
+func (s *WatcherService) GetSnapshot(dataSourceID uint) (*model.PageSnapshot, error) {
+	return s.snapshotRepo.GetByDataSourceID(dataSourceID)
+}
+
+// Check re-fetches a watched data source and compares it with its snapshot. The
+// first check records the current page as the baseline. A material change of
+// an ingested page uploads the new version and replaces the stale document;
+// pages that were never ingested only get their snapshot updated. The check
+// time and error are always recorded so failing pages wait for the next interval.
+func (s *WatcherService) Check(ctx context.Context, source *model.DataSource) (*WatchResult, error) {
+	if !source.Watch {
+		return nil, ErrNotWatched
+	}
+	if _, busy := s.inflight.LoadOrStore(source.ID, struct{}{}); busy {
+		return nil, fmt.Errorf("data source %d: %w", source.ID, ErrCheckInProgress)
+	}
+	defer s.inflight.Delete(source.ID)
+
+	snapshot, err := s.snapshotRepo.GetByDataSourceID(source.ID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		snapshot = &model.PageSnapshot{DataSourceID: source.ID}
+	}
+
+	result, err := s.check(ctx, source, snapshot)
+	if ctx.Err() != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	snapshot.CheckedAt = &now
+	snapshot.LastError = ""
+	if err != nil {
+		snapshot.LastError = err.Error()
+	}
+	if saveErr := s.snapshotRepo.Save(snapshot); saveErr != nil {
+		if err != nil {
+			log.Printf("warn: failed to save snapshot of data source %d: %v", source.ID, saveErr)
+			return result, err
+		}
+		return result, fmt.Errorf("failed to save snapshot: %w", saveErr)
+	}
+	return result, err
+}
+
+func (s *WatcherService) check(ctx context.Context, source *model.DataSource, snapshot *model.PageSnapshot) (*WatchResult, error) {
+	// Always bypass the extraction cache, which would hide the change
+	page, _, err := s.sources.fetchPage(ctx, source, true)
+	if err != nil {
+		return nil, err
+	}
+	text := normalizeText(page.Content)
+	hash := contentHash(text)
+
+	if snapshot.DocumentID == "" {
+		s.sources.adoptDocument(source, snapshot)
+	}
+	result := &WatchResult{
+		DataSourceID: source.ID,
+		Length:       page.Length,
+		DocumentID:   snapshot.DocumentID,
+		DatasetID:    snapshot.DatasetID,
+	}
+
+	if snapshot.ContentHash == "" {
+		result.Status = WatchStatusBaseline
+		setSnapshotContent(snapshot, source, page, text, hash)
+		return result, nil
+	}
+	if hash == snapshot.ContentHash {
+		result.Status = WatchStatusUnchanged
+		snapshot.LastChange = 0
+		return result, nil
+	}
+
+	// Minor edits leave the snapshot alone, so they add up until they are material
+	result.Change = changeRatio(snapshot.Content, text)
+	snapshot.LastChange = result.Change
+	if result.Change < s.cfg.MinChange {
+		result.Status = WatchStatusMinor
+		return result, nil
+	}
+
+	result.Status = WatchStatusChanged
+	if snapshot.DocumentID == "" {
+		result.Message = "page is not ingested, snapshot updated"
+	} else {
+		title, content := pageDocument(source, page)
+		documentID, err := s.sources.replaceDocument(ctx, source, snapshot.DatasetID, snapshot.DocumentID, title, source.URL, content)
+		if err != nil {
+			if documentID == "" {
+				return result, err
+			}
+			// The new version is in place, only the stale document is left behind
+			result.Message = err.Error()
+		}
+		result.PreviousDocumentID = snapshot.DocumentID
+		result.DocumentID = documentID
+		snapshot.DocumentID = documentID
+		snapshot.Version++
+	}
+
+	now := time.Now()
+	snapshot.ChangedAt = &now
+	setSnapshotContent(snapshot, source, page, text, hash)
+	return result, nil
+}
+
+// replaceDocument uploads a new version of a document from docURL to the
+// dataset of the stale document, moves the article-tag associations to it and
+// deletes the stale document. A failed deletion returns the new document ID
+// along with the error.
+func (s *DataSourceService) replaceDocument(ctx context.Context, source *model.DataSource, datasetID, staleID, title, docURL, content string) (string, error) {
+	resp, err := s.ragflowSvc.upload(ctx, datasetID, &UploadRequest{
+		Content:  content,
+		Filename: documentFilename(title, fmt.Sprintf("datasource-%d", source.ID)),
+		Title:    title,
+		URL:      docURL,
+		Tags:     tagNames(source.Tags),
+		Category: source.Category,
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Code != 0 {
+		return "", fmt.Errorf("RagFlow error %d: %s", resp.Code, resp.Message)
+	}
+	documentID, _ := resp.Data["id"].(string)
+	if documentID == "" {
+		return "", errors.New("RagFlow returned no document ID")
+	}
+
+	// Described by the tags it has before they move to the new document
+	staleTitle, staleURL, staleTags := s.ragflowSvc.documentInfo(staleID)
+	if err := s.datasetSvc.repo.RepointArticleTags(staleID, documentID, datasetID, title); err != nil {
+		// Keep the stale document the tags still point to and drop the new one
+		if delErr := s.ragflowSvc.DeleteDocument(ctx, datasetID, documentID); delErr != nil {
+			log.Printf("warn: failed to delete orphaned document %s: %v", documentID, delErr)
+		}
+		return "", fmt.Errorf("failed to move article tags: %w", err)
+	}
+
+	if err := s.ragflowSvc.deleteDocument(ctx, datasetID, staleID, staleTitle, staleURL, staleTags); err != nil {
+		return documentID, fmt.Errorf("failed to delete stale document %s: %w", staleID, err)
+	}
+	return documentID, nil
+}
+
+// adoptDocument links the snapshot to the RagFlow document already ingested for
+// the data source URL, if any.
+func (s *DataSourceService) adoptDocument(source *model.DataSource, snapshot *model.PageSnapshot) {
+	check, err := s.datasetSvc.CheckURL(source.URL, true, false)
+	if err != nil {
+		log.Printf("warn: failed to look up document of data source %d: %v", source.ID, err)
+		return
+	}
+	if check.Exists {
+		snapshot.DocumentID = check.DocumentID
+		snapshot.DatasetID = check.DatasetID
+	}
+}
+
+// recordIngest makes a freshly ingested page the snapshot of a watched data source.
+func (s *DataSourceService) recordIngest(source *model.DataSource, page *model.ExtractedPage, result *DataSourceIngestResult) {
+	snapshot, err := s.snapshotRepo.GetByDataSourceID(source.ID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("warn: failed to load snapshot of data source %d: %v", source.ID, err)
+			return
+		}
+		snapshot = &model.PageSnapshot{DataSourceID: source.ID}
+	}
+
+	text := normalizeText(page.Content)
+	now := time.Now()
+	setSnapshotContent(snapshot, source, page, text, contentHash(text))
+	snapshot.DocumentID = result.DocumentID
+	snapshot.DatasetID = result.DatasetID
+	snapshot.LastChange = 0
+	snapshot.CheckedAt = &now
+	snapshot.LastError = ""
+	if err := s.snapshotRepo.Save(snapshot); err != nil {
+		log.Printf("warn: failed to save snapshot of data source %d: %v", source.ID, err)
+	}
+}
+
+func setSnapshotContent(snapshot *model.PageSnapshot, source *model.DataSource, page *model.ExtractedPage, text, hash string) {
+	snapshot.URL = truncateRunes(source.URL, 2000)
+	snapshot.Content = text
+	snapshot.ContentHash = hash
+	snapshot.Length = page.Length
+}
+
+// normalizeText collapses whitespace within lines and drops blank lines, so
+// reformatting alone never counts as a change.
+func normalizeText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// changeRatio returns the share of the text, by length, in lines that were
+// added or removed between the two versions. Moved lines do not count.
+func changeRatio(before, after string) float64 {
+	remaining := map[string]int{}
+	total := 0
+	for _, line := range strings.Split(before, "\n") {
+		remaining[line]++
+		total += len(line)
+	}
+
+	changed := 0
+	for _, line := range strings.Split(after, "\n") {
+		total += len(line)
+		if remaining[line] > 0 {
+			remaining[line]--
+			continue
+		}
+		changed += len(line)
+	}
+	for line, n := range remaining {
+		changed += n * len(line)
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return float64(changed) / float64(total)
+}
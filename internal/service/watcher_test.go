@@ -0,0 +1,32 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChangeRatio(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          float64
+	}{
+		{"identical", "a\nb\nc", "a\nb\nc", 0},
+		{"both empty", "", "", 0},
+		{"reordered lines", "alpha\nbeta", "beta\nalpha", 0},
+		{"one line replaced", "a\nb", "a\nc", 0.5},
+		{"everything replaced", "abc", "xyz", 1},
+		{"from empty", "", "abcd", 1},
+		{"appended line", "aaaa\nbbbb", "aaaa\nbbbb\ncc", 2.0 / 18},
+		{"removed duplicate", "x\nx", "x", 1.0 / 3},
+		{"changes weigh by length", "short\na much longer line", "short\na much longer line!", 37.0 / 47},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changeRatio(tt.before, tt.after); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("changeRatio = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,41 +1,106 @@
 package service
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/singll/bellkeeper/internal/config"
 	"github.com/singll/bellkeeper/internal/model"
 	"github.com/singll/bellkeeper/internal/repository"
 )
 
+// defaultRetryStatusCodes are the response codes retried for webhooks that do not set their own
+var defaultRetryStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+var (
+	// ErrDeliveryNotDead is returned when a delivery that is not dead is requeued.
+	ErrDeliveryNotDead = errors.New("only dead deliveries can be requeued")
+
+	// ErrWebhookDisabled is returned when a disabled webhook is asked to deliver.
+	ErrWebhookDisabled = errors.New("webhook is disabled")
+
+	// ErrWebhookNotFound is returned when a webhook does not exist.
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	// ErrWebhookHistoryNotFound is returned when a history record does not exist.
+	ErrWebhookHistoryNotFound = errors.New("webhook history not found")
+)
+
+// WebhookService manages webhooks and delivers them. A delivery whose attempt
+// fails with a retryable error is retried in the background following the
+// retry policy of its webhook; the retry state lives on the delivery record,
+// so pending retries survive restarts. Deliveries of the events a webhook
+// subscribes to are queued the same way and made by the background loop.
 type WebhookService struct {
+	cfg  config.WebhooksConfig
 	repo *repository.WebhookRepository
+	wake chan struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
-func NewWebhookService(repo *repository.WebhookRepository) *WebhookService {
-	return &WebhookService{repo: repo}
+func NewWebhookService(cfg config.WebhooksConfig, repo *repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		cfg:  cfg,
+		repo: repo,
+		wake: make(chan struct{}, 1),
+	}
 }
 
 func (s *WebhookService) List(page, perPage int) ([]model.WebhookConfig, int64, error) {
-	return s.repo.List(page, perPage)
+	webhooks, total, err := s.repo.List(page, perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range webhooks {
+		markWebhookSigned(&webhooks[i])
+	}
+	return webhooks, total, nil
 }
 
 func (s *WebhookService) GetByID(id uint) (*model.WebhookConfig, error) {
-	return s.repo.GetByID(id)
+	webhook, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	markWebhookSigned(webhook)
+	return webhook, nil
 }
 
 func (s *WebhookService) Create(webhook *model.WebhookConfig) error {
-	return s.repo.Create(webhook)
+	if err := s.repo.Create(webhook); err != nil {
+		return err
+	}
+	markWebhookSigned(webhook)
+	return nil
 }
 
 func (s *WebhookService) Update(webhook *model.WebhookConfig) error {
-	return s.repo.Update(webhook)
+	if err := s.repo.Update(webhook); err != nil {
+		return err
+	}
+	markWebhookSigned(webhook)
+	return nil
+}
+
+// markWebhookSigned flags webhooks whose deliveries are signed
+func markWebhookSigned(webhook *model.WebhookConfig) {
+	webhook.Signed = webhook.SigningSecret != ""
 }
 
 func (s *WebhookService) Delete(id uint) error {
@@ -52,75 +117,30 @@ func (s *WebhookService) Trigger(id uint, payload map[string]interface{}) (*mode
 		return nil, err
 	}
 
-	// Create history record
 	history := &model.WebhookHistory{
 		WebhookID:     id,
 		RequestURL:    webhook.URL,
 		RequestMethod: webhook.Method,
-		Status:        "pending",
 	}
 
 	// Prepare request body
-	var bodyBytes []byte
 	if payload != nil {
-		bodyBytes, _ = json.Marshal(payload)
+		bodyBytes, _ := json.Marshal(payload)
 		history.RequestBody = string(bodyBytes)
 	} else if webhook.BodyTemplate != "" {
-		bodyBytes = []byte(webhook.BodyTemplate)
 		history.RequestBody = webhook.BodyTemplate
 	}
 
-	// Create history first
-	if err := s.repo.CreateHistory(history); err != nil {
-		return nil, err
-	}
-
-	// Make HTTP request
-	start := time.Now()
-	client := &http.Client{Timeout: time.Duration(webhook.TimeoutSeconds) * time.Second}
-
-	req, err := http.NewRequest(webhook.Method, webhook.URL, bytes.NewBuffer(bodyBytes))
-	if err != nil {
-		history.Status = "failed"
-		history.ErrorMessage = err.Error()
-		s.repo.UpdateHistory(history)
-		return history, err
-	}
-
-	req.Header.Set("Content-Type", webhook.ContentType)
-
-	// Add custom headers
+	headers := map[string]string{"Content-Type": webhook.ContentType}
 	if webhook.Headers != nil {
-		var headers map[string]string
-		json.Unmarshal(webhook.Headers, &headers)
-		for k, v := range headers {
-			req.Header.Set(k, v)
+		var custom map[string]string
+		json.Unmarshal(webhook.Headers, &custom)
+		for k, v := range custom {
+			headers[k] = v
 		}
 	}
 
-	resp, err := client.Do(req)
-	history.DurationMs = int(time.Since(start).Milliseconds())
-
-	if err != nil {
-		history.Status = "failed"
-		history.ErrorMessage = err.Error()
-		s.repo.UpdateHistory(history)
-		return history, err
-	}
-	defer resp.Body.Close()
-
-	history.ResponseCode = resp.StatusCode
-	respBody, _ := io.ReadAll(resp.Body)
-	history.ResponseBody = string(respBody)
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		history.Status = "success"
-	} else {
-		history.Status = "failed"
-	}
-
-	s.repo.UpdateHistory(history)
-	return history, nil
+	return s.deliver(webhook, history, headers)
 }
 
 // --- Batch D: 模板变量系统 ---
@@ -211,78 +231,40 @@ func (s *WebhookService) TriggerWithVariables(id uint, payload map[string]interf
 
 	variables := buildVariables(webhook, payload, customVars)
 
-	// Create history record
 	history := &model.WebhookHistory{
 		WebhookID:     id,
 		RequestURL:    processTemplate(webhook.URL, variables),
 		RequestMethod: webhook.Method,
-		Status:        "pending",
 	}
 
 	// Prepare request body with template processing
-	var bodyBytes []byte
 	if payload != nil {
 		processed := processTemplateMap(payload, variables)
-		bodyBytes, _ = json.Marshal(processed)
+		bodyBytes, _ := json.Marshal(processed)
 		history.RequestBody = string(bodyBytes)
 	} else if webhook.BodyTemplate != "" {
-		processed := processTemplate(webhook.BodyTemplate, variables)
-		bodyBytes = []byte(processed)
-		history.RequestBody = processed
-	}
-
-	if err := s.repo.CreateHistory(history); err != nil {
-		return nil, err
-	}
-
-	start := time.Now()
-	client := &http.Client{Timeout: time.Duration(webhook.TimeoutSeconds) * time.Second}
-
-	req, err := http.NewRequest(webhook.Method, history.RequestURL, bytes.NewBuffer(bodyBytes))
-	if err != nil {
-		history.Status = "failed"
-		history.ErrorMessage = err.Error()
-		s.repo.UpdateHistory(history)
-		return history, err
+		history.RequestBody = processTemplate(webhook.BodyTemplate, variables)
 	}
 
-	req.Header.Set("Content-Type", webhook.ContentType)
-
 	// Add custom headers with template processing
+	headers := map[string]string{"Content-Type": webhook.ContentType}
 	if webhook.Headers != nil {
-		var headers map[string]string
-		json.Unmarshal(webhook.Headers, &headers)
-		for k, v := range headers {
-			req.Header.Set(k, processTemplate(v, variables))
+		var custom map[string]string
+		json.Unmarshal(webhook.Headers, &custom)
+		for k, v := range custom {
+			headers[k] = processTemplate(v, variables)
 		}
 	}
 
-	resp, err := client.Do(req)
-	history.DurationMs = int(time.Since(start).Milliseconds())
-
-	if err != nil {
-		history.Status = "failed"
-		history.ErrorMessage = err.Error()
-		s.repo.UpdateHistory(history)
-		return history, err
-	}
-	defer resp.Body.Close()
-
-	history.ResponseCode = resp.StatusCode
-	respBody, _ := io.ReadAll(resp.Body)
-	history.ResponseBody = string(respBody)
-
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		history.Status = "success"
-	} else {
-		history.Status = "failed"
-	}
-
-	s.repo.UpdateHistory(history)
-	return history, nil
+	return s.deliver(webhook, history, headers)
 }
 
 // GetHistoryByStatus returns webhook history filtered by status
 func (s *WebhookService) GetHistoryByStatus(webhookID uint, status string, limit int) ([]model.WebhookHistory, error) {
 	return s.repo.GetHistoryByStatus(webhookID, status, limit)
 }
+
+// PurgeHistory deletes webhook deliveries created before the given time, with their attempts
+func (s *WebhookService) PurgeHistory(before time.Time) (int64, error) {
+	return s.repo.DeleteHistoryBefore(before)
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+	"github.com/singll/bellkeeper/internal/pkg/defaults"
+	"gorm.io/gorm"
+)
+
+// deliver stores a new delivery with the request headers and makes its first
+// attempt. The returned error is that of the first attempt; a retry may
+// already be scheduled when it is set.
+func (s *WebhookService) deliver(webhook *model.WebhookConfig, delivery *model.WebhookHistory, headers map[string]string) (*model.WebhookHistory, error) {
+	delivery.Status = model.WebhookStatusPending
+	delivery.MaxAttempts = maxAttempts(webhook)
+	if data, err := json.Marshal(headers); err == nil {
+		delivery.RequestHeaders = data
+	}
+
+	if err := s.repo.CreateHistory(delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, s.attempt(webhook, delivery)
+}
+
+// attempt sends the request of a delivery once, records the attempt and
+// moves the delivery to its next status.
+func (s *WebhookService) attempt(webhook *model.WebhookConfig, delivery *model.WebhookHistory) error {
+	delivery.Attempts++
+	record := &model.WebhookHistory{
+		WebhookID:      delivery.WebhookID,
+		ParentID:       &delivery.ID,
+		Attempt:        delivery.Attempts,
+		RequestURL:     delivery.RequestURL,
+		RequestMethod:  delivery.RequestMethod,
+		RequestHeaders: delivery.RequestHeaders,
+		RequestBody:    delivery.RequestBody,
+		Status:         model.WebhookStatusPending,
+	}
+	if err := s.repo.CreateHistory(record); err != nil {
+		log.Printf("warn: failed to record attempt %d of webhook delivery %d: %v", record.Attempt, delivery.ID, err)
+	}
+
+	retryable, err := s.send(webhook, record)
+	if record.ID != 0 {
+		if err := s.repo.UpdateHistory(record); err != nil {
+			log.Printf("warn: failed to update attempt %d of webhook delivery %d: %v", record.Attempt, delivery.ID, err)
+		}
+	}
+
+	delivery.ResponseCode = record.ResponseCode
+	delivery.ResponseBody = record.ResponseBody
+	delivery.DurationMs = record.DurationMs
+	delivery.ErrorMessage = record.ErrorMessage
+	delivery.NextRetryAt = nil
+
+	switch {
+	case record.Status == model.WebhookStatusSuccess:
+		delivery.Status = model.WebhookStatusSuccess
+	case retryable && delivery.Attempts < delivery.MaxAttempts:
+		next := time.Now().Add(retryBackoff(webhook, delivery.Attempts))
+		delivery.Status = model.WebhookStatusRetrying
+		delivery.NextRetryAt = &next
+	case retryable:
+		delivery.Status = model.WebhookStatusDead
+		log.Printf("warn: webhook delivery %d (%s) is dead after %d attempts", delivery.ID, webhook.Name, delivery.Attempts)
+	default:
+		delivery.Status = model.WebhookStatusFailed
+	}
+
+	if err := s.repo.UpdateHistory(delivery); err != nil {
+		log.Printf("warn: failed to update webhook delivery %d: %v", delivery.ID, err)
+	}
+	return err
+}
+
+// send makes the HTTP request stored on an attempt record and records the
+// response on it. It reports whether the failure, if any, is worth retrying:
+// network errors are, as are the retryable status codes of the webhook.
+func (s *WebhookService) send(webhook *model.WebhookConfig, record *model.WebhookHistory) (bool, error) {
+	start := time.Now()
+	client := &http.Client{Timeout: time.Duration(webhook.TimeoutSeconds) * time.Second}
+
+	req, err := http.NewRequest(record.RequestMethod, record.RequestURL, strings.NewReader(record.RequestBody))
+	if err != nil {
+		record.Status = model.WebhookStatusFailed
+		record.ErrorMessage = err.Error()
+		return false, err
+	}
+
+	headers := map[string]string{}
+	if record.RequestHeaders != nil {
+		json.Unmarshal(record.RequestHeaders, &headers)
+	}
+	// Signed per attempt, so that a retry carries a fresh timestamp
+	if webhook.SigningSecret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers[WebhookTimestampHeader] = timestamp
+		headers[WebhookSignatureHeader] = SignWebhook(webhook.SigningSecret, timestamp, []byte(record.RequestBody))
+		if data, err := json.Marshal(headers); err == nil {
+			record.RequestHeaders = data
+		}
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	record.DurationMs = int(time.Since(start).Milliseconds())
+
+	if err != nil {
+		record.Status = model.WebhookStatusFailed
+		record.ErrorMessage = err.Error()
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	record.ResponseCode = resp.StatusCode
+	respBody, _ := io.ReadAll(resp.Body)
+	record.ResponseBody = string(respBody)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		record.Status = model.WebhookStatusSuccess
+		return false, nil
+	}
+	record.Status = model.WebhookStatusFailed
+	return retryableStatus(webhook, resp.StatusCode), nil
+}
+
+// GetAttempts returns the attempt records of a delivery
+func (s *WebhookService) GetAttempts(deliveryID uint) ([]model.WebhookHistory, error) {
+	if _, err := s.repo.GetHistoryByID(deliveryID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAttempts(deliveryID)
+}
+
+// ListDead returns the deliveries that ran out of attempts, of all webhooks
+// or of one when webhookID is set
+func (s *WebhookService) ListDead(webhookID uint, page, perPage int) ([]model.WebhookHistory, int64, error) {
+	return s.repo.ListDeliveries(model.WebhookStatusDead, webhookID, page, perPage)
+}
+
+// Requeue schedules a dead delivery for an immediate retry and grants it as
+// many further attempts as its webhook allows.
+func (s *WebhookService) Requeue(deliveryID uint) (*model.WebhookHistory, error) {
+	delivery, err := s.repo.GetHistoryByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.ParentID != nil || delivery.Status != model.WebhookStatusDead {
+		return nil, ErrDeliveryNotDead
+	}
+	webhook, err := s.repo.GetByID(delivery.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery.Status = model.WebhookStatusRetrying
+	delivery.MaxAttempts = delivery.Attempts + maxAttempts(webhook)
+	delivery.NextRetryAt = &now
+	if err := s.repo.UpdateHistory(delivery); err != nil {
+		return nil, err
+	}
+
+	s.notify()
+	return delivery, nil
+}
+
+// Replay sends the stored request of a history record again as a new
+// delivery linked to it, with the retry policy and signing secret the webhook
+// has now. With currentURL the request goes to the webhook's current URL
+// instead of the stored one. The returned error is that of the first attempt.
+func (s *WebhookService) Replay(historyID uint, currentURL bool) (*model.WebhookHistory, error) {
+	source, err := s.repo.GetHistoryByID(historyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookHistoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	webhook, err := s.repo.GetByID(source.WebhookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.IsActive {
+		return nil, fmt.Errorf("%w: '%s'", ErrWebhookDisabled, webhook.Name)
+	}
+
+	headers := map[string]string{}
+	if source.RequestHeaders != nil {
+		json.Unmarshal(source.RequestHeaders, &headers)
+	} else {
+		// Recorded before request headers were stored
+		headers["Content-Type"] = webhook.ContentType
+	}
+	// The old signature has expired; a new one is added when sending
+	delete(headers, WebhookTimestampHeader)
+	delete(headers, WebhookSignatureHeader)
+
+	replay := &model.WebhookHistory{
+		WebhookID:     webhook.ID,
+		RequestURL:    source.RequestURL,
+		RequestMethod: source.RequestMethod,
+		RequestBody:   source.RequestBody,
+		ReplayOf:      &source.ID,
+		EventType:     source.EventType,
+	}
+	if currentURL {
+		replay.RequestURL = processTemplate(webhook.URL, buildVariables(webhook, nil, nil))
+	}
+
+	return s.deliver(webhook, replay, headers)
+}
+
+// notify wakes the loop so that it picks up a delivery that is due now.
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start launches the loop that makes the queued and retried attempts of due deliveries.
+func (s *WebhookService) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.run(ctx)
+	log.Printf("Webhook deliveries started (poll interval %ds)", s.cfg.PollInterval)
+}
+
+// Stop waits for the attempt in progress to finish.
+func (s *WebhookService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	log.Println("Webhook deliveries stopped")
+}
+
+func (s *WebhookService) run(ctx context.Context) {
+	defer s.wg.Done()
+
+	interval := time.Duration(s.cfg.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.retryDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// retryDue makes the next attempt of every due delivery this process claims,
+// the first one of queued event deliveries included.
+func (s *WebhookService) retryDue(ctx context.Context) {
+	deliveries, err := s.repo.GetDueRetries(time.Now(), defaults.WebhookRetryBatch)
+	if err != nil {
+		log.Printf("warn: failed to load due webhook retries: %v", err)
+		return
+	}
+
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		delivery := &deliveries[i]
+
+		webhook, err := s.repo.GetByID(delivery.WebhookID)
+		if err != nil || !webhook.IsActive {
+			s.bury(delivery, webhook, err)
+			continue
+		}
+
+		// Hold the retry for twice the timeout, after which a lost attempt is made again
+		until := time.Now().Add(2 * time.Duration(webhook.TimeoutSeconds) * time.Second)
+		won, err := s.repo.ClaimRetry(delivery.ID, *delivery.NextRetryAt, until)
+		if err != nil {
+			log.Printf("warn: failed to claim retry of webhook delivery %d: %v", delivery.ID, err)
+			continue
+		}
+		if !won {
+			continue
+		}
+
+		s.attempt(webhook, delivery)
+	}
+}
+
+// bury marks a delivery dead whose webhook was deleted or disabled since.
+func (s *WebhookService) bury(delivery *model.WebhookHistory, webhook *model.WebhookConfig, err error) {
+	delivery.Status = model.WebhookStatusDead
+	delivery.NextRetryAt = nil
+	if err != nil {
+		delivery.ErrorMessage = fmt.Sprintf("webhook unavailable: %v", err)
+	} else {
+		delivery.ErrorMessage = fmt.Sprintf("webhook '%s' is disabled", webhook.Name)
+	}
+	if err := s.repo.UpdateHistory(delivery); err != nil {
+		log.Printf("warn: failed to update webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func maxAttempts(webhook *model.WebhookConfig) int {
+	if webhook.MaxAttempts < 1 {
+		return 1
+	}
+	return webhook.MaxAttempts
+}
+
+// retryBackoff returns the delay before the retry that follows the given
+// attempt, doubling from the retry delay of the webhook.
+func retryBackoff(webhook *model.WebhookConfig, attempt int) time.Duration {
+	delay := time.Duration(webhook.RetryDelaySeconds) * time.Second
+	if delay <= 0 {
+		delay = defaults.DefaultWebhookRetryDelay * time.Second
+	}
+	limit := time.Duration(defaults.MaxWebhookBackoffMinutes) * time.Minute
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// retryableStatus reports whether a response code is retried for the webhook
+func retryableStatus(webhook *model.WebhookConfig, code int) bool {
+	codes := defaultRetryStatusCodes
+	if len(webhook.RetryStatusCodes) > 0 {
+		var custom []int
+		if err := json.Unmarshal(webhook.RetryStatusCodes, &custom); err == nil && custom != nil {
+			codes = custom
+		}
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
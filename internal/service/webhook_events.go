@@ -0,0 +1,205 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/singll/bellkeeper/internal/model"
+)
+
+// Headers added to the deliveries of events
+const (
+	WebhookEventHeader   = "X-Bellkeeper-Event"
+	WebhookEventIDHeader = "X-Bellkeeper-Event-ID"
+)
+
+// ErrInvalidSubscription wraps validation errors of an event subscription's type or filters.
+var ErrInvalidSubscription = errors.New("invalid subscription")
+
+// EventFilters are the filters of an event subscription. Every list that is
+// not empty must match the event: Tags shares a tag with it, ignoring case,
+// Categories contains its category and DatasetIDs its dataset.
+type EventFilters struct {
+	Tags       []string `json:"tags,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	DatasetIDs []string `json:"dataset_ids,omitempty"`
+}
+
+// Match reports whether an event passes the filters
+func (f EventFilters) Match(event Event) bool {
+	if len(f.Tags) > 0 && !slices.ContainsFunc(f.Tags, func(tag string) bool {
+		return slices.ContainsFunc(event.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+	}) {
+		return false
+	}
+	if len(f.Categories) > 0 && !slices.Contains(f.Categories, event.Category) {
+		return false
+	}
+	if len(f.DatasetIDs) > 0 && !slices.Contains(f.DatasetIDs, event.DatasetID) {
+		return false
+	}
+	return true
+}
+
+// RegisterEvents delivers the events published on the bus to the webhooks subscribed to them.
+func (s *WebhookService) RegisterEvents(bus *EventBus) {
+	bus.Subscribe(s.handleEvent)
+}
+
+// EventTypes returns the event types webhooks can subscribe to
+func (s *WebhookService) EventTypes() []string {
+	return EventTypes
+}
+
+func (s *WebhookService) ListSubscriptions(webhookID uint) ([]model.WebhookSubscription, error) {
+	if _, err := s.repo.GetByID(webhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSubscriptions(webhookID)
+}
+
+func (s *WebhookService) GetSubscription(webhookID, id uint) (*model.WebhookSubscription, error) {
+	return s.repo.GetSubscription(webhookID, id)
+}
+
+func (s *WebhookService) CreateSubscription(sub *model.WebhookSubscription) error {
+	if err := prepareSubscription(sub); err != nil {
+		return err
+	}
+	return s.repo.CreateSubscription(sub)
+}
+
+func (s *WebhookService) UpdateSubscription(sub *model.WebhookSubscription) error {
+	if err := prepareSubscription(sub); err != nil {
+		return err
+	}
+	return s.repo.UpdateSubscription(sub)
+}
+
+func (s *WebhookService) DeleteSubscription(webhookID, id uint) error {
+	return s.repo.DeleteSubscription(webhookID, id)
+}
+
+// prepareSubscription validates the event type and filters of a subscription
+// before it is stored, dropping empty filters.
+func prepareSubscription(sub *model.WebhookSubscription) error {
+	sub.EventType = strings.TrimSpace(sub.EventType)
+	if !validEventPattern(sub.EventType) {
+		return fmt.Errorf("%w: unknown event type %q", ErrInvalidSubscription, sub.EventType)
+	}
+
+	var filters EventFilters
+	if err := decodeParams(json.RawMessage(sub.Filters), &filters); err != nil {
+		return fmt.Errorf("%w: filters: %v", ErrInvalidSubscription, err)
+	}
+	sub.Filters = nil
+	if len(filters.Tags) > 0 || len(filters.Categories) > 0 || len(filters.DatasetIDs) > 0 {
+		data, err := json.Marshal(filters)
+		if err != nil {
+			return err
+		}
+		sub.Filters = data
+	}
+	return nil
+}
+
+// validEventPattern reports whether a subscription pattern matches at least
+// one event type
+func validEventPattern(pattern string) bool {
+	for _, eventType := range EventTypes {
+		if slices.Contains(eventTypePatterns(eventType), pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleEvent queues a delivery of the event for every active webhook with an
+// active subscription matching it. A webhook matched by several subscriptions
+// receives the event once.
+func (s *WebhookService) handleEvent(event Event) {
+	subs, err := s.repo.GetActiveSubscriptions(eventTypePatterns(event.Type))
+	if err != nil {
+		log.Printf("warn: failed to load webhook subscriptions for %s event %s: %v", event.Type, event.ID, err)
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("warn: failed to encode %s event %s: %v", event.Type, event.ID, err)
+		return
+	}
+
+	delivered := make(map[uint]bool)
+	queued := false
+	for _, sub := range subs {
+		webhook := sub.Webhook
+		if webhook == nil || !webhook.IsActive || delivered[webhook.ID] {
+			continue
+		}
+
+		var filters EventFilters
+		if len(sub.Filters) > 0 {
+			if err := json.Unmarshal(sub.Filters, &filters); err != nil {
+				log.Printf("warn: webhook subscription %d has invalid filters: %v", sub.ID, err)
+				continue
+			}
+		}
+		if !filters.Match(event) {
+			continue
+		}
+		delivered[webhook.ID] = true
+
+		if err := s.enqueueEvent(webhook, event, body); err != nil {
+			log.Printf("warn: failed to queue %s event %s for webhook %d: %v", event.Type, event.ID, webhook.ID, err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		s.notify()
+	}
+}
+
+// enqueueEvent stores a pending delivery of an event, due right away. The
+// body is the event itself; the URL and custom headers of the webhook may
+// use the event_type and event_id variables.
+func (s *WebhookService) enqueueEvent(webhook *model.WebhookConfig, event Event, body []byte) error {
+	variables := buildVariables(webhook, nil, map[string]string{
+		"event_type": event.Type,
+		"event_id":   event.ID,
+	})
+
+	headers := map[string]string{"Content-Type": webhook.ContentType}
+	if webhook.Headers != nil {
+		var custom map[string]string
+		json.Unmarshal(webhook.Headers, &custom)
+		for k, v := range custom {
+			headers[k] = processTemplate(v, variables)
+		}
+	}
+	headers[WebhookEventHeader] = event.Type
+	headers[WebhookEventIDHeader] = event.ID
+
+	now := time.Now()
+	delivery := &model.WebhookHistory{
+		WebhookID:     webhook.ID,
+		EventType:     event.Type,
+		RequestURL:    processTemplate(webhook.URL, variables),
+		RequestMethod: webhook.Method,
+		RequestBody:   string(body),
+		Status:        model.WebhookStatusPending,
+		MaxAttempts:   maxAttempts(webhook),
+		NextRetryAt:   &now,
+	}
+	if data, err := json.Marshal(headers); err == nil {
+		delivery.RequestHeaders = data
+	}
+	return s.repo.CreateHistory(delivery)
+}
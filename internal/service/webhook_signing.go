@@ -0,0 +1,29 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Headers of a signed webhook delivery. The timestamp is in Unix seconds; the
+// signature is "v1=" followed by the hex HMAC-SHA256, keyed with the signing
+// secret of the webhook, of the timestamp, a dot and the raw request body.
+// Receivers recompute it to check the sender and reject old timestamps to
+// stop replays.
+const (
+	WebhookTimestampHeader = "X-Bellkeeper-Timestamp"
+	WebhookSignatureHeader = "X-Bellkeeper-Signature"
+)
+
+// webhookSignatureVersion prefixes the signature, leaving room for another scheme
+const webhookSignatureVersion = "v1"
+
+// SignWebhook returns the signature header value of a delivery body sent at timestamp.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return webhookSignatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
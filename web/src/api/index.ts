@@ -1,15 +1,38 @@
 import type {
   Tag,
   DataSource,
+  DataSourceIngestResult,
+  DataSourceStatus,
+  DataSourceImportSummary,
+  CrawlSettings,
+  CrawlResult,
+  CandidateArticle,
+  GitHubSettings,
+  GitHubSyncResult,
+  GitHubItem,
+  PageSnapshot,
+  WatchResult,
   RSSFeed,
+  FeedEntry,
+  FeedFilters,
+  FeedFetchResult,
+  FeedPreview,
+  WebSubSubscription,
+  OPMLImportSummary,
   WebhookConfig,
   WebhookHistory,
+  WebhookSubscription,
   DatasetMapping,
   Setting,
   PaginatedResponse,
   HealthStatus,
   Workflow,
   WorkflowExecution,
+  Job,
+  JobStatus,
+  Schedule,
+  InboundHook,
+  InboundPreview,
 } from '@/types'
 
 const API_BASE = '/api'
@@ -70,13 +93,13 @@ export const dataSourcesApi = {
   get: (id: number) =>
     request<{ data: DataSource }>(`/datasources/${id}`),
 
-  create: (data: Partial<DataSource> & { tag_ids?: number[] }) =>
+  create: (data: Partial<DataSource> & { tag_ids?: number[]; crawl?: CrawlSettings; github?: GitHubSettings }) =>
     request<{ data: DataSource }>('/datasources', {
       method: 'POST',
       body: JSON.stringify(data),
     }),
 
-  update: (id: number, data: Partial<DataSource> & { tag_ids?: number[] }) =>
+  update: (id: number, data: Partial<DataSource> & { tag_ids?: number[]; crawl?: CrawlSettings; github?: GitHubSettings }) =>
     request<{ data: DataSource }>(`/datasources/${id}`, {
       method: 'PUT',
       body: JSON.stringify(data),
@@ -84,25 +107,69 @@ export const dataSourcesApi = {
 
   delete: (id: number) =>
     request<{ message: string }>(`/datasources/${id}`, { method: 'DELETE' }),
+
+  ingest: (id: number, refresh = false) =>
+    request<{ data: DataSourceIngestResult }>(`/datasources/${id}/ingest`, {
+      method: 'POST',
+      body: JSON.stringify({ refresh }),
+    }),
+
+  crawl: (id: number) =>
+    request<{ data: CrawlResult }>(`/datasources/${id}/crawl`, { method: 'POST' }),
+
+  candidates: (id: number, page = 1, perPage = 20, keyword = '') =>
+    request<PaginatedResponse<CandidateArticle>>(
+      `/datasources/${id}/candidates?page=${page}&per_page=${perPage}&keyword=${encodeURIComponent(keyword)}`
+    ),
+
+  check: (id: number) =>
+    request<{ data: WatchResult }>(`/datasources/${id}/check`, { method: 'POST' }),
+
+  snapshot: (id: number) =>
+    request<{ data: PageSnapshot }>(`/datasources/${id}/snapshot`),
+
+  status: (id: number, refresh = false) =>
+    request<{ data: DataSourceStatus }>(`/datasources/${id}/status${refresh ? '?refresh=true' : ''}`),
+
+  githubSync: (id: number) =>
+    request<{ data: GitHubSyncResult }>(`/datasources/${id}/github/sync`, { method: 'POST' }),
+
+  githubItems: (id: number, kind: '' | 'release' | 'file' = '') =>
+    request<{ data: GitHubItem[] }>(`/datasources/${id}/github/items?kind=${kind}`),
+
+  // The file is sent as the raw body; the format follows from its extension
+  import: (file: File, dryRun = false) => {
+    const format = file.name.toLowerCase().endsWith('.json') ? 'json' : 'csv'
+    return request<{ data: DataSourceImportSummary }>(
+      `/datasources/import?format=${format}${dryRun ? '&dry_run=true' : ''}`,
+      {
+        method: 'POST',
+        headers: { 'Content-Type': format === 'json' ? 'application/json' : 'text/csv' },
+        body: file,
+      }
+    )
+  },
+
+  exportUrl: (format: 'csv' | 'json') => `${API_BASE}/datasources/export?format=${format}`,
 }
 
 // RSS Feeds API
 export const rssApi = {
-  list: (page = 1, perPage = 20, category = '', keyword = '') =>
+  list: (page = 1, perPage = 20, category = '', keyword = '', health: '' | 'healthy' | 'unhealthy' = '') =>
     request<PaginatedResponse<RSSFeed>>(
-      `/rss?page=${page}&per_page=${perPage}&category=${encodeURIComponent(category)}&keyword=${encodeURIComponent(keyword)}`
+      `/rss?page=${page}&per_page=${perPage}&category=${encodeURIComponent(category)}&keyword=${encodeURIComponent(keyword)}&health=${health}`
     ),
 
   get: (id: number) =>
     request<{ data: RSSFeed }>(`/rss/${id}`),
 
-  create: (data: Partial<RSSFeed> & { tag_ids?: number[] }) =>
+  create: (data: Partial<RSSFeed> & { tag_ids?: number[]; filters?: FeedFilters }) =>
     request<{ data: RSSFeed }>('/rss', {
       method: 'POST',
       body: JSON.stringify(data),
     }),
 
-  update: (id: number, data: Partial<RSSFeed> & { tag_ids?: number[] }) =>
+  update: (id: number, data: Partial<RSSFeed> & { tag_ids?: number[]; filters?: FeedFilters }) =>
     request<{ data: RSSFeed }>(`/rss/${id}`, {
       method: 'PUT',
       body: JSON.stringify(data),
@@ -110,6 +177,42 @@ export const rssApi = {
 
   delete: (id: number) =>
     request<{ message: string }>(`/rss/${id}`, { method: 'DELETE' }),
+
+  entries: (id: number, page = 1, perPage = 20, keyword = '') =>
+    request<PaginatedResponse<FeedEntry>>(
+      `/rss/${id}/entries?page=${page}&per_page=${perPage}&keyword=${encodeURIComponent(keyword)}`
+    ),
+
+  fetch: (id: number) =>
+    request<{ data: FeedFetchResult }>(`/rss/${id}/fetch`, { method: 'POST' }),
+
+  websub: (id: number) =>
+    request<{ data: WebSubSubscription }>(`/rss/${id}/websub`),
+
+  preview: (url: string) =>
+    request<{ data: FeedPreview }>('/rss/preview', {
+      method: 'POST',
+      body: JSON.stringify({ url }),
+    }),
+
+  // OPML is sent as the raw body so the JSON content type of request() doesn't apply
+  importOpml: (file: File) =>
+    request<{ data: OPMLImportSummary }>('/rss/import/opml', {
+      method: 'POST',
+      headers: { 'Content-Type': 'text/x-opml' },
+      body: file,
+    }),
+
+  exportOpmlUrl: `${API_BASE}/rss/export/opml`,
+}
+
+// Feed Entries API
+export const entriesApi = {
+  list: (page = 1, perPage = 20, feedId?: number, keyword = '') => {
+    const params = new URLSearchParams({ page: String(page), per_page: String(perPage), keyword })
+    if (feedId) params.set('feed_id', String(feedId))
+    return request<PaginatedResponse<FeedEntry>>(`/entries?${params}`)
+  },
 }
 
 // Webhooks API
@@ -145,6 +248,48 @@ export const webhooksApi = {
 
   history: (id: number, limit = 20) =>
     request<{ data: WebhookHistory[] }>(`/webhooks/${id}/history?limit=${limit}`),
+
+  dead: (page = 1, perPage = 20, webhookId?: number) => {
+    const params = new URLSearchParams({ page: String(page), per_page: String(perPage) })
+    if (webhookId) params.set('webhook_id', String(webhookId))
+    return request<PaginatedResponse<WebhookHistory>>(`/webhooks/dead?${params}`)
+  },
+
+  attempts: (historyId: number) =>
+    request<{ data: WebhookHistory[] }>(`/webhooks/history/${historyId}/attempts`),
+
+  requeue: (historyId: number) =>
+    request<{ data: WebhookHistory }>(`/webhooks/history/${historyId}/requeue`, {
+      method: 'POST',
+    }),
+
+  replay: (historyId: number, useCurrentUrl = false) =>
+    request<{ data: WebhookHistory }>(`/webhooks/history/${historyId}/replay`, {
+      method: 'POST',
+      body: JSON.stringify({ use_current_url: useCurrentUrl }),
+    }),
+
+  events: () => request<{ data: string[] }>('/webhooks/events'),
+
+  subscriptions: (id: number) =>
+    request<{ data: WebhookSubscription[] }>(`/webhooks/${id}/subscriptions`),
+
+  createSubscription: (id: number, data: Partial<WebhookSubscription>) =>
+    request<{ data: WebhookSubscription }>(`/webhooks/${id}/subscriptions`, {
+      method: 'POST',
+      body: JSON.stringify(data),
+    }),
+
+  updateSubscription: (id: number, subscriptionId: number, data: Partial<WebhookSubscription>) =>
+    request<{ data: WebhookSubscription }>(`/webhooks/${id}/subscriptions/${subscriptionId}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    }),
+
+  deleteSubscription: (id: number, subscriptionId: number) =>
+    request<{ message: string }>(`/webhooks/${id}/subscriptions/${subscriptionId}`, {
+      method: 'DELETE',
+    }),
 }
 
 // Datasets API
@@ -200,6 +345,88 @@ export const systemApi = {
     request<{ message: string }>('/system/restart', { method: 'POST' }),
 }
 
+// Background jobs API
+export const jobsApi = {
+  list: (page = 1, perPage = 20, type = '', status: JobStatus | '' = '') =>
+    request<PaginatedResponse<Job>>(
+      `/jobs?page=${page}&per_page=${perPage}&type=${encodeURIComponent(type)}&status=${status}`
+    ),
+
+  get: (id: number) =>
+    request<{ data: Job }>(`/jobs/${id}`),
+
+  // Progress stream (Server-Sent Events named status / progress / item)
+  events: (id: number) => new EventSource(`${API_BASE}/jobs/${id}/events`),
+}
+
+// Schedules API
+export const schedulesApi = {
+  list: (page = 1, perPage = 20) =>
+    request<PaginatedResponse<Schedule>>(
+      `/schedules?page=${page}&per_page=${perPage}`
+    ),
+
+  get: (id: number) =>
+    request<{ data: Schedule }>(`/schedules/${id}`),
+
+  create: (data: Partial<Schedule>) =>
+    request<{ data: Schedule }>('/schedules', {
+      method: 'POST',
+      body: JSON.stringify(data),
+    }),
+
+  update: (id: number, data: Partial<Schedule>) =>
+    request<{ data: Schedule }>(`/schedules/${id}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    }),
+
+  delete: (id: number) =>
+    request<{ message: string }>(`/schedules/${id}`, { method: 'DELETE' }),
+
+  run: (id: number) =>
+    request<{ data: Schedule }>(`/schedules/${id}/run`, { method: 'POST' }),
+
+  tasks: () => request<{ data: string[] }>('/schedules/tasks'),
+
+  preview: (cron: string, timezone = '', count = 5) =>
+    request<{ data: string[] }>(
+      `/schedules/preview?cron=${encodeURIComponent(cron)}&timezone=${encodeURIComponent(timezone)}&count=${count}`
+    ),
+}
+
+// Inbound hooks API
+export const inboundHooksApi = {
+  list: (page = 1, perPage = 20) =>
+    request<PaginatedResponse<InboundHook>>(
+      `/inbound-hooks?page=${page}&per_page=${perPage}`
+    ),
+
+  get: (id: number) =>
+    request<{ data: InboundHook }>(`/inbound-hooks/${id}`),
+
+  create: (data: Partial<InboundHook>) =>
+    request<{ data: InboundHook }>('/inbound-hooks', {
+      method: 'POST',
+      body: JSON.stringify(data),
+    }),
+
+  update: (id: number, data: Partial<InboundHook>) =>
+    request<{ data: InboundHook }>(`/inbound-hooks/${id}`, {
+      method: 'PUT',
+      body: JSON.stringify(data),
+    }),
+
+  delete: (id: number) =>
+    request<{ message: string }>(`/inbound-hooks/${id}`, { method: 'DELETE' }),
+
+  preview: (id: number, payload: unknown) =>
+    request<{ data: InboundPreview }>(`/inbound-hooks/${id}/preview`, {
+      method: 'POST',
+      body: JSON.stringify(payload),
+    }),
+}
+
 // Workflows API
 export const workflowsApi = {
   list: () => request<{ data: Workflow[] }>('/workflows/status'),
@@ -286,4 +513,15 @@ export const ragflowApi = {
       `/ragflow/documents/${encodeURIComponent(documentId)}?dataset_id=${encodeURIComponent(datasetId)}`,
       { method: 'DELETE' }
     ),
+
+  // Transfer documents to another dataset as a background job
+  batchTransfer: (sourceDatasetId: string, targetDatasetId: string, documentIds: string[]) =>
+    request<{ data: Job }>('/ragflow/documents/batch-transfer?async=true', {
+      method: 'POST',
+      body: JSON.stringify({
+        source_dataset_id: sourceDatasetId,
+        target_dataset_id: targetDatasetId,
+        document_ids: documentIds,
+      }),
+    }),
 }
@@ -35,6 +35,14 @@ const Dashboard: Component = () => {
     { label: '知识库', key: 'datasets_count', icon: 'M19 11H5m14 0a2 2 0 012 2v6a2 2 0 01-2 2H5a2 2 0 01-2-2v-6a2 2 0 012-2m14 0V9a2 2 0 00-2-2M5 11V9a2 2 0 012-2m0 0V5a2 2 0 012-2h6a2 2 0 012 2v2M7 7h10', color: 'text-purple-400' },
   ]
 
+  const reachability = [
+    { label: '正常', key: 'datasources_up', color: 'text-emerald-400' },
+    { label: '已迁移', key: 'datasources_moved', color: 'text-amber-400' },
+    { label: '无法访问', key: 'datasources_failing', color: 'text-orange-400' },
+    { label: '已失效', key: 'datasources_dead', color: 'text-red-400' },
+    { label: '证书即将过期', key: 'datasources_tls_expiring', color: 'text-amber-400' },
+  ]
+
   return (
     <div class="animate-fade-in">
       {/* Header */}
@@ -107,6 +115,28 @@ const Dashboard: Component = () => {
         </For>
       </div>
 
+      {/* Data Source Reachability */}
+      <Show when={typeof health()?.metrics?.datasources_up === 'number'}>
+        <div class="card mb-6">
+          <div class="flex items-center justify-between mb-4">
+            <h2 class="text-lg font-semibold text-white">数据源可达性</h2>
+            <A href="/datasources" class="text-sm text-primary-400 hover:text-primary-300">
+              查看数据源
+            </A>
+          </div>
+          <div class="grid grid-cols-2 sm:grid-cols-5 gap-4">
+            <For each={reachability}>
+              {(item) => (
+                <div>
+                  <div class="text-sm text-dark-400">{item.label}</div>
+                  <div class={`text-xl font-bold ${item.color}`}>{getMetric(item.key)}</div>
+                </div>
+              )}
+            </For>
+          </div>
+        </div>
+      </Show>
+
       <div class="grid grid-cols-1 lg:grid-cols-2 gap-6">
         {/* Service Status */}
         <div class="card">
@@ -11,12 +11,23 @@ const DataSources: Component = () => {
   const [showModal, setShowModal] = createSignal(false)
   const [editing, setEditing] = createSignal<DataSource | null>(null)
   const [submitting, setSubmitting] = createSignal(false)
+  const [ingestingId, setIngestingId] = createSignal<number | null>(null)
+  const [crawlingId, setCrawlingId] = createSignal<number | null>(null)
+  const [checkingId, setCheckingId] = createSignal<number | null>(null)
+  const [syncingId, setSyncingId] = createSignal<number | null>(null)
+  const [candidatesSource, setCandidatesSource] = createSignal<DataSource | null>(null)
+  const [candidatesPage, setCandidatesPage] = createSignal(1)
 
   const [sources, { refetch }] = createResource(
     () => ({ page: page(), keyword: keyword() }),
     ({ page, keyword }) => dataSourcesApi.list(page, 20, '', keyword)
   )
 
+  const [candidates, { refetch: refetchCandidates }] = createResource(
+    () => candidatesSource() && { id: candidatesSource()!.id, page: candidatesPage() },
+    ({ id, page }) => dataSourcesApi.candidates(id, page, 20)
+  )
+
   const [allTags] = createResource(() => tagsApi.list(1, 100))
 
   const [form, setForm] = createSignal({
@@ -26,6 +37,14 @@ const DataSources: Component = () => {
     category: '',
     description: '',
     is_active: true,
+    extract_full_text: false,
+    watch: false,
+    max_depth: 0,
+    max_pages: 0,
+    github_readme: false,
+    github_docs: '',
+    github_prereleases: false,
+    github_max_releases: 0,
     tag_ids: [] as number[],
   })
 
@@ -38,6 +57,14 @@ const DataSources: Component = () => {
       category: '',
       description: '',
       is_active: true,
+      extract_full_text: false,
+      watch: false,
+      max_depth: 0,
+      max_pages: 0,
+      github_readme: false,
+      github_docs: '',
+      github_prereleases: false,
+      github_max_releases: 0,
       tag_ids: [],
     })
     setShowModal(true)
@@ -52,6 +79,14 @@ const DataSources: Component = () => {
       category: source.category,
       description: source.description,
       is_active: source.is_active,
+      extract_full_text: source.extract_full_text,
+      watch: source.watch,
+      max_depth: source.metadata?.crawl?.max_depth ?? 0,
+      max_pages: source.metadata?.crawl?.max_pages ?? 0,
+      github_readme: source.metadata?.github?.readme ?? false,
+      github_docs: source.metadata?.github?.docs?.join(', ') ?? '',
+      github_prereleases: source.metadata?.github?.include_prereleases ?? false,
+      github_max_releases: source.metadata?.github?.max_releases ?? 0,
       tag_ids: source.tags?.map((t) => t.id) || [],
     })
     setShowModal(true)
@@ -60,12 +95,23 @@ const DataSources: Component = () => {
   const handleSubmit = async (e: Event) => {
     e.preventDefault()
     setSubmitting(true)
+    const { max_depth, max_pages, github_readme, github_docs, github_prereleases, github_max_releases, ...rest } = form()
+    const github =
+      rest.type === 'github'
+        ? {
+            readme: github_readme || undefined,
+            docs: github_docs.split(/[,\n]/).map((p) => p.trim()).filter(Boolean),
+            include_prereleases: github_prereleases || undefined,
+            max_releases: github_max_releases || undefined,
+          }
+        : undefined
+    const data = { ...rest, crawl: { max_depth: max_depth || undefined, max_pages: max_pages || undefined }, github }
     try {
       if (editing()) {
-        await dataSourcesApi.update(editing()!.id, form())
+        await dataSourcesApi.update(editing()!.id, data)
         toast.success('数据源更新成功')
       } else {
-        await dataSourcesApi.create(form())
+        await dataSourcesApi.create(data)
         toast.success('数据源创建成功')
       }
       setShowModal(false)
@@ -88,6 +134,127 @@ const DataSources: Component = () => {
     }
   }
 
+  let importInput: HTMLInputElement | undefined
+
+  // Preview the import with a dry run and apply it once confirmed
+  const handleImport = async (e: Event) => {
+    const input = e.currentTarget as HTMLInputElement
+    const file = input.files?.[0]
+    input.value = ''
+    if (!file) return
+    try {
+      const { data: preview } = await dataSourcesApi.import(file, true)
+      const rejected = preview.results.filter((r) => r.status === 'rejected')
+      const lines = [
+        `共 ${preview.total} 行: 新建 ${preview.created}，更新 ${preview.updated}，无变化 ${preview.unchanged}，拒绝 ${preview.rejected}`,
+        ...rejected.slice(0, 10).map((r) => `第 ${r.row} 行 ${r.url || r.name}: ${r.message}`),
+      ]
+      if (preview.created + preview.updated === 0) {
+        if (preview.rejected > 0) toast.error(`没有可导入的数据源: ${lines[0]}`)
+        else toast.success('所有数据源均无变化')
+        return
+      }
+      if (!confirm(`${lines.join('\n')}\n\n确定导入吗？`)) return
+
+      const { data } = await dataSourcesApi.import(file)
+      toast.success(`导入完成: 新建 ${data.created}，更新 ${data.updated}，拒绝 ${data.rejected}，失败 ${data.failed}`)
+      data.results
+        .filter((r) => r.status === 'failed')
+        .forEach((r) => toast.error(`第 ${r.row} 行 ${r.url}: ${r.message}`))
+      refetch()
+    } catch (err) {
+      toast.error('导入失败: ' + (err as Error).message)
+    }
+  }
+
+  const handleIngest = async (source: DataSource) => {
+    setIngestingId(source.id)
+    try {
+      const { data } = await dataSourcesApi.ingest(source.id)
+      if (data.status === 'duplicate') {
+        toast.error(`已存在: ${data.message}`)
+      } else {
+        toast.success(`已入库「${data.title}」(${data.length} 字${data.cached ? '，使用缓存' : ''})`)
+      }
+    } catch (err) {
+      toast.error('入库失败: ' + (err as Error).message)
+    } finally {
+      setIngestingId(null)
+    }
+  }
+
+  const handleCrawl = async (source: DataSource) => {
+    setCrawlingId(source.id)
+    try {
+      const { data } = await dataSourcesApi.crawl(source.id)
+      toast.success(
+        `抓取 ${data.pages} 个页面，发现 ${data.discovered} 个链接，新增 ${data.new} 篇候选` +
+          (data.unchanged ? `，${data.unchanged} 个未变化` : '') +
+          (data.duplicate ? `，${data.duplicate} 个已入库` : '') +
+          (data.disallowed ? `，${data.disallowed} 个被 robots.txt 禁止` : '')
+      )
+      refetch()
+      if (candidatesSource()?.id === source.id) refetchCandidates()
+    } catch (err) {
+      toast.error('爬取失败: ' + (err as Error).message)
+    } finally {
+      setCrawlingId(null)
+    }
+  }
+
+  const handleCheck = async (source: DataSource) => {
+    setCheckingId(source.id)
+    try {
+      const { data } = await dataSourcesApi.check(source.id)
+      const change = `${(data.change * 100).toFixed(1)}%`
+      switch (data.status) {
+        case 'baseline':
+          toast.success('已记录页面快照')
+          break
+        case 'unchanged':
+          toast.success('页面未变化')
+          break
+        case 'minor':
+          toast.success(`页面有细微变化 (${change})，未重新入库`)
+          break
+        case 'changed':
+          if (data.message) {
+            toast.error(`页面已变化 (${change}): ${data.message}`)
+          } else {
+            toast.success(`页面已变化 (${change})，已上传新版本并删除旧文档`)
+          }
+          break
+      }
+    } catch (err) {
+      toast.error('检查失败: ' + (err as Error).message)
+    } finally {
+      setCheckingId(null)
+    }
+  }
+
+  const handleGitHubSync = async (source: DataSource) => {
+    setSyncingId(source.id)
+    try {
+      const { data } = await dataSourcesApi.githubSync(source.id)
+      toast.success(
+        `新增 ${data.releases} 个 Release，${data.files} 个文档` +
+          (data.unchanged ? `，${data.unchanged} 个未变化` : '') +
+          (data.duplicate ? `，${data.duplicate} 个已入库` : '') +
+          (data.errors ? `，${data.errors} 个失败` : '')
+      )
+      refetch()
+    } catch (err) {
+      toast.error('同步失败: ' + (err as Error).message)
+    } finally {
+      setSyncingId(null)
+    }
+  }
+
+  const openCandidates = (source: DataSource) => {
+    setCandidatesPage(1)
+    setCandidatesSource(source)
+  }
+
   const toggleTag = (tagId: number) => {
     const current = form().tag_ids
     if (current.includes(tagId)) {
@@ -105,12 +272,24 @@ const DataSources: Component = () => {
           <h1 class="text-2xl font-bold text-white">数据源管理</h1>
           <p class="text-sm text-dark-400 mt-1">管理知识采集的数据来源</p>
         </div>
-        <button class="btn btn-primary" onClick={openCreateModal}>
-          <svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24">
-            <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M12 4v16m8-8H4" />
-          </svg>
-          新建数据源
-        </button>
+        <div class="flex items-center gap-2">
+          <input ref={importInput} type="file" accept=".csv,.json,text/csv,application/json" class="hidden" onChange={handleImport} />
+          <button class="btn btn-secondary" onClick={() => importInput?.click()}>
+            导入
+          </button>
+          <a class="btn btn-secondary" href={dataSourcesApi.exportUrl('csv')} download>
+            导出 CSV
+          </a>
+          <a class="btn btn-secondary" href={dataSourcesApi.exportUrl('json')} download>
+            导出 JSON
+          </a>
+          <button class="btn btn-primary" onClick={openCreateModal}>
+            <svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+              <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M12 4v16m8-8H4" />
+            </svg>
+            新建数据源
+          </button>
+        </div>
       </div>
 
       {/* Search */}
@@ -204,6 +383,21 @@ const DataSources: Component = () => {
                         </td>
                         <td>
                           <span class="badge badge-primary">{source.type}</span>
+                          <Show when={source.last_crawl_error}>
+                            <span class="badge badge-danger ml-1" title={source.last_crawl_error}>
+                              爬取失败
+                            </span>
+                          </Show>
+                          <Show when={source.reachability?.state === 'moved'}>
+                            <span class="badge badge-warning ml-1" title={`已永久重定向到 ${source.reachability!.final_url}`}>
+                              已迁移
+                            </span>
+                          </Show>
+                          <Show when={source.reachability?.state === 'failing' || source.reachability?.state === 'dead'}>
+                            <span class="badge badge-danger ml-1" title={source.reachability!.error}>
+                              {source.reachability!.state === 'dead' ? '已失效' : '无法访问'}
+                            </span>
+                          </Show>
                         </td>
                         <td>
                           <div class="flex flex-wrap gap-1">
@@ -228,10 +422,69 @@ const DataSources: Component = () => {
                             <span class={source.is_active ? 'text-emerald-400' : 'text-dark-500'}>
                               {source.is_active ? '启用' : '禁用'}
                             </span>
+                            <Show when={source.watch}>
+                              <span class="badge badge-primary">监控中</span>
+                            </Show>
                           </div>
                         </td>
                         <td class="text-right">
                           <div class="flex items-center justify-end gap-1 opacity-0 group-hover:opacity-100 transition-opacity">
+                            <button
+                              class="btn btn-ghost btn-sm"
+                              title="抓取并入库"
+                              disabled={ingestingId() === source.id}
+                              onClick={() => handleIngest(source)}
+                            >
+                              <svg class={`w-4 h-4 ${ingestingId() === source.id ? 'animate-pulse' : ''}`} fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                                <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 16v1a3 3 0 003 3h10a3 3 0 003-3v-1m-4-8l-4-4m0 0L8 8m4-4v12" />
+                              </svg>
+                            </button>
+                            <Show when={source.watch}>
+                              <button
+                                class="btn btn-ghost btn-sm"
+                                title="检查页面变化"
+                                disabled={checkingId() === source.id}
+                                onClick={() => handleCheck(source)}
+                              >
+                                <svg class={`w-4 h-4 ${checkingId() === source.id ? 'animate-pulse' : ''}`} fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                                  <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M15 12a3 3 0 11-6 0 3 3 0 016 0z" />
+                                  <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M2.458 12C3.732 7.943 7.523 5 12 5c4.478 0 8.268 2.943 9.542 7-1.274 4.057-5.064 7-9.542 7-4.477 0-8.268-2.943-9.542-7z" />
+                                </svg>
+                              </button>
+                            </Show>
+                            <Show when={source.type === 'github'}>
+                              <button
+                                class="btn btn-ghost btn-sm"
+                                title="同步 Release 与文档"
+                                disabled={syncingId() === source.id}
+                                onClick={() => handleGitHubSync(source)}
+                              >
+                                <svg class={`w-4 h-4 ${syncingId() === source.id ? 'animate-spin' : ''}`} fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                                  <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 4v5h.582m15.356 2A8.001 8.001 0 004.582 9m0 0H9m11 11v-5h-.581m0 0a8.003 8.003 0 01-15.357-2m15.357 2H15" />
+                                </svg>
+                              </button>
+                            </Show>
+                            <Show when={source.type === 'website' || source.type === 'sitemap'}>
+                              <button
+                                class="btn btn-ghost btn-sm"
+                                title="爬取站内链接"
+                                disabled={crawlingId() === source.id}
+                                onClick={() => handleCrawl(source)}
+                              >
+                                <svg class={`w-4 h-4 ${crawlingId() === source.id ? 'animate-spin' : ''}`} fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                                  <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 4v5h.582m15.356 2A8.001 8.001 0 004.582 9m0 0H9m11 11v-5h-.581m0 0a8.003 8.003 0 01-15.357-2m15.357 2H15" />
+                                </svg>
+                              </button>
+                              <button
+                                class="btn btn-ghost btn-sm"
+                                title="候选文章"
+                                onClick={() => openCandidates(source)}
+                              >
+                                <svg class="w-4 h-4" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                                  <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 6h16M4 10h16M4 14h16M4 18h16" />
+                                </svg>
+                              </button>
+                            </Show>
                             <button
                               class="btn btn-ghost btn-sm"
                               onClick={() => openEditModal(source)}
@@ -347,6 +600,7 @@ const DataSources: Component = () => {
                 onChange={(e) => setForm({ ...form(), type: e.currentTarget.value })}
               >
                 <option value="website">网站</option>
+                <option value="sitemap">Sitemap</option>
                 <option value="api">API</option>
                 <option value="github">GitHub</option>
                 <option value="rss">RSS</option>
@@ -409,8 +663,186 @@ const DataSources: Component = () => {
               <span class="ms-3 text-sm font-medium text-dark-300">启用数据源</span>
             </label>
           </div>
+          <div class="flex items-center gap-3">
+            <label class="relative inline-flex items-center cursor-pointer">
+              <input
+                type="checkbox"
+                class="sr-only peer"
+                checked={form().extract_full_text}
+                onChange={(e) => setForm({ ...form(), extract_full_text: e.currentTarget.checked })}
+              />
+              <div class="w-11 h-6 bg-dark-700 peer-focus:outline-none peer-focus:ring-2 peer-focus:ring-primary-500 rounded-full peer peer-checked:after:translate-x-full rtl:peer-checked:after:-translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:start-[2px] after:bg-white after:border-gray-300 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:bg-primary-600"></div>
+              <span class="ms-3 text-sm font-medium text-dark-300">正文提取 (仅入库页面主体文章)</span>
+            </label>
+          </div>
+          <div class="flex items-center gap-3">
+            <label class="relative inline-flex items-center cursor-pointer">
+              <input
+                type="checkbox"
+                class="sr-only peer"
+                checked={form().watch}
+                onChange={(e) => setForm({ ...form(), watch: e.currentTarget.checked })}
+              />
+              <div class="w-11 h-6 bg-dark-700 peer-focus:outline-none peer-focus:ring-2 peer-focus:ring-primary-500 rounded-full peer peer-checked:after:translate-x-full rtl:peer-checked:after:-translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:start-[2px] after:bg-white after:border-gray-300 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:bg-primary-600"></div>
+              <span class="ms-3 text-sm font-medium text-dark-300">监控变化 (页面变化后自动重新入库)</span>
+            </label>
+          </div>
+          <Show when={form().type === 'website' || form().type === 'sitemap'}>
+            <div class="grid grid-cols-2 gap-4">
+              <div>
+                <label class="label">爬取深度 (仅网站)</label>
+                <input
+                  type="number"
+                  class="input"
+                  min="0"
+                  placeholder="默认"
+                  value={form().max_depth || ''}
+                  onInput={(e) => setForm({ ...form(), max_depth: parseInt(e.currentTarget.value) || 0 })}
+                />
+              </div>
+              <div>
+                <label class="label">单次页面上限</label>
+                <input
+                  type="number"
+                  class="input"
+                  min="0"
+                  placeholder="默认"
+                  value={form().max_pages || ''}
+                  onInput={(e) => setForm({ ...form(), max_pages: parseInt(e.currentTarget.value) || 0 })}
+                />
+              </div>
+            </div>
+          </Show>
+          <Show when={form().type === 'github'}>
+            <div class="grid grid-cols-2 gap-4">
+              <div>
+                <label class="label">Release 数量上限</label>
+                <input
+                  type="number"
+                  class="input"
+                  min="0"
+                  max="100"
+                  placeholder="默认"
+                  value={form().github_max_releases || ''}
+                  onInput={(e) => setForm({ ...form(), github_max_releases: parseInt(e.currentTarget.value) || 0 })}
+                />
+              </div>
+              <div>
+                <label class="label">文档路径</label>
+                <input
+                  type="text"
+                  class="input font-mono"
+                  placeholder="docs, CHANGELOG.md"
+                  value={form().github_docs}
+                  onInput={(e) => setForm({ ...form(), github_docs: e.currentTarget.value })}
+                />
+              </div>
+            </div>
+            <div class="flex items-center gap-3">
+              <label class="relative inline-flex items-center cursor-pointer">
+                <input
+                  type="checkbox"
+                  class="sr-only peer"
+                  checked={form().github_readme}
+                  onChange={(e) => setForm({ ...form(), github_readme: e.currentTarget.checked })}
+                />
+                <div class="w-11 h-6 bg-dark-700 peer-focus:outline-none peer-focus:ring-2 peer-focus:ring-primary-500 rounded-full peer peer-checked:after:translate-x-full rtl:peer-checked:after:-translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:start-[2px] after:bg-white after:border-gray-300 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:bg-primary-600"></div>
+                <span class="ms-3 text-sm font-medium text-dark-300">同步 README</span>
+              </label>
+            </div>
+            <div class="flex items-center gap-3">
+              <label class="relative inline-flex items-center cursor-pointer">
+                <input
+                  type="checkbox"
+                  class="sr-only peer"
+                  checked={form().github_prereleases}
+                  onChange={(e) => setForm({ ...form(), github_prereleases: e.currentTarget.checked })}
+                />
+                <div class="w-11 h-6 bg-dark-700 peer-focus:outline-none peer-focus:ring-2 peer-focus:ring-primary-500 rounded-full peer peer-checked:after:translate-x-full rtl:peer-checked:after:-translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:start-[2px] after:bg-white after:border-gray-300 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:bg-primary-600"></div>
+                <span class="ms-3 text-sm font-medium text-dark-300">包含预发布版本</span>
+              </label>
+            </div>
+          </Show>
         </form>
       </Modal>
+
+      {/* Candidates Modal */}
+      <Modal
+        open={candidatesSource() !== null}
+        onClose={() => setCandidatesSource(null)}
+        title={`候选文章 - ${candidatesSource()?.name ?? ''}`}
+        size="xl"
+      >
+        <Show
+          when={!candidates.loading}
+          fallback={
+            <div class="text-center py-8">
+              <div class="loading-spinner mx-auto" />
+              <p class="mt-3 text-dark-400">加载中...</p>
+            </div>
+          }
+        >
+          <Show
+            when={candidates()?.data && candidates()!.data.length > 0}
+            fallback={
+              <div class="empty-state">
+                <p class="empty-state-title">暂无候选文章</p>
+                <p class="empty-state-description">爬取数据源后，发现的站内页面会出现在这里</p>
+              </div>
+            }
+          >
+            <ul class="divide-y divide-dark-700/50 max-h-[60vh] overflow-y-auto">
+              <For each={candidates()?.data}>
+                {(candidate) => (
+                  <li class="py-3">
+                    <a
+                      href={candidate.url}
+                      target="_blank"
+                      rel="noopener noreferrer"
+                      class="font-medium text-white hover:text-primary-400"
+                    >
+                      {candidate.title || candidate.url}
+                    </a>
+                    <div class="flex items-center gap-3 mt-1 text-xs text-dark-400">
+                      <Show when={candidate.depth > 0}>
+                        <span class="badge badge-gray">深度 {candidate.depth}</span>
+                      </Show>
+                      <Show when={candidate.last_modified}>
+                        <span>更新于 {new Date(candidate.last_modified!).toLocaleDateString('zh-CN')}</span>
+                      </Show>
+                      <span class="truncate font-mono">{candidate.url}</span>
+                      <span>{new Date(candidate.created_at).toLocaleString('zh-CN')}</span>
+                    </div>
+                  </li>
+                )}
+              </For>
+            </ul>
+            <Show when={candidates()!.total > 20}>
+              <div class="flex items-center justify-between mt-4">
+                <div class="text-sm text-dark-400">
+                  共 <span class="text-dark-200 font-medium">{candidates()!.total}</span> 条
+                </div>
+                <div class="flex gap-2">
+                  <button
+                    class="btn btn-secondary btn-sm"
+                    disabled={candidatesPage() === 1}
+                    onClick={() => setCandidatesPage((p) => p - 1)}
+                  >
+                    上一页
+                  </button>
+                  <button
+                    class="btn btn-secondary btn-sm"
+                    disabled={candidatesPage() * 20 >= (candidates()?.total || 0)}
+                    onClick={() => setCandidatesPage((p) => p + 1)}
+                  >
+                    下一页
+                  </button>
+                </div>
+              </div>
+            </Show>
+          </Show>
+        </Show>
+      </Modal>
     </div>
   )
 }
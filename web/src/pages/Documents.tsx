@@ -1,8 +1,8 @@
-import { Component, createSignal, createEffect, For, Show } from 'solid-js'
-import { datasetsApi, ragflowApi, type RagFlowDocument } from '@/api'
+import { Component, createSignal, createEffect, onCleanup, For, Show } from 'solid-js'
+import { datasetsApi, jobsApi, ragflowApi, type RagFlowDocument } from '@/api'
 import { useToast } from '@/components/Toast'
 import Modal from '@/components/Modal'
-import type { DatasetMapping } from '@/types'
+import type { DatasetMapping, JobEvent } from '@/types'
 
 const Documents: Component = () => {
   const toast = useToast()
@@ -27,6 +27,14 @@ const Documents: Component = () => {
   const [uploading, setUploading] = createSignal(false)
   const [urlCheckResult, setUrlCheckResult] = createSignal<{ checked: boolean; exists: boolean } | null>(null)
 
+  const [selected, setSelected] = createSignal<string[]>([])
+  const [showTransferModal, setShowTransferModal] = createSignal(false)
+  const [transferTarget, setTransferTarget] = createSignal('')
+  const [transferProgress, setTransferProgress] = createSignal<JobEvent | null>(null)
+  const [transferFailures, setTransferFailures] = createSignal<{ id: string; error: string }[]>([])
+  let transferEvents: EventSource | undefined
+  onCleanup(() => transferEvents?.close())
+
   createEffect(async () => {
     try {
       const res = await datasetsApi.list(1, 100)
@@ -52,6 +60,7 @@ const Documents: Component = () => {
 
     setLoading(true)
     setError('')
+    setSelected([])
     try {
       const res = await ragflowApi.listDocuments(dsId, page(), 20)
       if (res.code === 0 && res.data) {
@@ -156,6 +165,92 @@ const Documents: Component = () => {
     }
   }
 
+  const toggleSelected = (id: string, checked: boolean) => {
+    setSelected(ids => checked ? [...ids, id] : ids.filter(i => i !== id))
+  }
+
+  const allSelected = () => documents().length > 0 && selected().length === documents().length
+
+  const transferring = () => {
+    const p = transferProgress()
+    return p !== null && p.status !== 'succeeded' && p.status !== 'failed'
+  }
+
+  const openTransfer = () => {
+    if (transferring()) {
+      setShowTransferModal(true)
+      return
+    }
+    setTransferTarget('')
+    setTransferProgress(null)
+    setTransferFailures([])
+    setShowTransferModal(true)
+  }
+
+  const watchTransfer = (jobId: number) => {
+    transferEvents?.close()
+    const source = jobsApi.events(jobId)
+    transferEvents = source
+
+    const onEvent = (e: MessageEvent) => {
+      const event = JSON.parse(e.data) as JobEvent
+      setTransferProgress(event)
+      // A retried job starts counting again
+      if (event.type === 'progress' && event.done === 0) {
+        setTransferFailures([])
+      }
+      if (event.type === 'item' && event.item && event.item.success === false) {
+        const item = event.item
+        setTransferFailures(f => [...f, { id: String(item.document_id), error: String(item.error ?? '') }])
+      }
+      if (event.type === 'status' && (event.status === 'succeeded' || event.status === 'failed')) {
+        source.close()
+        if (event.status === 'failed') {
+          toast.error('转移失败: ' + (event.error || '未知错误'))
+        } else if (event.failed > 0) {
+          toast.error(`${event.failed} 个文档转移失败，${event.done - event.failed} 个成功`)
+        } else {
+          toast.success(`已转移 ${event.done} 个文档`)
+        }
+        loadDocuments()
+      }
+    }
+    for (const type of ['status', 'progress', 'item']) {
+      source.addEventListener(type, onEvent as EventListener)
+    }
+  }
+
+  const handleTransfer = async () => {
+    const target = transferTarget()
+    if (!target) {
+      toast.error('请选择目标知识库')
+      return
+    }
+
+    const ids = selected()
+    setTransferFailures([])
+    try {
+      const res = await ragflowApi.batchTransfer(selectedDataset(), target, ids)
+      setTransferProgress({
+        type: 'status',
+        job_id: res.data.id,
+        status: res.data.status,
+        total: ids.length,
+        done: 0,
+        failed: 0,
+      })
+      watchTransfer(res.data.id)
+    } catch (err) {
+      toast.error('转移失败: ' + (err as Error).message)
+    }
+  }
+
+  const transferPercent = () => {
+    const p = transferProgress()
+    if (!p || p.total === 0) return 0
+    return Math.round((p.done / p.total) * 100)
+  }
+
   const getStatusBadge = (status: string) => {
     switch (status) {
       case 'done':
@@ -190,12 +285,22 @@ const Documents: Component = () => {
           <h1 class="text-2xl font-bold text-white">文档管理</h1>
           <p class="text-sm text-dark-400 mt-1">管理 RagFlow 知识库中的文档</p>
         </div>
-        <button class="btn btn-primary" onClick={() => setShowUploadModal(true)}>
-          <svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24">
-            <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 16v1a3 3 0 003 3h10a3 3 0 003-3v-1m-4-8l-4-4m0 0L8 8m4-4v12" />
-          </svg>
-          上传文档
-        </button>
+        <div class="flex gap-2">
+          <Show when={selected().length > 0 || transferring()}>
+            <button class="btn btn-secondary" onClick={openTransfer}>
+              <Show when={transferring()} fallback={<>转移所选 ({selected().length})</>}>
+                <div class="loading-spinner" />
+                转移中 {transferPercent()}%
+              </Show>
+            </button>
+          </Show>
+          <button class="btn btn-primary" onClick={() => setShowUploadModal(true)}>
+            <svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+              <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 16v1a3 3 0 003 3h10a3 3 0 003-3v-1m-4-8l-4-4m0 0L8 8m4-4v12" />
+            </svg>
+            上传文档
+          </button>
+        </div>
       </div>
 
       {/* Dataset Selector */}
@@ -245,6 +350,13 @@ const Documents: Component = () => {
           <table class="table">
             <thead>
               <tr>
+                <th class="w-10">
+                  <input
+                    type="checkbox"
+                    checked={allSelected()}
+                    onChange={(e) => setSelected(e.currentTarget.checked ? documents().map(d => d.id) : [])}
+                  />
+                </th>
                 <th>文件名</th>
                 <th>状态</th>
                 <th>分块数</th>
@@ -257,7 +369,7 @@ const Documents: Component = () => {
                 when={!loading()}
                 fallback={
                   <tr>
-                    <td colspan="5" class="text-center py-12">
+                    <td colspan="6" class="text-center py-12">
                       <div class="loading-spinner mx-auto" />
                       <p class="mt-3 text-dark-400">加载中...</p>
                     </td>
@@ -268,7 +380,7 @@ const Documents: Component = () => {
                   when={documents().length > 0}
                   fallback={
                     <tr>
-                      <td colspan="5">
+                      <td colspan="6">
                         <div class="empty-state">
                           <svg class="empty-state-icon" fill="none" stroke="currentColor" viewBox="0 0 24 24">
                             <path stroke-linecap="round" stroke-linejoin="round" stroke-width="1.5" d="M9 12h6m-6 4h6m2 5H7a2 2 0 01-2-2V5a2 2 0 012-2h5.586a1 1 0 01.707.293l5.414 5.414a1 1 0 01.293.707V19a2 2 0 01-2 2z" />
@@ -283,6 +395,13 @@ const Documents: Component = () => {
                   <For each={documents()}>
                     {(doc) => (
                       <tr class="group">
+                        <td>
+                          <input
+                            type="checkbox"
+                            checked={selected().includes(doc.id)}
+                            onChange={(e) => toggleSelected(doc.id, e.currentTarget.checked)}
+                          />
+                        </td>
                         <td>
                           <div class="flex items-center gap-2">
                             <svg class="w-5 h-5 text-dark-500" fill="none" stroke="currentColor" viewBox="0 0 24 24">
@@ -354,6 +473,73 @@ const Documents: Component = () => {
         </div>
       </Show>
 
+      {/* Transfer Modal */}
+      <Modal
+        open={showTransferModal()}
+        onClose={() => setShowTransferModal(false)}
+        title="转移文档"
+        size="lg"
+        footer={
+          <>
+            <button type="button" class="btn btn-secondary" onClick={() => setShowTransferModal(false)}>
+              {transferProgress() ? '关闭' : '取消'}
+            </button>
+            <Show when={!transferProgress()}>
+              <button type="button" class="btn btn-primary" onClick={handleTransfer}>
+                开始转移
+              </button>
+            </Show>
+          </>
+        }
+      >
+        <div class="space-y-4">
+          <div>
+            <label class="label">将选中的 {transferProgress()?.total ?? selected().length} 个文档转移到</label>
+            <select
+              class="input"
+              value={transferTarget()}
+              disabled={transferProgress() !== null}
+              onChange={(e) => setTransferTarget(e.currentTarget.value)}
+            >
+              <option value="">选择目标知识库</option>
+              <For each={datasets().filter(ds => ds.dataset_id !== selectedDataset())}>
+                {(ds) => <option value={ds.dataset_id}>{ds.display_name || ds.name}</option>}
+              </For>
+            </select>
+          </div>
+
+          <Show when={transferProgress()}>
+            <div>
+              <div class="flex items-center justify-between text-sm text-dark-300 mb-2">
+                <span>
+                  {transferProgress()!.status === 'queued' ? '排队中' : transferring() ? '转移中' : '已完成'}
+                </span>
+                <span>
+                  {transferProgress()!.done} / {transferProgress()!.total}
+                  <Show when={transferProgress()!.failed > 0}>
+                    <span class="text-red-400 ml-2">失败 {transferProgress()!.failed}</span>
+                  </Show>
+                </span>
+              </div>
+              <div class="w-full h-2 bg-dark-700 rounded-full overflow-hidden">
+                <div class="h-full bg-primary-500 transition-all" style={{ width: `${transferPercent()}%` }} />
+              </div>
+              <p class="text-xs text-dark-500 mt-2">关闭窗口不会中断转移，可随时重新打开查看进度</p>
+            </div>
+          </Show>
+
+          <Show when={transferFailures().length > 0}>
+            <div class="max-h-40 overflow-y-auto p-3 bg-dark-700/50 rounded-xl border border-dark-600/50 space-y-1">
+              <For each={transferFailures()}>
+                {(f) => (
+                  <p class="text-xs font-mono text-red-400 break-all">{f.id}: {f.error}</p>
+                )}
+              </For>
+            </div>
+          </Show>
+        </div>
+      </Modal>
+
       {/* Upload Modal */}
       <Modal
         open={showUploadModal()}
@@ -2,23 +2,54 @@ import { Component, createSignal, createResource, For, Show } from 'solid-js'
 import { rssApi, tagsApi } from '@/api'
 import { useToast } from '@/components/Toast'
 import Modal from '@/components/Modal'
-import type { RSSFeed } from '@/types'
+import type { RSSFeed, FeedEntry, FeedFilters, FeedPreview, WebSubSubscription } from '@/types'
+
+const filtersPlaceholder = `{
+  "include": [{ "field": "title", "keyword": "golang" }],
+  "exclude": [{ "field": "any", "regex": "(?i)sponsored|广告" }],
+  "min_content_length": 200
+}`
 
 const RSSFeeds: Component = () => {
   const toast = useToast()
   const [page, setPage] = createSignal(1)
   const [keyword, setKeyword] = createSignal('')
+  const [health, setHealth] = createSignal<'' | 'healthy' | 'unhealthy'>('')
   const [showModal, setShowModal] = createSignal(false)
   const [editing, setEditing] = createSignal<RSSFeed | null>(null)
   const [submitting, setSubmitting] = createSignal(false)
+  const [entriesFeed, setEntriesFeed] = createSignal<RSSFeed | null>(null)
+  const [entriesPage, setEntriesPage] = createSignal(1)
+  const [preview, setPreview] = createSignal<FeedPreview | null>(null)
+  const [previewing, setPreviewing] = createSignal(false)
+  const [fetchingId, setFetchingId] = createSignal<number | null>(null)
+  const [websub, setWebsub] = createSignal<WebSubSubscription | null>(null)
 
   const [feeds, { refetch }] = createResource(
-    () => ({ page: page(), keyword: keyword() }),
-    ({ page, keyword }) => rssApi.list(page, 20, '', keyword)
+    () => ({ page: page(), keyword: keyword(), health: health() }),
+    ({ page, keyword, health }) => rssApi.list(page, 20, '', keyword, health)
   )
 
   const [allTags] = createResource(() => tagsApi.list(1, 100))
 
+  const [entries] = createResource(
+    () => entriesFeed() && { id: entriesFeed()!.id, page: entriesPage() },
+    ({ id, page }) => rssApi.entries(id, page, 20)
+  )
+
+  const entryStatusBadge: Record<FeedEntry['status'], { label: string; class: string }> = {
+    new: { label: '新条目', class: 'badge-gray' },
+    ingested: { label: '已入库', class: 'badge-success' },
+    duplicate: { label: '重复', class: 'badge-warning' },
+    failed: { label: '入库失败', class: 'badge-danger' },
+    skipped: { label: '已过滤', class: 'badge-gray' },
+  }
+
+  const openEntries = (feed: RSSFeed) => {
+    setEntriesPage(1)
+    setEntriesFeed(feed)
+  }
+
   const [form, setForm] = createSignal({
     name: '',
     url: '',
@@ -26,7 +57,10 @@ const RSSFeeds: Component = () => {
     description: '',
     is_active: true,
     fetch_interval_minutes: 60,
+    auto_ingest: false,
+    extract_full_text: false,
     tag_ids: [] as number[],
+    filters: '',
   })
 
   const openCreateModal = () => {
@@ -38,8 +72,13 @@ const RSSFeeds: Component = () => {
       description: '',
       is_active: true,
       fetch_interval_minutes: 60,
+      auto_ingest: false,
+      extract_full_text: false,
       tag_ids: [],
+      filters: '',
     })
+    setPreview(null)
+    setWebsub(null)
     setShowModal(true)
   }
 
@@ -52,20 +91,36 @@ const RSSFeeds: Component = () => {
       description: feed.description,
       is_active: feed.is_active,
       fetch_interval_minutes: feed.fetch_interval_minutes,
+      auto_ingest: feed.auto_ingest,
+      extract_full_text: feed.extract_full_text,
       tag_ids: feed.tags?.map((t) => t.id) || [],
+      filters: feed.metadata?.filters ? JSON.stringify(feed.metadata.filters, null, 2) : '',
     })
+    setPreview(null)
+    setWebsub(null)
     setShowModal(true)
+    rssApi.websub(feed.id).then((res) => setWebsub(res.data)).catch(() => {})
   }
 
   const handleSubmit = async (e: Event) => {
     e.preventDefault()
+    let filters: FeedFilters = {}
+    if (form().filters.trim()) {
+      try {
+        filters = JSON.parse(form().filters)
+      } catch {
+        toast.error('过滤规则不是有效的 JSON')
+        return
+      }
+    }
+    const data = { ...form(), filters }
     setSubmitting(true)
     try {
       if (editing()) {
-        await rssApi.update(editing()!.id, form())
+        await rssApi.update(editing()!.id, data)
         toast.success('RSS 订阅更新成功')
       } else {
-        await rssApi.create(form())
+        await rssApi.create(data)
         toast.success('RSS 订阅创建成功')
       }
       setShowModal(false)
@@ -88,6 +143,62 @@ const RSSFeeds: Component = () => {
     }
   }
 
+  // Parses the URL without saving; empty name/description fields are prefilled from the feed
+  const handlePreview = async () => {
+    if (!form().url) return
+    setPreviewing(true)
+    try {
+      const { data } = await rssApi.preview(form().url)
+      setPreview(data)
+      setForm({
+        ...form(),
+        name: form().name || data.title,
+        description: form().description || data.description,
+      })
+    } catch (err) {
+      setPreview(null)
+      toast.error('预览失败: ' + (err as Error).message)
+    } finally {
+      setPreviewing(false)
+    }
+  }
+
+  const handleFetchNow = async (feed: RSSFeed) => {
+    setFetchingId(feed.id)
+    try {
+      const { data } = await rssApi.fetch(feed.id)
+      if (data.not_modified) {
+        toast.success(`${feed.name}: 内容未变化`)
+      } else {
+        toast.success(`${feed.name}: 新增 ${data.new}，更新 ${data.updated}，过滤 ${data.skipped}`)
+      }
+      refetch()
+    } catch (err) {
+      toast.error('抓取失败: ' + (err as Error).message)
+    } finally {
+      setFetchingId(null)
+    }
+  }
+
+  let opmlInput: HTMLInputElement | undefined
+
+  const handleImportOpml = async (e: Event) => {
+    const input = e.currentTarget as HTMLInputElement
+    const file = input.files?.[0]
+    input.value = ''
+    if (!file) return
+    try {
+      const { data } = await rssApi.importOpml(file)
+      toast.success(`OPML 导入完成: 新建 ${data.created}，跳过 ${data.skipped}，失败 ${data.failed}`)
+      data.results
+        .filter((r) => r.status === 'failed')
+        .forEach((r) => toast.error(`${r.url}: ${r.message}`))
+      refetch()
+    } catch (err) {
+      toast.error('导入失败: ' + (err as Error).message)
+    }
+  }
+
   const toggleTag = (tagId: number) => {
     const current = form().tag_ids
     if (current.includes(tagId)) {
@@ -117,12 +228,21 @@ const RSSFeeds: Component = () => {
           <h1 class="text-2xl font-bold text-white">RSS 订阅管理</h1>
           <p class="text-sm text-dark-400 mt-1">管理自动抓取的 RSS 订阅源</p>
         </div>
-        <button class="btn btn-primary" onClick={openCreateModal}>
-          <svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24">
-            <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M12 4v16m8-8H4" />
-          </svg>
-          新建订阅
-        </button>
+        <div class="flex items-center gap-2">
+          <input ref={opmlInput} type="file" accept=".opml,.xml,text/xml,text/x-opml" class="hidden" onChange={handleImportOpml} />
+          <button class="btn btn-secondary" onClick={() => opmlInput?.click()}>
+            导入 OPML
+          </button>
+          <a class="btn btn-secondary" href={rssApi.exportOpmlUrl} download>
+            导出 OPML
+          </a>
+          <button class="btn btn-primary" onClick={openCreateModal}>
+            <svg class="w-5 h-5" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+              <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M12 4v16m8-8H4" />
+            </svg>
+            新建订阅
+          </button>
+        </div>
       </div>
 
       {/* Search */}
@@ -143,6 +263,18 @@ const RSSFeeds: Component = () => {
               }}
             />
           </div>
+          <select
+            class="input w-36"
+            value={health()}
+            onChange={(e) => {
+              setHealth(e.currentTarget.value as '' | 'healthy' | 'unhealthy')
+              setPage(1)
+            }}
+          >
+            <option value="">全部状态</option>
+            <option value="healthy">正常</option>
+            <option value="unhealthy">异常</option>
+          </select>
           <Show when={keyword()}>
             <button class="btn btn-ghost btn-sm" onClick={() => setKeyword('')}>
               清除
@@ -243,6 +375,14 @@ const RSSFeeds: Component = () => {
                             <span class={feed.is_active ? 'text-emerald-400' : 'text-dark-500'}>
                               {feed.is_active ? '启用' : '禁用'}
                             </span>
+                            <Show when={feed.unhealthy}>
+                              <span
+                                class="badge badge-danger"
+                                title={`连续失败 ${feed.consecutive_failures} 次${feed.last_status_code ? ` (HTTP ${feed.last_status_code})` : ''}: ${feed.last_error || ''}`}
+                              >
+                                抓取异常
+                              </span>
+                            </Show>
                           </div>
                         </td>
                         <td>
@@ -252,6 +392,25 @@ const RSSFeeds: Component = () => {
                         </td>
                         <td class="text-right">
                           <div class="flex items-center justify-end gap-1 opacity-0 group-hover:opacity-100 transition-opacity">
+                            <button
+                              class="btn btn-ghost btn-sm"
+                              title="立即抓取"
+                              disabled={fetchingId() === feed.id}
+                              onClick={() => handleFetchNow(feed)}
+                            >
+                              <svg class={`w-4 h-4 ${fetchingId() === feed.id ? 'animate-spin' : ''}`} fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                                <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 4v5h.582m15.356 2A8.001 8.001 0 004.582 9m0 0H9m11 11v-5h-.581m0 0a8.003 8.003 0 01-15.357-2m15.357 2H15" />
+                              </svg>
+                            </button>
+                            <button
+                              class="btn btn-ghost btn-sm"
+                              title="查看条目"
+                              onClick={() => openEntries(feed)}
+                            >
+                              <svg class="w-4 h-4" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                                <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M4 6h16M4 10h16M4 14h16M4 18h16" />
+                              </svg>
+                            </button>
                             <button
                               class="btn btn-ghost btn-sm"
                               onClick={() => openEditModal(feed)}
@@ -349,14 +508,55 @@ const RSSFeeds: Component = () => {
           </div>
           <div>
             <label class="label">RSS URL *</label>
-            <input
-              type="url"
-              class="input font-mono"
-              required
-              placeholder="https://example.com/feed.xml"
-              value={form().url}
-              onInput={(e) => setForm({ ...form(), url: e.currentTarget.value })}
-            />
+            <div class="flex gap-2">
+              <input
+                type="url"
+                class="input font-mono flex-1"
+                required
+                placeholder="https://example.com/feed.xml"
+                value={form().url}
+                onInput={(e) => setForm({ ...form(), url: e.currentTarget.value })}
+              />
+              <button
+                type="button"
+                class="btn btn-secondary"
+                disabled={!form().url || previewing()}
+                onClick={handlePreview}
+              >
+                {previewing() ? '解析中...' : '预览'}
+              </button>
+            </div>
+            <Show when={preview()}>
+              <div class="mt-2 p-3 bg-dark-700/50 rounded-xl border border-dark-600/50 text-sm">
+                <p class="text-dark-300">
+                  <span class="badge badge-gray mr-2">{preview()!.format}</span>
+                  共 {preview()!.total_items} 条，最新条目：
+                </p>
+                <ul class="mt-2 space-y-1">
+                  <For each={preview()!.items.slice(0, 5)}>
+                    {(item) => (
+                      <li class="truncate text-dark-400">
+                        {item.title || item.link}
+                        <Show when={item.published}>
+                          <span class="text-dark-500 ml-2">{new Date(item.published!).toLocaleDateString('zh-CN')}</span>
+                        </Show>
+                      </li>
+                    )}
+                  </For>
+                </ul>
+              </div>
+            </Show>
+            <Show when={websub()}>
+              <p class="mt-2 text-xs text-dark-400" title={websub()!.last_error || websub()!.hub_url}>
+                WebSub 推送：
+                <span class={`badge ${websub()!.state === 'active' ? 'badge-success' : websub()!.state === 'pending' ? 'badge-gray' : 'badge-danger'}`}>
+                  {websub()!.state}
+                </span>
+                <Show when={websub()!.last_push_at}>
+                  <span class="ml-2">最近推送 {new Date(websub()!.last_push_at!).toLocaleString('zh-CN')}</span>
+                </Show>
+              </p>
+            </Show>
           </div>
           <div class="grid grid-cols-2 gap-4">
             <div>
@@ -429,8 +629,129 @@ const RSSFeeds: Component = () => {
               <span class="ms-3 text-sm font-medium text-dark-300">启用订阅</span>
             </label>
           </div>
+          <div class="flex items-center gap-3">
+            <label class="relative inline-flex items-center cursor-pointer">
+              <input
+                type="checkbox"
+                class="sr-only peer"
+                checked={form().auto_ingest}
+                onChange={(e) => setForm({ ...form(), auto_ingest: e.currentTarget.checked })}
+              />
+              <div class="w-11 h-6 bg-dark-700 peer-focus:outline-none peer-focus:ring-2 peer-focus:ring-primary-500 rounded-full peer peer-checked:after:translate-x-full rtl:peer-checked:after:-translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:start-[2px] after:bg-white after:border-gray-300 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:bg-primary-600"></div>
+              <span class="ms-3 text-sm font-medium text-dark-300">自动入库 (按标签/分类路由到 RagFlow)</span>
+            </label>
+          </div>
+          <div class="flex items-center gap-3">
+            <label class="relative inline-flex items-center cursor-pointer">
+              <input
+                type="checkbox"
+                class="sr-only peer"
+                checked={form().extract_full_text}
+                onChange={(e) => setForm({ ...form(), extract_full_text: e.currentTarget.checked })}
+              />
+              <div class="w-11 h-6 bg-dark-700 peer-focus:outline-none peer-focus:ring-2 peer-focus:ring-primary-500 rounded-full peer peer-checked:after:translate-x-full rtl:peer-checked:after:-translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:start-[2px] after:bg-white after:border-gray-300 after:border after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:bg-primary-600"></div>
+              <span class="ms-3 text-sm font-medium text-dark-300">全文提取 (入库时抓取原文链接的正文)</span>
+            </label>
+          </div>
+          <div>
+            <label class="label">过滤规则 (JSON，可选)</label>
+            <textarea
+              class="input resize-none font-mono text-xs"
+              rows="5"
+              placeholder={filtersPlaceholder}
+              value={form().filters}
+              onInput={(e) => setForm({ ...form(), filters: e.currentTarget.value })}
+            />
+            <p class="text-xs text-dark-500 mt-1">
+              字段可选 title / content / author / any；命中 exclude、未命中任一 include 或正文短于 min_content_length 的新条目记为“已过滤”，不会入库
+            </p>
+          </div>
         </form>
       </Modal>
+
+      {/* Entries Modal */}
+      <Modal
+        open={entriesFeed() !== null}
+        onClose={() => setEntriesFeed(null)}
+        title={`抓取条目 - ${entriesFeed()?.name ?? ''}`}
+        size="xl"
+      >
+        <Show
+          when={!entries.loading}
+          fallback={
+            <div class="text-center py-8">
+              <div class="loading-spinner mx-auto" />
+              <p class="mt-3 text-dark-400">加载中...</p>
+            </div>
+          }
+        >
+          <Show
+            when={entries()?.data && entries()!.data.length > 0}
+            fallback={
+              <div class="empty-state">
+                <p class="empty-state-title">暂无条目</p>
+                <p class="empty-state-description">订阅尚未抓取到任何内容</p>
+              </div>
+            }
+          >
+            <ul class="divide-y divide-dark-700/50 max-h-[60vh] overflow-y-auto">
+              <For each={entries()?.data}>
+                {(entry) => (
+                  <li class="py-3">
+                    <a
+                      href={entry.link}
+                      target="_blank"
+                      rel="noopener noreferrer"
+                      class="font-medium text-white hover:text-primary-400"
+                    >
+                      {entry.title || entry.link}
+                    </a>
+                    <div class="flex items-center gap-3 mt-1 text-xs text-dark-400">
+                      <span
+                        class={`badge ${entryStatusBadge[entry.status]?.class ?? 'badge-gray'}`}
+                        title={entry.status_message}
+                      >
+                        {entryStatusBadge[entry.status]?.label ?? entry.status}
+                      </span>
+                      <Show when={entry.author}>
+                        <span>{entry.author}</span>
+                      </Show>
+                      <span>
+                        {entry.published_at
+                          ? new Date(entry.published_at).toLocaleString('zh-CN')
+                          : new Date(entry.created_at).toLocaleString('zh-CN')}
+                      </span>
+                    </div>
+                  </li>
+                )}
+              </For>
+            </ul>
+            <Show when={entries()!.total > 20}>
+              <div class="flex items-center justify-between mt-4">
+                <div class="text-sm text-dark-400">
+                  共 <span class="text-dark-200 font-medium">{entries()!.total}</span> 条
+                </div>
+                <div class="flex gap-2">
+                  <button
+                    class="btn btn-secondary btn-sm"
+                    disabled={entriesPage() === 1}
+                    onClick={() => setEntriesPage((p) => p - 1)}
+                  >
+                    上一页
+                  </button>
+                  <button
+                    class="btn btn-secondary btn-sm"
+                    disabled={entriesPage() * 20 >= (entries()?.total || 0)}
+                    onClick={() => setEntriesPage((p) => p + 1)}
+                  >
+                    下一页
+                  </button>
+                </div>
+              </div>
+            </Show>
+          </Show>
+        </Show>
+      </Modal>
     </div>
   )
 }
@@ -2,7 +2,32 @@ import { Component, createSignal, createResource, For, Show } from 'solid-js'
 import { webhooksApi } from '@/api'
 import { useToast } from '@/components/Toast'
 import Modal from '@/components/Modal'
-import type { WebhookConfig, WebhookHistory } from '@/types'
+import type { EventFilters, WebhookConfig, WebhookDeliveryStatus, WebhookHistory, WebhookSubscription } from '@/types'
+
+// eventPatterns lists * and, for every event type, its prefixes and itself
+const eventPatterns = (types: string[]) => {
+  const patterns = ['*']
+  for (const type of types) {
+    const parts = type.split('.')
+    for (let i = 1; i < parts.length; i++) {
+      const prefix = parts.slice(0, i).join('.') + '.*'
+      if (!patterns.includes(prefix)) patterns.push(prefix)
+    }
+    patterns.push(type)
+  }
+  return patterns
+}
+
+const splitList = (value: string) =>
+  value.split(/[,，]/).map((v) => v.trim()).filter(Boolean)
+
+const describeFilters = (filters?: EventFilters | null) => {
+  const parts: string[] = []
+  if (filters?.tags?.length) parts.push(`标签: ${filters.tags.join(', ')}`)
+  if (filters?.categories?.length) parts.push(`分类: ${filters.categories.join(', ')}`)
+  if (filters?.dataset_ids?.length) parts.push(`知识库: ${filters.dataset_ids.join(', ')}`)
+  return parts.join('；') || '全部事件'
+}
 
 const Webhooks: Component = () => {
   const toast = useToast()
@@ -13,17 +38,34 @@ const Webhooks: Component = () => {
   const [selectedWebhook, setSelectedWebhook] = createSignal<WebhookConfig | null>(null)
   const [submitting, setSubmitting] = createSignal(false)
   const [triggering, setTriggering] = createSignal<number | null>(null)
+  const [expanded, setExpanded] = createSignal<number | null>(null)
+  const [attempts, setAttempts] = createSignal<WebhookHistory[]>([])
+  const [showSubscriptionsModal, setShowSubscriptionsModal] = createSignal(false)
+  const [subscribing, setSubscribing] = createSignal(false)
+  const [subscriptionForm, setSubscriptionForm] = createSignal({
+    event_type: '*',
+    tags: '',
+    categories: '',
+    dataset_ids: '',
+  })
 
   const [webhooks, { refetch }] = createResource(
     () => page(),
     (page) => webhooksApi.list(page, 20)
   )
 
-  const [history] = createResource(
+  const [history, { refetch: refetchHistory }] = createResource(
     () => selectedWebhook()?.id,
     (id) => (id ? webhooksApi.history(id) : null)
   )
 
+  const [subscriptions, { refetch: refetchSubscriptions }] = createResource(
+    () => (showSubscriptionsModal() ? selectedWebhook()?.id : undefined),
+    (id) => webhooksApi.subscriptions(id)
+  )
+
+  const [eventTypes] = createResource(() => webhooksApi.events())
+
   const [form, setForm] = createSignal({
     name: '',
     url: '',
@@ -34,6 +76,11 @@ const Webhooks: Component = () => {
     timeout_seconds: 30,
     description: '',
     is_active: true,
+    max_attempts: 3,
+    retry_delay_seconds: 30,
+    retry_status_codes: '',
+    signing_secret: '',
+    clear_signing_secret: false,
   })
 
   const openCreateModal = () => {
@@ -48,6 +95,11 @@ const Webhooks: Component = () => {
       timeout_seconds: 30,
       description: '',
       is_active: true,
+      max_attempts: 3,
+      retry_delay_seconds: 30,
+      retry_status_codes: '',
+      signing_secret: '',
+      clear_signing_secret: false,
     })
     setShowModal(true)
   }
@@ -68,15 +120,136 @@ const Webhooks: Component = () => {
       timeout_seconds: webhook.timeout_seconds,
       description: webhook.description,
       is_active: webhook.is_active,
+      max_attempts: webhook.max_attempts,
+      retry_delay_seconds: webhook.retry_delay_seconds,
+      retry_status_codes: webhook.retry_status_codes?.join(', ') ?? '',
+      signing_secret: '',
+      clear_signing_secret: false,
     })
     setShowModal(true)
   }
 
   const openHistoryModal = (webhook: WebhookConfig) => {
     setSelectedWebhook(webhook)
+    setExpanded(null)
     setShowHistoryModal(true)
   }
 
+  const openSubscriptionsModal = (webhook: WebhookConfig) => {
+    setSelectedWebhook(webhook)
+    setSubscriptionForm({ event_type: '*', tags: '', categories: '', dataset_ids: '' })
+    setShowSubscriptionsModal(true)
+  }
+
+  const handleSubscribe = async (e: Event) => {
+    e.preventDefault()
+    const webhook = selectedWebhook()
+    if (!webhook) return
+    setSubscribing(true)
+    try {
+      const f = subscriptionForm()
+      await webhooksApi.createSubscription(webhook.id, {
+        event_type: f.event_type,
+        filters: {
+          tags: splitList(f.tags),
+          categories: splitList(f.categories),
+          dataset_ids: splitList(f.dataset_ids),
+        },
+      })
+      toast.success('订阅已添加')
+      setSubscriptionForm({ event_type: '*', tags: '', categories: '', dataset_ids: '' })
+      refetchSubscriptions()
+    } catch (err) {
+      toast.error('添加订阅失败: ' + (err as Error).message)
+    } finally {
+      setSubscribing(false)
+    }
+  }
+
+  const toggleSubscription = async (sub: WebhookSubscription) => {
+    try {
+      await webhooksApi.updateSubscription(sub.webhook_id, sub.id, {
+        event_type: sub.event_type,
+        filters: sub.filters,
+        is_active: !sub.is_active,
+      })
+      refetchSubscriptions()
+    } catch (err) {
+      toast.error('更新订阅失败: ' + (err as Error).message)
+    }
+  }
+
+  const handleUnsubscribe = async (sub: WebhookSubscription) => {
+    if (!confirm(`确定要删除订阅 "${sub.event_type}" 吗？`)) return
+    try {
+      await webhooksApi.deleteSubscription(sub.webhook_id, sub.id)
+      toast.success('订阅已删除')
+      refetchSubscriptions()
+    } catch (err) {
+      toast.error('删除订阅失败: ' + (err as Error).message)
+    }
+  }
+
+  const toggleAttempts = async (h: WebhookHistory) => {
+    if (expanded() === h.id) {
+      setExpanded(null)
+      return
+    }
+    try {
+      const res = await webhooksApi.attempts(h.id)
+      setAttempts(res.data)
+      setExpanded(h.id)
+    } catch (err) {
+      toast.error('加载失败: ' + (err as Error).message)
+    }
+  }
+
+  const handleRequeue = async (h: WebhookHistory) => {
+    try {
+      await webhooksApi.requeue(h.id)
+      toast.success('已重新加入重试队列')
+      refetchHistory()
+    } catch (err) {
+      toast.error('重新入队失败: ' + (err as Error).message)
+    }
+  }
+
+  const handleReplay = async (h: WebhookHistory, useCurrentUrl = false) => {
+    if (!confirm(`确定要重新发送这次调用${useCurrentUrl ? '到当前 URL' : ''}吗？`)) return
+    try {
+      const result = await webhooksApi.replay(h.id, useCurrentUrl)
+      if (result.data.status === 'success') {
+        toast.success(`重放成功，响应码: ${result.data.response_code}`)
+      } else {
+        toast.warning(`重放完成但返回错误: ${result.data.error_message || result.data.response_code}`)
+      }
+    } catch (err) {
+      toast.error('重放失败: ' + (err as Error).message)
+    } finally {
+      refetchHistory()
+    }
+  }
+
+  const statusLabel: Record<WebhookDeliveryStatus, string> = {
+    pending: '进行中',
+    success: '成功',
+    failed: '失败',
+    retrying: '等待重试',
+    dead: '已放弃',
+  }
+
+  const statusClass = (status: WebhookDeliveryStatus) => {
+    if (status === 'success') return 'text-emerald-400'
+    if (status === 'retrying' || status === 'pending') return 'text-amber-400'
+    return 'text-red-400'
+  }
+
+  const statusDot = (status: WebhookDeliveryStatus) => {
+    if (status === 'success') return 'status-dot-success'
+    if (status === 'retrying' || status === 'pending') return 'status-dot-warning'
+    return 'status-dot-danger'
+  }
+
   const handleSubmit = async (e: Event) => {
     e.preventDefault()
     setSubmitting(true)
@@ -89,7 +262,23 @@ const Webhooks: Component = () => {
         setSubmitting(false)
         return
       }
-      const payload = { ...form(), headers }
+      let retryStatusCodes: number[] | undefined
+      const codes = form().retry_status_codes.trim()
+      if (codes) {
+        retryStatusCodes = codes.split(/[\s,]+/).filter(Boolean).map(Number)
+        if (retryStatusCodes.some((c) => !Number.isInteger(c) || c < 100 || c > 599)) {
+          toast.error('重试状态码格式无效')
+          setSubmitting(false)
+          return
+        }
+      }
+      const { clear_signing_secret, signing_secret, ...fields } = form()
+      const payload = {
+        ...fields,
+        headers,
+        retry_status_codes: retryStatusCodes,
+        signing_secret: clear_signing_secret ? '' : signing_secret || undefined,
+      }
       if (editing()) {
         await webhooksApi.update(editing()!.id, payload)
         toast.success('Webhook 更新成功')
@@ -123,6 +312,8 @@ const Webhooks: Component = () => {
       const result = await webhooksApi.trigger(webhook.id)
       if (result.data.status === 'success') {
         toast.success(`触发成功，响应码: ${result.data.response_code}`)
+      } else if (result.data.status === 'retrying') {
+        toast.warning(`触发失败，将自动重试: ${result.data.error_message || result.data.response_code}`)
       } else {
         toast.warning(`触发完成但返回错误: ${result.data.error_message}`)
       }
@@ -208,7 +399,12 @@ const Webhooks: Component = () => {
                           </code>
                         </td>
                         <td>
-                          <span class="badge badge-primary">{webhook.method}</span>
+                          <div class="flex items-center gap-1">
+                            <span class="badge badge-primary">{webhook.method}</span>
+                            <Show when={webhook.signed}>
+                              <span class="badge badge-gray" title="投递已签名">签名</span>
+                            </Show>
+                          </div>
                         </td>
                         <td>
                           <span class="text-dark-400">{webhook.timeout_seconds}s</span>
@@ -237,6 +433,15 @@ const Webhooks: Component = () => {
                                 </svg>
                               )}
                             </button>
+                            <button
+                              class="btn btn-ghost btn-sm"
+                              title="事件订阅"
+                              onClick={() => openSubscriptionsModal(webhook)}
+                            >
+                              <svg class="w-4 h-4" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                                <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M15 17h5l-1.405-1.405A2.032 2.032 0 0118 14.158V11a6.002 6.002 0 00-4-5.659V5a2 2 0 10-4 0v.341C7.67 6.165 6 8.388 6 11v3.159c0 .538-.214 1.055-.595 1.436L4 17h5m6 0v1a3 3 0 11-6 0v-1m6 0H9" />
+                              </svg>
+                            </button>
                             <button
                               class="btn btn-ghost btn-sm"
                               onClick={() => openHistoryModal(webhook)}
@@ -391,6 +596,43 @@ const Webhooks: Component = () => {
               </div>
             </div>
           </div>
+          <div class="grid grid-cols-3 gap-4">
+            <div>
+              <label class="label">最多尝试</label>
+              <input
+                type="number"
+                class="input"
+                min="1"
+                max="20"
+                value={form().max_attempts}
+                onInput={(e) => setForm({ ...form(), max_attempts: parseInt(e.currentTarget.value) || 1 })}
+              />
+            </div>
+            <div>
+              <label class="label">首次重试间隔</label>
+              <div class="relative">
+                <input
+                  type="number"
+                  class="input pr-8"
+                  min="1"
+                  value={form().retry_delay_seconds}
+                  onInput={(e) => setForm({ ...form(), retry_delay_seconds: parseInt(e.currentTarget.value) || 30 })}
+                />
+                <span class="absolute right-3 top-1/2 -translate-y-1/2 text-dark-500 text-sm">秒</span>
+              </div>
+            </div>
+            <div>
+              <label class="label">重试状态码</label>
+              <input
+                type="text"
+                class="input font-mono"
+                placeholder="408, 429, 500, 502, 503, 504"
+                value={form().retry_status_codes}
+                onInput={(e) => setForm({ ...form(), retry_status_codes: e.currentTarget.value })}
+              />
+            </div>
+          </div>
+          <p class="text-xs text-dark-500 -mt-2">网络错误和列出的状态码会按指数退避重试，留空使用默认状态码</p>
           <div>
             <label class="label">Body 模板</label>
             <textarea
@@ -413,6 +655,31 @@ const Webhooks: Component = () => {
             />
             <p class="text-xs text-dark-500 mt-1">JSON 格式的 HTTP 请求头</p>
           </div>
+          <div>
+            <label class="label">签名密钥</label>
+            <input
+              type="password"
+              class="input font-mono"
+              autocomplete="new-password"
+              placeholder={editing()?.signed ? '已设置，留空保持不变' : '留空则不签名'}
+              disabled={form().clear_signing_secret}
+              value={form().signing_secret}
+              onInput={(e) => setForm({ ...form(), signing_secret: e.currentTarget.value })}
+            />
+            <div class="flex items-center justify-between mt-1">
+              <p class="text-xs text-dark-500">设置后每次投递带 X-Bellkeeper-Timestamp 和 X-Bellkeeper-Signature 请求头</p>
+              <Show when={editing()?.signed}>
+                <label class="flex items-center gap-2 text-xs text-dark-400">
+                  <input
+                    type="checkbox"
+                    checked={form().clear_signing_secret}
+                    onChange={(e) => setForm({ ...form(), clear_signing_secret: e.currentTarget.checked })}
+                  />
+                  清除密钥
+                </label>
+              </Show>
+            </div>
+          </div>
           <div>
             <label class="label">描述</label>
             <textarea
@@ -438,6 +705,99 @@ const Webhooks: Component = () => {
         </form>
       </Modal>
 
+      {/* Subscriptions Modal */}
+      <Modal
+        open={showSubscriptionsModal()}
+        onClose={() => setShowSubscriptionsModal(false)}
+        title={`事件订阅 - ${selectedWebhook()?.name || ''}`}
+        size="lg"
+      >
+        <div class="space-y-4">
+          <Show
+            when={subscriptions()?.data && subscriptions()!.data.length > 0}
+            fallback={
+              <p class="text-sm text-dark-500">暂无订阅，Webhook 只在手动或定时触发时调用</p>
+            }
+          >
+            <div class="space-y-2">
+              <For each={subscriptions()?.data}>
+                {(sub) => (
+                  <div class="flex items-center gap-3 p-3 bg-dark-700/50 rounded-xl border border-dark-600/50">
+                    <span class={`status-dot ${sub.is_active ? 'status-dot-success' : 'status-dot-gray'}`} />
+                    <code class="text-sm text-white font-mono">{sub.event_type}</code>
+                    <span class="text-xs text-dark-400 truncate">{describeFilters(sub.filters)}</span>
+                    <div class="ml-auto flex gap-2">
+                      <button class="btn btn-ghost btn-sm" onClick={() => toggleSubscription(sub)}>
+                        {sub.is_active ? '停用' : '启用'}
+                      </button>
+                      <button
+                        class="btn btn-ghost btn-sm text-red-400 hover:text-red-300 hover:bg-red-500/10"
+                        onClick={() => handleUnsubscribe(sub)}
+                      >
+                        删除
+                      </button>
+                    </div>
+                  </div>
+                )}
+              </For>
+            </div>
+          </Show>
+
+          <form onSubmit={handleSubscribe} class="space-y-3 border-t border-dark-600/50 pt-4">
+            <div class="grid grid-cols-2 gap-4">
+              <div>
+                <label class="label">事件类型</label>
+                <select
+                  class="input font-mono"
+                  value={subscriptionForm().event_type}
+                  onChange={(e) => setSubscriptionForm({ ...subscriptionForm(), event_type: e.currentTarget.value })}
+                >
+                  <For each={eventPatterns(eventTypes()?.data ?? [])}>
+                    {(pattern) => <option value={pattern}>{pattern}</option>}
+                  </For>
+                </select>
+              </div>
+              <div>
+                <label class="label">标签</label>
+                <input
+                  type="text"
+                  class="input"
+                  placeholder="多个用逗号分隔"
+                  value={subscriptionForm().tags}
+                  onInput={(e) => setSubscriptionForm({ ...subscriptionForm(), tags: e.currentTarget.value })}
+                />
+              </div>
+              <div>
+                <label class="label">分类</label>
+                <input
+                  type="text"
+                  class="input"
+                  placeholder="多个用逗号分隔"
+                  value={subscriptionForm().categories}
+                  onInput={(e) => setSubscriptionForm({ ...subscriptionForm(), categories: e.currentTarget.value })}
+                />
+              </div>
+              <div>
+                <label class="label">知识库 ID</label>
+                <input
+                  type="text"
+                  class="input font-mono"
+                  placeholder="多个用逗号分隔"
+                  value={subscriptionForm().dataset_ids}
+                  onInput={(e) => setSubscriptionForm({ ...subscriptionForm(), dataset_ids: e.currentTarget.value })}
+                />
+              </div>
+            </div>
+            <div class="flex items-center justify-between">
+              <p class="text-xs text-dark-500">过滤条件留空表示不过滤；事件以 JSON 请求体投递，带 X-Bellkeeper-Event 请求头</p>
+              <button type="submit" class="btn btn-primary btn-sm" disabled={subscribing()}>
+                添加订阅
+              </button>
+            </div>
+          </form>
+        </div>
+      </Modal>
+
       {/* History Modal */}
       <Modal
         open={showHistoryModal()}
@@ -468,11 +828,20 @@ const Webhooks: Component = () => {
                   <div class="p-4 bg-dark-700/50 rounded-xl border border-dark-600/50">
                     <div class="flex items-center justify-between mb-2">
                       <div class="flex items-center gap-2">
-                        <span class={`status-dot ${h.status === 'success' ? 'status-dot-success' : 'status-dot-danger'}`} />
-                        <span class={h.status === 'success' ? 'text-emerald-400 font-medium' : 'text-red-400 font-medium'}>
-                          {h.status === 'success' ? '成功' : '失败'}
+                        <span class={`status-dot ${statusDot(h.status)}`} />
+                        <span class={`${statusClass(h.status)} font-medium`}>
+                          {statusLabel[h.status] ?? h.status}
                         </span>
                         <span class="badge badge-gray">{h.response_code}</span>
+                        <Show when={(h.max_attempts ?? 0) > 1}>
+                          <span class="badge badge-gray">尝试 {h.attempts}/{h.max_attempts}</span>
+                        </Show>
+                        <Show when={h.replay_of}>
+                          <span class="badge badge-gray">重放自 #{h.replay_of}</span>
+                        </Show>
+                        <Show when={h.event_type}>
+                          <span class="badge badge-primary">{h.event_type}</span>
+                        </Show>
                       </div>
                       <span class="text-dark-500 text-sm">
                         {new Date(h.created_at).toLocaleString('zh-CN')}
@@ -483,7 +852,50 @@ const Webhooks: Component = () => {
                     </div>
                     <div class="flex items-center gap-4 text-sm">
                       <span class="text-dark-500">耗时: <span class="text-dark-300">{h.duration_ms}ms</span></span>
+                      <Show when={h.next_retry_at}>
+                        <span class="text-dark-500">
+                          下次重试: <span class="text-dark-300">{new Date(h.next_retry_at!).toLocaleString('zh-CN')}</span>
+                        </span>
+                      </Show>
+                      <div class="ml-auto flex gap-2">
+                        <Show when={(h.attempts ?? 0) > 1}>
+                          <button class="btn btn-ghost btn-sm" onClick={() => toggleAttempts(h)}>
+                            {expanded() === h.id ? '收起尝试' : '查看尝试'}
+                          </button>
+                        </Show>
+                        <Show when={h.status === 'dead'}>
+                          <button class="btn btn-secondary btn-sm" onClick={() => handleRequeue(h)}>
+                            重新入队
+                          </button>
+                        </Show>
+                        <button class="btn btn-ghost btn-sm" onClick={() => handleReplay(h)}>
+                          重放
+                        </button>
+                        <Show when={selectedWebhook() && h.request_url !== selectedWebhook()!.url}>
+                          <button class="btn btn-ghost btn-sm" onClick={() => handleReplay(h, true)}>
+                            按当前 URL 重放
+                          </button>
+                        </Show>
+                      </div>
                     </div>
+                    <Show when={expanded() === h.id}>
+                      <div class="mt-3 space-y-1 border-t border-dark-600/50 pt-3">
+                        <For each={attempts()}>
+                          {(a) => (
+                            <div class="flex items-center gap-3 text-xs">
+                              <span class="text-dark-500">#{a.attempt}</span>
+                              <span class={statusClass(a.status)}>{statusLabel[a.status] ?? a.status}</span>
+                              <span class="text-dark-300">{a.response_code || '-'}</span>
+                              <span class="text-dark-500">{a.duration_ms}ms</span>
+                              <span class="text-dark-500">{new Date(a.created_at).toLocaleString('zh-CN')}</span>
+                              <Show when={a.error_message}>
+                                <span class="text-red-400 truncate">{a.error_message}</span>
+                              </Show>
+                            </div>
+                          )}
+                        </For>
+                      </div>
+                    </Show>
                     <Show when={h.error_message}>
                       <div class="mt-2 text-sm text-red-400 bg-red-500/10 px-3 py-2 rounded-lg">
                         {h.error_message}
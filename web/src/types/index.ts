@@ -17,11 +17,155 @@ export interface DataSource {
   category: string
   description: string
   is_active: boolean
+  extract_full_text: boolean
+  watch: boolean
+  last_crawled_at?: string
+  last_crawl_error?: string
+  metadata?: { crawl?: CrawlSettings; github?: GitHubSettings } & Record<string, unknown>
   tags: Tag[]
+  reachability?: DataSourceStatus
   created_at: string
   updated_at: string
 }
 
+export interface CrawlSettings {
+  max_depth?: number
+  max_pages?: number
+}
+
+export interface DataSourceStatus {
+  id: number
+  data_source_id: number
+  state: 'up' | 'moved' | 'failing' | 'dead'
+  status_code: number
+  latency_ms: number
+  final_url?: string
+  tls_expires_at?: string
+  error?: string
+  consecutive_failures: number
+  checked_at: string
+  last_up_at?: string
+}
+
+export interface GitHubSettings {
+  owner?: string
+  repo?: string
+  include_prereleases?: boolean
+  max_releases?: number
+  readme?: boolean
+  docs?: string[]
+}
+
+export interface GitHubSyncResult {
+  data_source_id: number
+  releases: number
+  files: number
+  unchanged: number
+  duplicate: number
+  skipped: number
+  errors: number
+}
+
+export interface GitHubItem {
+  id: number
+  data_source_id: number
+  kind: 'release' | 'file'
+  ref: string
+  sha?: string
+  title: string
+  url: string
+  document_id?: string
+  dataset_id?: string
+  published_at?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface DataSourceImportResult {
+  row: number
+  name: string
+  url: string
+  status: 'created' | 'updated' | 'unchanged' | 'rejected' | 'failed'
+  data_source_id?: number
+  changes?: string[]
+  message?: string
+}
+
+export interface DataSourceImportSummary {
+  dry_run: boolean
+  total: number
+  created: number
+  updated: number
+  unchanged: number
+  rejected: number
+  failed: number
+  results: DataSourceImportResult[]
+}
+
+export interface CrawlResult {
+  data_source_id: number
+  pages: number
+  discovered: number
+  new: number
+  unchanged: number
+  duplicate: number
+  disallowed: number
+  errors: number
+}
+
+export interface CandidateArticle {
+  id: number
+  data_source_id: number
+  url: string
+  title: string
+  found_on: string
+  depth: number
+  last_modified?: string
+  status: 'new'
+  data_source?: DataSource
+  created_at: string
+  updated_at: string
+}
+
+export interface PageSnapshot {
+  id: number
+  data_source_id: number
+  url: string
+  content_hash: string
+  length: number
+  document_id?: string
+  dataset_id?: string
+  version: number
+  last_change: number
+  checked_at?: string
+  changed_at?: string
+  last_error?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface WatchResult {
+  data_source_id: number
+  status: 'baseline' | 'unchanged' | 'minor' | 'changed'
+  message?: string
+  change: number
+  length: number
+  document_id?: string
+  dataset_id?: string
+  previous_document_id?: string
+}
+
+export interface DataSourceIngestResult {
+  status: 'ingested' | 'duplicate'
+  message?: string
+  document_id?: string
+  dataset_id?: string
+  title: string
+  length: number
+  extracted: boolean
+  cached: boolean
+}
+
 export interface RSSFeed {
   id: number
   name: string
@@ -31,11 +175,116 @@ export interface RSSFeed {
   is_active: boolean
   last_fetched_at: string | null
   fetch_interval_minutes: number
+  auto_ingest: boolean
+  extract_full_text: boolean
+  last_checked_at: string | null
+  last_status_code: number
+  last_error?: string
+  consecutive_failures: number
+  unhealthy: boolean
+  metadata?: { filters?: FeedFilters } & Record<string, unknown>
   tags: Tag[]
   created_at: string
   updated_at: string
 }
 
+export interface FeedFilterRule {
+  field?: 'any' | 'title' | 'content' | 'author'
+  keyword?: string
+  regex?: string
+}
+
+export interface FeedFilters {
+  include?: FeedFilterRule[]
+  exclude?: FeedFilterRule[]
+  min_content_length?: number
+}
+
+export interface FeedEntry {
+  id: number
+  feed_id: number
+  guid: string
+  title: string
+  link: string
+  author: string
+  published_at: string | null
+  summary: string
+  content?: string
+  content_hash: string
+  status: 'new' | 'ingested' | 'duplicate' | 'failed' | 'skipped'
+  status_message?: string
+  document_id?: string
+  dataset_id?: string
+  feed?: RSSFeed
+  created_at: string
+  updated_at: string
+}
+
+export interface WebSubSubscription {
+  id: number
+  feed_id: number
+  hub_url: string
+  topic_url: string
+  state: 'pending' | 'active' | 'denied' | 'failed'
+  lease_seconds: number
+  requested_at?: string
+  expires_at?: string
+  last_push_at?: string
+  last_error?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface FeedFetchResult {
+  feed_id: number
+  title: string
+  format: string
+  items: number
+  new: number
+  updated: number
+  unchanged: number
+  skipped: number
+  ingested: number
+  not_modified: boolean
+}
+
+export interface FeedPreviewItem {
+  guid: string
+  title: string
+  link: string
+  author: string
+  published?: string
+  summary: string
+}
+
+export interface FeedPreview {
+  url: string
+  format: string
+  title: string
+  description: string
+  link: string
+  total_items: number
+  items: FeedPreviewItem[]
+}
+
+export interface OPMLImportResult {
+  url: string
+  name: string
+  category?: string
+  tags?: string[]
+  status: 'created' | 'skipped' | 'failed'
+  feed_id?: number
+  message?: string
+}
+
+export interface OPMLImportSummary {
+  total: number
+  created: number
+  skipped: number
+  failed: number
+  results: OPMLImportResult[]
+}
+
 export interface WebhookConfig {
   id: number
   name: string
@@ -47,22 +296,57 @@ export interface WebhookConfig {
   timeout_seconds: number
   description: string
   is_active: boolean
+  max_attempts: number
+  retry_delay_seconds: number
+  retry_status_codes?: number[] | null
+  // signing_secret is write-only; signed tells whether one is set
+  signing_secret?: string
+  signed: boolean
   created_at: string
   updated_at: string
 }
 
+export type WebhookDeliveryStatus = 'pending' | 'success' | 'failed' | 'retrying' | 'dead'
+
+// A delivery has no parent_id and mirrors its latest attempt; attempt records link to it
 export interface WebhookHistory {
   id: number
   webhook_id: number
   request_url: string
   request_method: string
   request_body: string
-  status: string
+  status: WebhookDeliveryStatus
   response_code: number
   response_body: string
   duration_ms: number
   error_message: string
   created_at: string
+  parent_id?: number
+  attempt?: number
+  attempts?: number
+  max_attempts?: number
+  next_retry_at?: string
+  replay_of?: number
+  event_type?: string
+  webhook?: WebhookConfig
+}
+
+// Every non-empty list must match the event; tags ignore case
+export interface EventFilters {
+  tags?: string[]
+  categories?: string[]
+  dataset_ids?: string[]
+}
+
+// event_type is a type such as document.uploaded, a prefix such as document.* or *
+export interface WebhookSubscription {
+  id: number
+  webhook_id: number
+  event_type: string
+  filters?: EventFilters | null
+  is_active: boolean
+  created_at: string
+  updated_at: string
 }
 
 export interface DatasetMapping {
@@ -132,3 +416,102 @@ export interface WorkflowExecution {
   started_at: string
   stopped_at?: string
 }
+
+export type JobStatus = 'queued' | 'running' | 'succeeded' | 'failed'
+
+export interface Job {
+  id: number
+  type: string
+  status: JobStatus
+  payload?: unknown
+  result?: unknown
+  error?: string
+  attempts: number
+  max_attempts: number
+  total: number
+  done: number
+  failed: number
+  run_at: string
+  started_at?: string
+  finished_at?: string
+  created_at: string
+  updated_at: string
+}
+
+export type JobEventType = 'status' | 'progress' | 'item'
+
+// Event of GET /api/jobs/:id/events; item mirrors an entry of the job results
+export interface JobEvent {
+  type: JobEventType
+  job_id: number
+  status: JobStatus
+  total: number
+  done: number
+  failed: number
+  error?: string
+  item?: Record<string, unknown>
+}
+
+export type ScheduleTask =
+  | 'webhook.trigger'
+  | 'workflow.trigger'
+  | 'datasources.check'
+  | 'webhook_history.purge'
+
+export interface Schedule {
+  id: number
+  name: string
+  description: string
+  cron: string
+  timezone: string
+  task: ScheduleTask
+  params?: Record<string, unknown>
+  is_active: boolean
+  next_run_at?: string
+  last_run_at?: string
+  last_status?: 'succeeded' | 'failed'
+  last_error?: string
+  last_result?: unknown
+  last_duration_ms?: number
+  created_at: string
+  updated_at: string
+}
+
+// Each mapping field is a JSONPath into the payload (starting with $) or a literal value
+export interface InboundMapping {
+  title?: string
+  url?: string
+  content?: string
+  tags?: string[]
+  category?: string
+}
+
+export interface InboundHook {
+  id: number
+  name: string
+  slug: string
+  description: string
+  auth_type: 'token' | 'hmac'
+  // secret is write-only; has_secret tells whether one is set
+  secret?: string
+  has_secret: boolean
+  signature_header?: string
+  timestamp_header?: string
+  mapping: InboundMapping
+  is_active: boolean
+  last_received_at?: string
+  last_status?: 'ingested' | 'duplicate' | 'failed'
+  last_error?: string
+  last_document_id?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface InboundPreview {
+  content: string
+  filename: string
+  title: string
+  url: string
+  tags: string[] | null
+  category: string
+}